@@ -1,17 +1,54 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"LibraryGo/internal/config"
 	"LibraryGo/internal/router"
 )
 
 func main() {
-	r := router.SetupRouter()
+	cfg := config.Load()
+	app := router.NewApp(cfg)
+	slog.SetDefault(app.Logger)
 
-	port := ":8080"
-	fmt.Println("Server is running on port", port)
-	log.Fatal(http.ListenAndServe(port, r))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Jobs are stopped by the shutdown below, once requests have drained, not by the signal
+	app.Scheduler.Start(context.Background())
+
+	server := app.NewServer(cfg)
+	failed := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", server.Addr)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	select {
+	case err := <-failed:
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process without waiting
+	stop()
+
+	slog.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	err := app.Shutdown(shutdownCtx, server, cfg.Server.DrainDelay)
+	cancel()
+	if err != nil {
+		slog.Error("shutdown incomplete", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("shutdown complete")
 }
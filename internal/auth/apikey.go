@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"LibraryGo/internal/model"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrInvalidAPIKey is returned for keys that match no configured key
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKey is a configured static key. Only the SHA-256 of the key is kept, so the
+// configuration never holds a usable secret; keys are long random strings, which
+// makes a slow password hash unnecessary.
+type APIKey struct {
+	Name  string   `json:"name"`
+	Hash  string   `json:"hash"` // Hex SHA-256 of the key, e.g. from `printf %s "$KEY" | sha256sum`
+	Roles []string `json:"roles"`
+}
+
+// HashAPIKey returns the hash an API key is configured by
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyStore authenticates static API keys
+type KeyStore struct {
+	keys   []APIKey
+	hashes [][]byte
+}
+
+// NewKeyStore validates the configured keys
+func NewKeyStore(keys []APIKey) (*KeyStore, error) {
+	store := &KeyStore{}
+	names := make(map[string]bool)
+	for _, key := range keys {
+		hash, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(key.Hash), "sha256:"))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("API key %q: hash must be 64 hex digits of SHA-256", key.Name)
+		}
+		if key.Name == "" || names[key.Name] {
+			return nil, fmt.Errorf("API key %q: names must be present and unique", key.Name)
+		}
+		names[key.Name] = true
+		store.keys = append(store.keys, key)
+		store.hashes = append(store.hashes, hash)
+	}
+	return store, nil
+}
+
+// LoadAPIKeys reads a JSON array of keys from a file
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// Len is the number of configured keys
+func (s *KeyStore) Len() int {
+	return len(s.keys)
+}
+
+// Authenticate returns the principal of a key
+func (s *KeyStore) Authenticate(key string) (model.Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	// Compare with every key in constant time so timing reveals nothing about near misses
+	match := -1
+	for i, hash := range s.hashes {
+		if subtle.ConstantTimeCompare(sum[:], hash) == 1 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return model.Principal{}, ErrInvalidAPIKey
+	}
+	matched := s.keys[match]
+	return model.Principal{ID: "key:" + matched.Name, Name: matched.Name, Kind: model.PrincipalAPIKey, Roles: matched.Roles}, nil
+}
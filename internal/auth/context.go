@@ -0,0 +1,21 @@
+// Package auth verifies API keys and JWT bearer tokens and carries the authenticated
+// principal through request contexts
+package auth
+
+import (
+	"LibraryGo/internal/model"
+	"context"
+)
+
+type contextKey struct{}
+
+// WithPrincipal returns a context carrying the principal
+func WithPrincipal(ctx context.Context, principal model.Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// PrincipalFrom returns the principal of a request context, if it was authenticated
+func PrincipalFrom(ctx context.Context) (model.Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(model.Principal)
+	return principal, ok
+}
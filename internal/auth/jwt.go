@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"LibraryGo/internal/model"
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Supported JWT signature algorithms
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// Errors returned when verifying tokens
+var (
+	ErrInvalidToken = errors.New("invalid bearer token")
+	ErrTokenExpired = errors.New("bearer token has expired")
+)
+
+// DefaultLeeway allows for clock drift between the token issuer and this server
+const DefaultLeeway = time.Minute
+
+// Key is a verification key of a key set
+type Key struct {
+	ID        string
+	Algorithm string
+	secret    []byte
+	public    *rsa.PublicKey
+}
+
+// KeySet holds the keys tokens may be signed with
+type KeySet struct {
+	keys []Key
+}
+
+// NewKeySet creates an empty key set
+func NewKeySet() *KeySet {
+	return &KeySet{}
+}
+
+// AddHMAC adds a shared secret for HS256; id may be empty for a set with a single key
+func (ks *KeySet) AddHMAC(id string, secret []byte) {
+	ks.keys = append(ks.keys, Key{ID: id, Algorithm: HS256, secret: secret})
+}
+
+// AddRSA adds a public key for RS256
+func (ks *KeySet) AddRSA(id string, public *rsa.PublicKey) {
+	ks.keys = append(ks.keys, Key{ID: id, Algorithm: RS256, public: public})
+}
+
+// Len is the number of keys
+func (ks *KeySet) Len() int {
+	return len(ks.keys)
+}
+
+// jwk is a JSON Web Key as found in a JWKS document
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	K   string `json:"k"` // Symmetric key
+	N   string `json:"n"` // RSA modulus
+	E   string `json:"e"` // RSA exponent
+}
+
+// ParseJWKS reads the keys of a JSON Web Key Set. Symmetric ("oct") keys are used for
+// HS256 and RSA keys for RS256; encryption keys and other key types are skipped.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	ks := NewKeySet()
+	for _, key := range document.Keys {
+		if key.Use == "enc" {
+			continue
+		}
+		switch key.Kty {
+		case "oct":
+			if key.Alg != "" && key.Alg != HS256 {
+				continue
+			}
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("key %q: invalid symmetric key", key.Kid)
+			}
+			ks.AddHMAC(key.Kid, secret)
+		case "RSA":
+			if key.Alg != "" && key.Alg != RS256 {
+				continue
+			}
+			n, errN := base64.RawURLEncoding.DecodeString(key.N)
+			e, errE := base64.RawURLEncoding.DecodeString(key.E)
+			if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("key %q: invalid RSA public key", key.Kid)
+			}
+			ks.AddRSA(key.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())})
+		}
+	}
+	return ks, nil
+}
+
+// LoadKeySet reads a JWKS file
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ks, err := ParseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ks, nil
+}
+
+// Claims are the registered and library claims of a token
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Name      string   `json:"name"`
+	Roles     []string `json:"roles"`
+	PatronID  int      `json:"patron_id"` // Set in tokens issued to library members
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	IssuedAt  int64    `json:"iat"`
+	Nonce     string   `json:"nonce"` // Binds an OpenID Connect ID token to the login that asked for it
+}
+
+// audience is the aud claim, which is a string or an array of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var single string
+		if err := json.Unmarshal(data, &single); err != nil {
+			return err
+		}
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// JWTVerifier checks the signature and validity of bearer tokens
+type JWTVerifier struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+	now      func() time.Time
+
+	Leeway time.Duration
+}
+
+// NewJWTVerifier creates a verifier; an empty issuer or audience is not checked
+func NewJWTVerifier(keys *KeySet, issuer, audience string) *JWTVerifier {
+	return &JWTVerifier{keys: keys, issuer: issuer, audience: audience, now: time.Now, Leeway: DefaultLeeway}
+}
+
+// SetClock replaces the clock, for tests
+func (v *JWTVerifier) SetClock(now func() time.Time) {
+	v.now = now
+}
+
+// Verify checks a compact JWS token and returns its claims. Only HS256 and RS256
+// are accepted, each with keys of its own kind, so a public key can never be
+// used as an HMAC secret.
+func (v *JWTVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if !v.verifySignature(header.Alg, header.Kid, signed, signature) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	now := v.now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(v.Leeway)) {
+		return Claims{}, ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(v.Leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return Claims{}, ErrInvalidToken
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return Claims{}, ErrInvalidToken
+	}
+	if v.audience != "" && !contains(claims.Audience, v.audience) {
+		return Claims{}, ErrInvalidToken
+	}
+	if claims.Subject == "" {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// Authenticate verifies a token and returns its principal
+func (v *JWTVerifier) Authenticate(token string) (model.Principal, error) {
+	claims, err := v.Verify(token)
+	if err != nil {
+		return model.Principal{}, err
+	}
+	return model.Principal{ID: claims.Subject, Name: claims.Name, Kind: model.PrincipalToken, Roles: claims.Roles,
+		PatronID: claims.PatronID}, nil
+}
+
+// verifySignature tries the keys named by kid, or every key of the algorithm without one
+func (v *JWTVerifier) verifySignature(alg, kid string, signed, signature []byte) bool {
+	if alg != HS256 && alg != RS256 {
+		return false
+	}
+	digest := sha256.Sum256(signed)
+	for _, key := range v.keys.keys {
+		if key.Algorithm != alg || kid != "" && key.ID != kid {
+			continue
+		}
+		switch alg {
+		case HS256:
+			mac := hmac.New(sha256.New, key.secret)
+			mac.Write(signed)
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return true
+			}
+		case RS256:
+			if rsa.VerifyPKCS1v15(key.public, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeSegment decodes a base64url JSON segment of a token
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by OIDCClient
+var (
+	ErrProviderUnavailable = errors.New("identity provider is unavailable")
+	ErrCodeExchange        = errors.New("authorization code was not accepted")
+	ErrNonceMismatch       = errors.New("ID token was issued for another login")
+)
+
+// OpenID Connect caching defaults
+const (
+	DefaultDiscoveryTTL = time.Hour
+	DefaultKeysTTL      = time.Hour
+	minKeysRefresh      = time.Minute // Unknown key IDs refetch the key set at most this often
+	maxProviderResponse = 1 << 20
+)
+
+// OIDCConfig describes the client registered with an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer       string // Provider URL; its discovery document is at /.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string // Empty for public clients, which rely on PKCE alone
+	RedirectURL  string // Callback URL registered with the provider
+	Scopes       []string
+}
+
+// Discovery is the part of a provider's discovery document the login flow needs
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCClient runs the authorization code flow with PKCE against one provider. The
+// discovery document and key set are fetched on first use and cached; the key set
+// is also refetched early when a token names a key it does not hold, so the
+// provider can rotate keys without a restart.
+type OIDCClient struct {
+	config OIDCConfig
+	client *http.Client
+	now    func() time.Time
+
+	DiscoveryTTL time.Duration
+	KeysTTL      time.Duration
+
+	mu           sync.Mutex // Guards the cached discovery document and keys
+	discovery    *Discovery
+	discoveredAt time.Time
+	keys         *KeySet
+	keysAt       time.Time
+}
+
+// NewOIDCClient creates a client; nothing is fetched until the first login
+func NewOIDCClient(config OIDCConfig, client *http.Client) *OIDCClient {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile"}
+	}
+	return &OIDCClient{config: config, client: client, now: time.Now, DiscoveryTTL: DefaultDiscoveryTTL, KeysTTL: DefaultKeysTTL}
+}
+
+// SetClock replaces the clock, for tests
+func (c *OIDCClient) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// NewPKCEVerifier returns a random code verifier and its S256 challenge
+func NewPKCEVerifier() (verifier, challenge string, err error) {
+	verifier, err = RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomToken returns 32 random bytes in base64url, for states, nonces and session IDs
+func RandomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// AuthCodeURL returns the provider URL a browser is sent to for logging in
+func (c *OIDCClient) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	discovery, err := c.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"scope":                 {strings.Join(c.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the claims of the verified ID token
+func (c *OIDCClient) Exchange(ctx context.Context, code, verifier, nonce string) (Claims, error) {
+	discovery, err := c.Discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {c.config.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProviderResponse)).Decode(&body); err != nil {
+		return Claims{}, fmt.Errorf("%w: token response: %v", ErrProviderUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		if body.Error == "" {
+			body.Error = resp.Status
+		}
+		return Claims{}, fmt.Errorf("%w: %s %s", ErrCodeExchange, body.Error, body.ErrorDescription)
+	}
+	return c.VerifyIDToken(ctx, body.IDToken, nonce)
+}
+
+// VerifyIDToken checks an ID token was signed by the provider for this client and
+// this login
+func (c *OIDCClient) VerifyIDToken(ctx context.Context, token, nonce string) (Claims, error) {
+	discovery, err := c.Discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+	keys, err := c.keySet(ctx, false)
+	if err != nil {
+		return Claims{}, err
+	}
+	verify := func(keys *KeySet) (Claims, error) {
+		verifier := NewJWTVerifier(keys, discovery.Issuer, c.config.ClientID)
+		verifier.SetClock(c.now)
+		return verifier.Verify(token)
+	}
+	claims, err := verify(keys)
+	if errors.Is(err, ErrInvalidToken) {
+		// The provider may have rotated its keys since they were cached
+		if fresh, refreshErr := c.keySet(ctx, true); refreshErr == nil && fresh != keys {
+			claims, err = verify(fresh)
+		}
+	}
+	if err != nil {
+		return Claims{}, err
+	}
+	if claims.Nonce != nonce {
+		return Claims{}, ErrNonceMismatch
+	}
+	return claims, nil
+}
+
+// Discover returns the provider's discovery document, fetching it when the cached
+// copy is missing or stale
+func (c *OIDCClient) Discover(ctx context.Context) (Discovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.discovery != nil && c.now().Sub(c.discoveredAt) < c.DiscoveryTTL {
+		return *c.discovery, nil
+	}
+
+	var discovery Discovery
+	endpoint := strings.TrimSuffix(c.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(ctx, endpoint, &discovery); err != nil {
+		if c.discovery != nil {
+			// A stale document beats failing every login while the provider is down
+			return *c.discovery, nil
+		}
+		return Discovery{}, err
+	}
+	if discovery.Issuer != c.config.Issuer {
+		return Discovery{}, fmt.Errorf("%w: discovery names issuer %q instead of %q", ErrProviderUnavailable, discovery.Issuer, c.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return Discovery{}, fmt.Errorf("%w: discovery document is incomplete", ErrProviderUnavailable)
+	}
+	c.discovery = &discovery
+	c.discoveredAt = c.now()
+	return discovery, nil
+}
+
+// keySet returns the cached key set, fetching it when missing or stale. refresh asks for
+// a new copy, which is only fetched when the cached one is older than minKeysRefresh.
+func (c *OIDCClient) keySet(ctx context.Context, refresh bool) (*KeySet, error) {
+	discovery, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	age := c.now().Sub(c.keysAt)
+	if c.keys != nil && age < c.KeysTTL && (!refresh || age < minKeysRefresh) {
+		return c.keys, nil
+	}
+
+	var raw json.RawMessage
+	if err := c.getJSON(ctx, discovery.JWKSURI, &raw); err != nil {
+		if c.keys != nil {
+			return c.keys, nil
+		}
+		return nil, err
+	}
+	keys, err := ParseJWKS(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	c.keys = keys
+	c.keysAt = c.now()
+	return keys, nil
+}
+
+// getJSON fetches and decodes a provider document
+func (c *OIDCClient) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: GET %s: %s", ErrProviderUnavailable, endpoint, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProviderResponse)).Decode(out); err != nil {
+		return fmt.Errorf("%w: GET %s: %v", ErrProviderUnavailable, endpoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"LibraryGo/internal/model"
+	"sort"
+)
+
+// Roles a principal can hold
+const (
+	RoleAnonymous = "anonymous" // Callers without credentials
+	RolePatron    = "patron"    // Library members, through the patron-facing clients
+	RoleStaff     = "staff"
+	RoleAdmin     = "admin"
+)
+
+// Permissions routes are declared with
+const (
+	PermPublic            = "public" // Granted to every caller, even without credentials
+	PermCatalogRead       = "catalog:read"
+	PermCatalogWrite      = "catalog:write"
+	PermCatalogDelete     = "catalog:delete"
+	PermCirculationRead   = "circulation:read"
+	PermCirculationWrite  = "circulation:write"
+	PermPatronsRead       = "patrons:read"
+	PermPatronsWrite      = "patrons:write"
+	PermReviewsWrite      = "reviews:write"
+	PermReviewsModerate   = "reviews:moderate"
+	PermCollectionsWrite  = "collections:write"  // Create reading lists and change one's own
+	PermCollectionsManage = "collections:manage" // Read and change every patron's reading lists
+	PermBranchesRead      = "branches:read"
+	PermBranchesWrite     = "branches:write"
+	PermLabelsPrint       = "labels:print"
+	PermJobsRead          = "jobs:read"
+	PermJobsRun           = "jobs:run"
+	PermMetricsRead       = "metrics:read"
+)
+
+// Anonymous is the principal of requests made without credentials
+var Anonymous = model.Principal{ID: "anonymous", Kind: model.PrincipalAnonymous, Roles: []string{RoleAnonymous}}
+
+// Policy grants permissions to roles
+type Policy struct {
+	roles map[string]map[string]bool
+}
+
+// NewPolicy creates a policy from the permissions of each role
+func NewPolicy(roles map[string][]string) *Policy {
+	policy := &Policy{roles: make(map[string]map[string]bool)}
+	for role, permissions := range roles {
+		policy.roles[role] = make(map[string]bool)
+		for _, permission := range permissions {
+			policy.roles[role][permission] = true
+		}
+	}
+	return policy
+}
+
+// DefaultPolicy lets anonymous callers search the catalogue, patrons keep their own
+// reading lists, staff run the catalogue and the circulation desk, and admins also
+// delete records, run jobs and read metrics
+func DefaultPolicy() *Policy {
+	anonymous := []string{PermCatalogRead}
+	patron := append([]string{PermReviewsWrite, PermCollectionsWrite}, anonymous...)
+	staff := append([]string{
+		PermCatalogWrite, PermCirculationRead, PermCirculationWrite, PermPatronsRead, PermPatronsWrite,
+		PermReviewsWrite, PermReviewsModerate, PermCollectionsWrite, PermCollectionsManage,
+		PermBranchesRead, PermBranchesWrite, PermLabelsPrint,
+	}, anonymous...)
+	admin := append([]string{PermCatalogDelete, PermJobsRead, PermJobsRun, PermMetricsRead}, staff...)
+	return NewPolicy(map[string][]string{
+		RoleAnonymous: anonymous,
+		RolePatron:    patron,
+		RoleStaff:     staff,
+		RoleAdmin:     admin,
+	})
+}
+
+// Allows reports whether any role of the principal grants the permission.
+// Unknown roles grant nothing.
+func (p *Policy) Allows(principal model.Principal, permission string) bool {
+	if permission == PermPublic {
+		return true
+	}
+	for _, role := range principal.Roles {
+		if p.roles[role][permission] {
+			return true
+		}
+	}
+	return false
+}
+
+// Actor returns who the principal acts as on patrons' records, with staff rights
+// over every patron's records when it holds permission
+func (p *Policy) Actor(principal model.Principal, permission string) model.Actor {
+	return model.Actor{ID: principal.ID, PatronID: principal.PatronID, Staff: p.Allows(principal, permission)}
+}
+
+// Permissions lists what the principal may do
+func (p *Policy) Permissions(principal model.Principal) []string {
+	granted := make(map[string]bool)
+	for _, role := range principal.Roles {
+		for permission := range p.roles[role] {
+			granted[permission] = true
+		}
+	}
+	permissions := make([]string, 0, len(granted))
+	for permission := range granted {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"LibraryGo/internal/model"
+	"sync"
+	"time"
+)
+
+// SessionCookie is the cookie a browser session's ID is kept in
+const SessionCookie = "library_session"
+
+// LoginStateCookie binds a login in progress to the browser that started it, so a
+// callback carrying someone else's state is refused
+const LoginStateCookie = "library_login"
+
+// DefaultSessionTTL is how long a staff login lasts, about a working day
+const DefaultSessionTTL = 10 * time.Hour
+
+// Session is a logged-in browser
+type Session struct {
+	ID        string
+	Principal model.Principal
+	ExpiresAt time.Time
+}
+
+// SessionStore keeps browser sessions in memory; a restart logs everyone out
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewSessionStore creates a store whose sessions last ttl
+func NewSessionStore(ttl time.Duration) *SessionStore {
+	return &SessionStore{sessions: make(map[string]Session), ttl: ttl, now: time.Now}
+}
+
+// SetClock replaces the clock, for tests
+func (s *SessionStore) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Create starts a session for a principal under a new random ID
+func (s *SessionStore) Create(principal model.Principal) (Session, error) {
+	id, err := RandomToken()
+	if err != nil {
+		return Session{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+	session := Session{ID: id, Principal: principal, ExpiresAt: now.Add(s.ttl)}
+	s.sessions[id] = session
+	return session, nil
+}
+
+// Get returns a session that has not expired
+func (s *SessionStore) Get(id string) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	if !s.now().Before(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return Session{}, false
+	}
+	return session, true
+}
+
+// Delete ends a session
+func (s *SessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
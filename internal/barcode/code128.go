@@ -0,0 +1,115 @@
+package barcode
+
+// code128Patterns are the bar and space widths of every Code 128 symbol value;
+// each adds up to 11 modules, and the stop pattern to 13
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Code 128 control values
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// EncodeCode128 encodes printable ASCII as Code 128. Runs of digits are packed two to
+// a symbol in code set C, which keeps numeric barcodes short enough for spine labels.
+func EncodeCode128(data string) (Symbol, error) {
+	if data == "" {
+		return Symbol{}, ErrEmpty
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] < 32 || data[i] > 126 {
+			return Symbol{}, ErrInvalidChar
+		}
+	}
+
+	var values []int
+	set := 0 // Code set in use, none until the start code
+	for i := 0; i < len(data); {
+		if set == code128StartC {
+			values = append(values, code128CodeB)
+			set = code128StartB
+		}
+
+		digits := digitRun(data, i)
+		if worthSetC(digits, i == 0, i+digits == len(data)) {
+			if digits%2 == 1 {
+				// The odd digit goes in set B so the rest pairs up
+				if set == 0 {
+					values = append(values, code128StartB)
+					set = code128StartB
+				}
+				values = append(values, int(data[i])-32)
+				i++
+				digits--
+			}
+			if set == 0 {
+				values = append(values, code128StartC)
+			} else {
+				values = append(values, code128CodeC)
+			}
+			set = code128StartC
+			for end := i + digits; i < end; i += 2 {
+				values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+			}
+			continue
+		}
+
+		if set == 0 {
+			values = append(values, code128StartB)
+			set = code128StartB
+		}
+		values = append(values, int(data[i])-32)
+		i++
+	}
+
+	checksum := values[0]
+	for i := 1; i < len(values); i++ {
+		checksum += i * values[i]
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, value := range values {
+		for i, width := range code128Patterns[value] {
+			for n := 0; n < int(width-'0'); n++ {
+				modules = append(modules, i%2 == 0)
+			}
+		}
+	}
+	return Symbol{Modules: [][]bool{modules}, Quiet: 10, Linear: true}, nil
+}
+
+// worthSetC reports whether a run of digits is long enough to pay for switching to set C
+func worthSetC(digits int, atStart, atEnd bool) bool {
+	if atStart && atEnd {
+		return digits%2 == 0 || digits >= 4
+	}
+	if atStart || atEnd {
+		return digits >= 4
+	}
+	return digits >= 6
+}
+
+// digitRun counts the digits starting at i
+func digitRun(data string, i int) int {
+	n := 0
+	for i+n < len(data) && data[i+n] >= '0' && data[i+n] <= '9' {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,427 @@
+package barcode
+
+// qrVersion describes the error correction blocks of one QR Code version at level M,
+// which recovers from about 15% damage: enough for labels that get scuffed on the shelf
+type qrVersion struct {
+	ecPerBlock int
+	blocks     []int // Data codewords of each block
+	alignment  []int // Centre coordinates of the alignment patterns
+}
+
+// qrVersions are versions 1 to 10, holding up to 213 bytes
+var qrVersions = []qrVersion{
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// qrCode is a symbol under construction
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool // Finder, timing, alignment, format and version modules, which masks leave alone
+}
+
+// EncodeQR encodes data as a QR Code in byte mode at error correction level M,
+// choosing the smallest version that fits and the mask with the lowest penalty
+func EncodeQR(data []byte) (Symbol, error) {
+	if len(data) == 0 {
+		return Symbol{}, ErrEmpty
+	}
+
+	version := 0
+	for v := 1; v <= len(qrVersions); v++ {
+		if 4+countBits(v)+8*len(data) <= 8*dataCodewords(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return Symbol{}, ErrTooLong
+	}
+
+	codewords := interleave(version, encodeData(version, data))
+
+	qr := newQRCode(version)
+	qr.drawFunctionPatterns(version)
+	qr.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask)
+		qr.drawFormatBits(mask)
+		if penalty := qr.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		qr.applyMask(mask) // Masks are XORs, so applying one again undoes it
+	}
+	qr.applyMask(best)
+	qr.drawFormatBits(best)
+
+	return Symbol{Modules: qr.modules, Quiet: 4}, nil
+}
+
+// countBits is the length of the byte count in the data header
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// dataCodewords is how many data codewords a version holds
+func dataCodewords(version int) int {
+	total := 0
+	for _, n := range qrVersions[version-1].blocks {
+		total += n
+	}
+	return total
+}
+
+// encodeData builds the data codewords: mode, count, bytes, terminator and padding
+func encodeData(version int, data []byte) []byte {
+	var bits []bool
+	appendBits := func(value, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>i&1 == 1)
+		}
+	}
+	appendBits(0x4, 4) // Byte mode
+	appendBits(len(data), countBits(version))
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+
+	capacity := 8 * dataCodewords(version)
+	for i := 0; i < 4 && len(bits) < capacity; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	for pad := 0; len(bits) < capacity; pad++ {
+		if pad%2 == 0 {
+			appendBits(0xEC, 8)
+		} else {
+			appendBits(0x11, 8)
+		}
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return codewords
+}
+
+// interleave splits the data into blocks, appends each block's error correction
+// and interleaves the blocks codeword by codeword
+func interleave(version int, data []byte) []byte {
+	layout := qrVersions[version-1]
+	generator := rsGenerator(layout.ecPerBlock)
+
+	var dataBlocks, ecBlocks [][]byte
+	for _, n := range layout.blocks {
+		dataBlocks = append(dataBlocks, data[:n])
+		ecBlocks = append(ecBlocks, rsRemainder(data[:n], generator))
+		data = data[n:]
+	}
+
+	var result []byte
+	longest := layout.blocks[len(layout.blocks)-1]
+	for i := 0; i < longest; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < layout.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// newQRCode allocates a symbol of a version
+func newQRCode(version int) *qrCode {
+	size := 17 + 4*version
+	qr := &qrCode{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range qr.modules {
+		qr.modules[i] = make([]bool, size)
+		qr.function[i] = make([]bool, size)
+	}
+	return qr
+}
+
+// set places a function module at column x, row y
+func (qr *qrCode) set(x, y int, dark bool) {
+	qr.modules[y][x] = dark
+	qr.function[y][x] = true
+}
+
+// drawFunctionPatterns draws everything but the data, reserving the format area
+func (qr *qrCode) drawFunctionPatterns(version int) {
+	for i := 0; i < qr.size; i++ {
+		qr.set(6, i, i%2 == 0)
+		qr.set(i, 6, i%2 == 0)
+	}
+
+	for _, corner := range [][2]int{{3, 3}, {qr.size - 4, 3}, {3, qr.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := corner[0]+dx, corner[1]+dy
+				if x >= 0 && x < qr.size && y >= 0 && y < qr.size {
+					distance := max(abs(dx), abs(dy))
+					qr.set(x, y, distance != 2 && distance != 4)
+				}
+			}
+		}
+	}
+
+	positions := qrVersions[version-1].alignment
+	last := len(positions) - 1
+	for i, cy := range positions {
+		for j, cx := range positions {
+			// Alignment patterns never overlap the finders
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					qr.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	qr.drawFormatBits(0)
+	if version >= 7 {
+		remainder := version
+		for i := 0; i < 12; i++ {
+			remainder = remainder<<1 ^ (remainder>>11)*0x1F25
+		}
+		bits := version<<12 | remainder
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := qr.size-11+i%3, i/3
+			qr.set(a, b, dark)
+			qr.set(b, a, dark)
+		}
+	}
+}
+
+// drawFormatBits writes the error correction level and mask, twice, next to the finders
+func (qr *qrCode) drawFormatBits(mask int) {
+	data := 0<<3 | mask // Level M is 00
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		qr.set(8, i, bit(i))
+	}
+	qr.set(8, 7, bit(6))
+	qr.set(8, 8, bit(7))
+	qr.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qr.set(qr.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.set(8, qr.size-15+i, bit(i))
+	}
+	qr.set(8, qr.size-8, true) // Always dark
+}
+
+// drawCodewords fills the data area in the zigzag order, two columns at a time from the bottom right
+func (qr *qrCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < qr.size; vertical++ {
+			y := vertical
+			if upward {
+				y = qr.size - 1 - vertical
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if qr.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				qr.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by one of the eight mask patterns
+func (qr *qrCode) applyMask(mask int) {
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if qr.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			qr.modules[y][x] = qr.modules[y][x] != invert
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan: long runs, 2x2 blocks,
+// finder look-alikes and an unbalanced share of dark modules all count against it
+func (qr *qrCode) penalty() int {
+	penalty, dark := 0, 0
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < qr.size; a++ {
+			line := make([]bool, qr.size)
+			for b := 0; b < qr.size; b++ {
+				if vertical {
+					line[b] = qr.modules[b][a]
+				} else {
+					line[b] = qr.modules[a][b]
+				}
+			}
+			penalty += linePenalty(line)
+		}
+	}
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if qr.modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				c := qr.modules[y][x]
+				if c == qr.modules[y-1][x] && c == qr.modules[y][x-1] && c == qr.modules[y-1][x-1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	percent := dark * 100 / (qr.size * qr.size)
+	penalty += abs(percent-50) / 5 * 10
+	return penalty
+}
+
+// finderLike is the 1:1:3:1:1 pattern with four light modules on one side
+var finderLike = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// linePenalty scores one row or column for runs of five or more and finder look-alikes
+func linePenalty(line []bool) int {
+	penalty := 0
+	for i := 0; i < len(line); {
+		run := 1
+		for i+run < len(line) && line[i+run] == line[i] {
+			run++
+		}
+		if run >= 5 {
+			penalty += 3 + run - 5
+		}
+		i += run
+	}
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			matches := true
+			for j, dark := range pattern {
+				if line[i+j] != dark {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				penalty += 40
+			}
+		}
+	}
+	return penalty
+}
+
+// rsGenerator returns the Reed-Solomon generator polynomial of a degree, highest
+// coefficient dropped, over GF(256) with the QR Code field polynomial 0x11D
+func rsGenerator(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 2)
+	}
+	return result
+}
+
+// rsRemainder computes the error correction codewords of a block
+func rsRemainder(data, generator []byte) []byte {
+	result := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range generator {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(256)
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,151 @@
+// Package barcode encodes Code 128 and QR Code symbols and renders them as PNG or SVG
+package barcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// Symbologies
+const (
+	Code128 = "code128"
+	QR      = "qr"
+)
+
+// Errors returned when encoding
+var (
+	ErrUnsupported = errors.New("unsupported symbology")
+	ErrEmpty       = errors.New("nothing to encode")
+	ErrInvalidChar = errors.New("Code 128 encodes printable ASCII only")
+	ErrTooLong     = errors.New("data is too long for the symbol")
+)
+
+// Symbol is an encoded barcode: rows of dark (true) and light modules. Linear codes
+// have a single row that is stretched to the bar height when rendered.
+type Symbol struct {
+	Modules [][]bool
+	Quiet   int // Light modules required around the symbol
+	Linear  bool
+}
+
+// Encode encodes data in the given symbology
+func Encode(symbology, data string) (Symbol, error) {
+	switch symbology {
+	case Code128:
+		return EncodeCode128(data)
+	case QR:
+		return EncodeQR([]byte(data))
+	}
+	return Symbol{}, ErrUnsupported
+}
+
+// Width is the width in modules including the quiet zone
+func (s Symbol) Width() int {
+	if len(s.Modules) == 0 {
+		return 0
+	}
+	return len(s.Modules[0]) + 2*s.Quiet
+}
+
+// rowHeight is how tall one row is drawn: bars of linear symbols span the whole height
+func (s Symbol) rowHeight(module, barHeight float64) float64 {
+	if s.Linear {
+		return barHeight
+	}
+	return module
+}
+
+// Image draws the symbol with square modules of the given number of pixels;
+// bars of linear symbols are barHeight pixels tall
+func (s Symbol) Image(module, barHeight int) image.Image {
+	rowHeight := int(s.rowHeight(float64(module), float64(barHeight)))
+	quietY := s.Quiet * module
+	if s.Linear {
+		quietY = 0
+	}
+	img := image.NewGray(image.Rect(0, 0, s.Width()*module, len(s.Modules)*rowHeight+2*quietY))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for row, modules := range s.Modules {
+		for col, dark := range modules {
+			if !dark {
+				continue
+			}
+			x0, y0 := (col+s.Quiet)*module, quietY+row*rowHeight
+			for y := y0; y < y0+rowHeight; y++ {
+				for x := x0; x < x0+module; x++ {
+					img.SetGray(x, y, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// PNG encodes the symbol as a PNG image, see Image
+func (s Symbol) PNG(module, barHeight int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, s.Image(module, barHeight)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the symbol as a standalone SVG document sized in pixels
+func (s Symbol) SVG(module, barHeight int) []byte {
+	rowHeight := s.rowHeight(float64(module), float64(barHeight))
+	quietY := float64(s.Quiet * module)
+	if s.Linear {
+		quietY = 0
+	}
+	width := float64(s.Width() * module)
+	height := float64(len(s.Modules))*rowHeight + 2*quietY
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" shape-rendering="crispEdges">`,
+		number(width), number(height), number(width), number(height))
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		s.Path(float64(s.Quiet*module), quietY, float64(module), rowHeight))
+	return buf.Bytes()
+}
+
+// Path returns SVG path data drawing the dark modules from (x, y), without the quiet zone
+func (s Symbol) Path(x, y, module, rowHeight float64) string {
+	var path strings.Builder
+	s.Runs(func(row, col, length int) {
+		fmt.Fprintf(&path, "M%s %sh%sv%sh-%sz", number(x+float64(col)*module), number(y+float64(row)*rowHeight),
+			number(float64(length)*module), number(rowHeight), number(float64(length)*module))
+	})
+	return path.String()
+}
+
+// Runs calls draw for every horizontal run of dark modules, so renderers can draw
+// one rectangle per run instead of one per module
+func (s Symbol) Runs(draw func(row, col, length int)) {
+	for row, modules := range s.Modules {
+		for col := 0; col < len(modules); {
+			if !modules[col] {
+				col++
+				continue
+			}
+			length := 1
+			for col+length < len(modules) && modules[col+length] {
+				length++
+			}
+			draw(row, col, length)
+			col += length
+		}
+	}
+}
+
+// number formats a coordinate without trailing zeros
+func number(value float64) string {
+	formatted := strings.TrimRight(fmt.Sprintf("%.3f", value), "0")
+	return strings.TrimSuffix(formatted, ".")
+}
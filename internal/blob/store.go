@@ -0,0 +1,322 @@
+// Package blob stores binary objects such as cover images under slash-separated keys
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Errors returned by stores
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps blobs by key, e.g. "covers/12/small". Open and Create stream blobs too
+// large to hold in memory at once.
+type Store interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Open(key string) (io.ReadCloser, error)
+	Create(key string) (Writer, error)
+	Delete(key string) error
+}
+
+// Writer streams a new blob into a store. Nothing is stored until Close, which
+// replaces any blob under the key at once; Abort discards what was written.
+type Writer interface {
+	io.WriteCloser
+	Abort() error
+}
+
+// probeKey is written and removed again by Check
+const probeKey = "health/probe"
+
+// Check writes, reads back and deletes a small blob, to tell whether the store is usable
+func Check(store Store) error {
+	probe := []byte("ok")
+	if err := store.Put(probeKey, probe); err != nil {
+		return err
+	}
+	data, err := store.Get(probeKey)
+	if err != nil {
+		return err
+	}
+	if string(data) != string(probe) {
+		return errors.New("blob read back differs from the one written")
+	}
+	return store.Delete(probeKey)
+}
+
+// MemoryStore keeps blobs in memory only
+type MemoryStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: make(map[string][]byte)}
+}
+
+// Put stores a copy of the data
+func (s *MemoryStore) Put(key string, data []byte) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blobs[key] = append([]byte(nil), data...)
+	return nil
+}
+
+// Get returns a copy of the stored data
+func (s *MemoryStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, exists := s.blobs[key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Open reads the stored data
+func (s *MemoryStore) Open(key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, exists := s.blobs[key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	// Put never changes a stored slice in place, so readers can share it
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Create buffers the data and stores it on Close
+func (s *MemoryStore) Create(key string) (Writer, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+	return &memoryWriter{store: s, key: key}, nil
+}
+
+// memoryWriter collects a blob for MemoryStore.Create
+type memoryWriter struct {
+	store *MemoryStore
+	key   string
+	buf   bytes.Buffer
+	done  bool
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, os.ErrClosed
+	}
+	return w.buf.Write(p)
+}
+
+func (w *memoryWriter) Close() error {
+	if w.done {
+		return os.ErrClosed
+	}
+	w.done = true
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+
+	w.store.blobs[w.key] = w.buf.Bytes()
+	return nil
+}
+
+func (w *memoryWriter) Abort() error {
+	w.done = true
+	w.buf = bytes.Buffer{}
+	return nil
+}
+
+// Delete removes a blob; deleting a missing blob is not an error
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blobs, key)
+	return nil
+}
+
+// FileStore keeps blobs as files below a directory on the local filesystem. Every Put
+// and Delete is on disk when it returns, so nothing is lost on a crash or restart.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store rooted at dir, which is created on first write
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Put writes the blob atomically via a temporary file, and syncs the file and the
+// directories it went into
+func (s *FileStore) Put(key string, data []byte) error {
+	w, err := s.Create(key)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+// Create starts writing a blob to a temporary file next to its final place. Close
+// moves it there as Put does.
+func (s *FileStore) Create(key string) (Writer, error) {
+	name, err := s.file(key)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(name)
+	_, statErr := os.Stat(dir)
+	created := errors.Is(statErr, os.ErrNotExist)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &fileWriter{store: s, tmp: tmp, name: name, created: created}, nil
+}
+
+// fileWriter writes a blob for FileStore.Create
+type fileWriter struct {
+	store   *FileStore
+	tmp     *os.File
+	name    string
+	created bool // Whether Create made the blob's directory
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	return w.tmp.Write(p)
+}
+
+func (w *fileWriter) Close() error {
+	defer os.Remove(w.tmp.Name())
+
+	if err := w.tmp.Sync(); err != nil {
+		w.tmp.Close()
+		return err
+	}
+	if err := w.tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.tmp.Name(), w.name); err != nil {
+		return err
+	}
+	dir := filepath.Dir(w.name)
+	if err := syncDir(dir); err != nil {
+		return err
+	}
+	// New directories are only durable once the entries in their parents are
+	if w.created {
+		for parent := filepath.Dir(dir); ; parent = filepath.Dir(parent) {
+			if err := syncDir(parent); err != nil {
+				return err
+			}
+			if parent == filepath.Dir(w.store.dir) || parent == filepath.Dir(parent) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (w *fileWriter) Abort() error {
+	w.tmp.Close()
+	return os.Remove(w.tmp.Name())
+}
+
+// Get reads a blob
+func (s *FileStore) Get(key string) ([]byte, error) {
+	name, err := s.file(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Open opens a blob for reading
+func (s *FileStore) Open(key string) (io.ReadCloser, error) {
+	name, err := s.file(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Delete removes a blob; deleting a missing blob is not an error
+func (s *FileStore) Delete(key string) error {
+	name, err := s.file(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(name))
+}
+
+// syncDir flushes the entries of a directory to disk
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", dir, err)
+	}
+	return nil
+}
+
+// file maps a key to its path, refusing keys that would leave the directory
+func (s *FileStore) file(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// validKey accepts clean relative slash paths
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	return path.Clean(key) == key && key != "." && !strings.HasPrefix(key, "../") && key != ".."
+}
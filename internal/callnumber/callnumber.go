@@ -0,0 +1,205 @@
+// Package callnumber parses Dewey Decimal and Library of Congress call numbers
+// and orders them the way they stand on the shelf.
+package callnumber
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Classification schemes
+const (
+	SchemeDewey = "dewey"
+	SchemeLC    = "lc"
+)
+
+// ErrInvalid is returned when a string is not a Dewey or LC call number
+var ErrInvalid = errors.New("invalid call number")
+
+// Cutter is an author or title mark such as "R69h" or ".G63".
+// Its digits are a decimal fraction, so "G5" files after "G48".
+type Cutter struct {
+	Letters string
+	Digits  string
+	Suffix  string // Dewey work mark, e.g. the "h" of "R69h"
+}
+
+// CallNumber is a parsed call number
+type CallNumber struct {
+	Scheme   string
+	Class    string // LC class letters; empty for Dewey
+	Number   string // Integer part of the class number, without leading zeros
+	Decimal  string // Fraction digits of the class number
+	Cutters  []Cutter
+	Extra    []string // Dates, volumes and copy numbers
+	Original string
+}
+
+// Parse detects the scheme of a call number and parses it.
+// Dewey numbers start with three digits, LC numbers with one to three letters.
+func Parse(s string) (CallNumber, error) {
+	s = normalize(s)
+	if s == "" {
+		return CallNumber{}, ErrInvalid
+	}
+	if isDigit(s[0]) {
+		return ParseDewey(s)
+	}
+	return ParseLC(s)
+}
+
+// ParseDewey parses a Dewey Decimal call number such as "823.914 R69h 2003"
+func ParseDewey(s string) (CallNumber, error) {
+	s = normalize(s)
+	cn := CallNumber{Scheme: SchemeDewey, Original: s}
+
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	if i != 3 {
+		return CallNumber{}, ErrInvalid
+	}
+	cn.Number = trimZeros(s[:i])
+	if i < len(s) && s[i] == '.' {
+		j := i + 1
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		cn.Decimal = s[i+1 : j]
+		i = j
+	}
+	if i < len(s) && s[i] != ' ' {
+		return CallNumber{}, ErrInvalid
+	}
+
+	cutters, extra := parseRest(s[i:], true)
+	cn.Cutters = cutters
+	cn.Extra = extra
+	return cn, nil
+}
+
+// ParseLC parses a Library of Congress call number such as "QA76.73.G63 D66 2016"
+func ParseLC(s string) (CallNumber, error) {
+	s = normalize(s)
+	cn := CallNumber{Scheme: SchemeLC, Original: s}
+
+	i := 0
+	for i < len(s) && isLetter(s[i]) {
+		i++
+	}
+	if i == 0 || i > 3 {
+		return CallNumber{}, ErrInvalid
+	}
+	cn.Class = strings.ToUpper(s[:i])
+
+	// Class letters may stand alone, as in the range "QA-QB", or be followed by a number
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	j := i
+	for j < len(s) && isDigit(s[j]) {
+		j++
+	}
+	cn.Number = trimZeros(s[i:j])
+	i = j
+	if cn.Number != "" && i+1 < len(s) && s[i] == '.' && isDigit(s[i+1]) {
+		j = i + 1
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		cn.Decimal = s[i+1 : j]
+		i = j
+	}
+	if i < len(s) && s[i] != ' ' && s[i] != '.' {
+		return CallNumber{}, ErrInvalid
+	}
+
+	cutters, extra := parseRest(s[i:], false)
+	cn.Cutters = cutters
+	cn.Extra = extra
+	return cn, nil
+}
+
+// String returns the call number as it was written, with whitespace collapsed
+func (cn CallNumber) String() string {
+	return cn.Original
+}
+
+// parseRest splits what follows the class number into cutters and trailing extras.
+// Once a token is not a cutter, everything after it is an extra.
+func parseRest(s string, allowSuffix bool) ([]Cutter, []string) {
+	var cutters []Cutter
+	var extra []string
+
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' })
+	for _, field := range fields {
+		if len(extra) == 0 {
+			if parsed, ok := parseCutters(field, allowSuffix); ok {
+				cutters = append(cutters, parsed...)
+				continue
+			}
+		}
+		extra = append(extra, field)
+	}
+	return cutters, extra
+}
+
+// parseCutters reads one or more cutters from a token, as in ".G63" or "R69h".
+// LC runs cutters together, e.g. ".B37C65".
+func parseCutters(token string, allowSuffix bool) ([]Cutter, bool) {
+	token = strings.TrimPrefix(token, ".")
+	var cutters []Cutter
+	i := 0
+	for i < len(token) {
+		start := i
+		for i < len(token) && isLetter(token[i]) {
+			i++
+		}
+		letters := token[start:i]
+		if letters == "" || len(letters) > 2 {
+			return nil, false
+		}
+		start = i
+		for i < len(token) && isDigit(token[i]) {
+			i++
+		}
+		digits := token[start:i]
+		if digits == "" {
+			return nil, false
+		}
+
+		cutter := Cutter{Letters: strings.ToUpper(letters), Digits: digits}
+		if allowSuffix {
+			start = i
+			for i < len(token) && isLetter(token[i]) && (i+1 >= len(token) || !isDigit(token[i+1])) {
+				i++
+			}
+			cutter.Suffix = strings.ToLower(token[start:i])
+		}
+		cutters = append(cutters, cutter)
+	}
+	return cutters, len(cutters) > 0
+}
+
+// normalize trims a call number and collapses runs of whitespace
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+}
+
+func trimZeros(digits string) string {
+	trimmed := strings.TrimLeft(digits, "0")
+	if trimmed == "" && digits != "" {
+		return "0"
+	}
+	return trimmed
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z')
+}
@@ -0,0 +1,172 @@
+package callnumber
+
+import (
+	"strings"
+)
+
+// Compare orders two call numbers in shelf order, returning -1, 0 or 1.
+// Dewey numbers shelve before LC numbers.
+func Compare(a, b CallNumber) int {
+	if a.Scheme != b.Scheme {
+		if a.Scheme == SchemeDewey {
+			return -1
+		}
+		return 1
+	}
+	if c := strings.Compare(a.Class, b.Class); c != 0 {
+		return c
+	}
+	if c := compareInts(a.Number, b.Number); c != 0 {
+		return c
+	}
+	if c := compareFractions(a.Decimal, b.Decimal); c != 0 {
+		return c
+	}
+
+	for i := 0; i < len(a.Cutters) && i < len(b.Cutters); i++ {
+		if c := compareCutters(a.Cutters[i], b.Cutters[i]); c != 0 {
+			return c
+		}
+	}
+	if c := compareLengths(len(a.Cutters), len(b.Cutters)); c != 0 {
+		return c
+	}
+
+	for i := 0; i < len(a.Extra) && i < len(b.Extra); i++ {
+		if c := compareNatural(a.Extra[i], b.Extra[i]); c != 0 {
+			return c
+		}
+	}
+	return compareLengths(len(a.Extra), len(b.Extra))
+}
+
+// Less reports whether the call number a shelves before b
+func Less(a, b CallNumber) bool {
+	return Compare(a, b) < 0
+}
+
+// Range is an inclusive span of the shelf such as "500-599" or "QA1-QA76".
+// The upper bound covers everything filed under it, so 599.9 is within "500-599".
+type Range struct {
+	From CallNumber
+	To   CallNumber
+}
+
+// ParseRange parses "from-to" (a hyphen or en dash) or a single call number,
+// which then means everything filed under it
+func ParseRange(s string) (Range, error) {
+	s = strings.ReplaceAll(s, "–", "-")
+	from, to, found := strings.Cut(s, "-")
+	if !found {
+		to = from
+	}
+
+	lower, err := Parse(from)
+	if err != nil {
+		return Range{}, err
+	}
+	upper, err := Parse(to)
+	if err != nil {
+		return Range{}, err
+	}
+	if Compare(lower, upper) > 0 {
+		return Range{}, ErrInvalid
+	}
+	return Range{From: lower, To: upper}, nil
+}
+
+// Contains reports whether a call number falls within the range
+func (r Range) Contains(cn CallNumber) bool {
+	return Compare(r.From, cn) <= 0 && Compare(truncate(cn, r.To), r.To) <= 0
+}
+
+// truncate drops the parts of cn that are more precise than bound, so that the
+// bound "599" compares equal to "599.94 S63"
+func truncate(cn, bound CallNumber) CallNumber {
+	if cn.Scheme != bound.Scheme {
+		return cn
+	}
+	if bound.Number == "" {
+		cn.Number = ""
+	}
+	if bound.Decimal == "" {
+		cn.Decimal = ""
+	} else if len(cn.Decimal) > len(bound.Decimal) {
+		cn.Decimal = cn.Decimal[:len(bound.Decimal)]
+	}
+	if len(cn.Cutters) > len(bound.Cutters) {
+		cn.Cutters = cn.Cutters[:len(bound.Cutters)]
+	}
+	if len(cn.Extra) > len(bound.Extra) {
+		cn.Extra = cn.Extra[:len(bound.Extra)]
+	}
+	return cn
+}
+
+func compareCutters(a, b Cutter) int {
+	if c := strings.Compare(a.Letters, b.Letters); c != 0 {
+		return c
+	}
+	if c := compareFractions(a.Digits, b.Digits); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Suffix, b.Suffix)
+}
+
+// compareInts compares digit strings without leading zeros as integers
+func compareInts(a, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareFractions compares the digits after a decimal point, so "5" > "49"
+func compareFractions(a, b string) int {
+	return strings.Compare(strings.TrimRight(a, "0"), strings.TrimRight(b, "0"))
+}
+
+// compareNatural compares tokens such as "v.2" and "v.10" with their digit runs as numbers
+func compareNatural(a, b string) int {
+	a, b = strings.ToUpper(a), strings.ToUpper(b)
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			i, j := digitRun(a), digitRun(b)
+			if c := compareInts(trimZeros(a[:i]), trimZeros(b[:j])); c != 0 {
+				return c
+			}
+			a, b = a[i:], b[j:]
+			continue
+		}
+		if a[0] != b[0] {
+			if a[0] < b[0] {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[1:], b[1:]
+	}
+	return compareLengths(len(a), len(b))
+}
+
+func digitRun(s string) int {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// compareLengths files a shorter call number, with fewer parts, before a longer one
+func compareLengths(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the runtime settings of the server
+type Config struct {
+	Addr      string
+	Server    ServerConfig
+	Jobs      JobsConfig
+	Notify    NotifyConfig
+	Storage   StorageConfig
+	Lending   LendingConfig
+	Auth      AuthConfig
+	RateLimit RateLimitConfig
+	Log       LogConfig
+	Tracing   TracingConfig
+}
+
+// ServerConfig holds the HTTP server settings. The timeouts keep slow or idle clients
+// from holding connections open indefinitely.
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration // How long a client may take to send the request headers
+	ReadTimeout       time.Duration // How long a client may take to send the whole request
+	WriteTimeout      time.Duration // How long writing a response may take
+	TransferTimeout   time.Duration // How long book file uploads and downloads may take, in place of the two above
+	IdleTimeout       time.Duration // How long a keep-alive connection may wait for its next request
+	MaxHeaderBytes    int           // Largest request headers accepted
+	DrainDelay        time.Duration // How long readiness fails before the listener closes, for load balancers to notice
+	ShutdownTimeout   time.Duration // How long shutdown may take in all, in-flight requests included
+}
+
+// JobsConfig holds the background job settings
+type JobsConfig struct {
+	StateFile           string        // Where job run history is persisted; empty keeps it in memory
+	OverdueSchedule     string        // Cron spec of the overdue detection job
+	HoldExpirySchedule  string        // Cron spec of the hold expiry job
+	CompactionSchedule  string        // Cron spec of the nightly compaction job
+	ReminderSchedule    string        // Cron spec of the due date reminder job
+	HoldNoticeSchedule  string        // Cron spec of the hold ready notice job
+	EbookExpirySchedule string        // Cron spec of the digital loan expiry job
+	Retention           time.Duration // How long returned loans and closed holds are kept
+}
+
+// NotifyConfig holds the patron notification settings.
+// A channel is only enabled when its destination is configured.
+type NotifyConfig struct {
+	DefaultChannel string // Channel used for patrons without a preference: smtp, webhook or outbox; must be configured
+	SMTPHost       string
+	SMTPPort       int
+	SMTPUsername   string
+	SMTPPassword   string
+	SMTPFrom       string
+	WebhookURL     string
+	OutboxPath     string // File the outbox channel appends messages to
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	DueSoonWindow  time.Duration
+}
+
+// StorageConfig holds the blob storage settings for uploaded files such as cover images
+type StorageConfig struct {
+	Dir string // Local directory blobs are stored in; empty keeps them in memory
+}
+
+// LendingConfig holds the digital lending settings
+type LendingConfig struct {
+	SigningKey  string        // Secret for download links; empty uses a random key per process
+	LoanPeriod  time.Duration // How long a digital loan lasts
+	DownloadTTL time.Duration // How long a download link stays valid
+}
+
+// AuthConfig holds the authentication settings. Requests need an API key or a bearer
+// token signed with one of the configured keys, except on the few public routes.
+// Staff browsers can also log in through an OpenID Connect provider once OIDCIssuer is set.
+type AuthConfig struct {
+	Disabled    bool   // Trust every request; for tests and local development only
+	APIKeysFile string // JSON array of {"name", "hash", "roles"} with the SHA-256 of each key
+	JWKSFile    string // JSON Web Key Set of HS256 secrets and RS256 public keys
+	JWTSecret   string // HS256 secret, in addition to the key set
+	JWTIssuer   string // Required iss claim; empty accepts any
+	JWTAudience string // Required aud claim; empty accepts any
+
+	OIDCIssuer       string        // Provider URL; empty turns browser login off
+	OIDCClientID     string        // Client registered with the provider
+	OIDCClientSecret string        // Empty for a public client
+	OIDCRedirectURL  string        // This server's /auth/callback URL as registered with the provider
+	OIDCDefaultRole  string        // Role of users whose ID token has no roles claim; empty refuses them
+	SessionTTL       time.Duration // How long a browser session lasts
+}
+
+// RateLimitConfig holds the per-client request limits, each written as requests per
+// window such as "60/1m", or "off"
+type RateLimitConfig struct {
+	Disabled   bool
+	Default    string // Limit shared by routes without one of their own
+	Routes     string // Per-route limits, e.g. "GET /books=60/1m;GET /books/suggest=120/1m"
+	PerIP      string // Limit on all requests from one IP address, checked before authentication
+	TrustProxy bool   // Take client IPs from X-Forwarded-For, when behind a reverse proxy
+}
+
+// LogConfig holds the logging settings
+type LogConfig struct {
+	Level  string // debug, info, warn or error
+	Format string // text or json
+}
+
+// TracingConfig holds the tracing settings. Spans are exported in the OpenTelemetry
+// protocol, to a collector or to stdout.
+type TracingConfig struct {
+	Exporter    string // none, otlp or stdout
+	Endpoint    string // OTLP/HTTP traces endpoint of the collector
+	ServiceName string // service.name the spans are reported under
+}
+
+// Default returns the settings used when nothing is configured
+func Default() Config {
+	return Config{
+		Addr: ":8080",
+		Server: ServerConfig{
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			TransferTimeout:   20 * time.Minute, // A 100 MiB e-book takes about 14 minutes at a megabit a second
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    64 << 10,
+			DrainDelay:        5 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Jobs: JobsConfig{
+			OverdueSchedule:     "*/15 * * * *",
+			HoldExpirySchedule:  "0 * * * *",
+			CompactionSchedule:  "30 2 * * *",
+			ReminderSchedule:    "0 8 * * *",
+			HoldNoticeSchedule:  "*/10 * * * *",
+			EbookExpirySchedule: "*/5 * * * *",
+			Retention:           90 * 24 * time.Hour,
+		},
+		Notify: NotifyConfig{
+			DefaultChannel: "outbox", // Works without a mail server
+			OutboxPath:     "outbox.jsonl",
+			SMTPPort:       25,
+			SMTPFrom:       "library@localhost",
+			MaxAttempts:    3,
+			InitialBackoff: time.Second,
+			DueSoonWindow:  2 * 24 * time.Hour,
+		},
+		Lending: LendingConfig{
+			LoanPeriod:  14 * 24 * time.Hour,
+			DownloadTTL: time.Hour,
+		},
+		Auth: AuthConfig{
+			SessionTTL: 10 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Default: "600/1m",
+			// Catalogue search is public, so it gets a tighter limit against scrapers
+			Routes: "GET /books=60/1m;GET /books/suggest=300/1m;GET /collections/shared/{token}=60/1m",
+			// Room for several staff behind one address, while guessing credentials stays slow
+			PerIP: "1200/1m",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318/v1/traces",
+			ServiceName: "librarygo",
+		},
+	}
+}
+
+// Load returns the default settings overridden by LIBRARY_* environment variables
+func Load() Config {
+	cfg := Default()
+	cfg.Addr = getString("LIBRARY_ADDR", cfg.Addr)
+	cfg.Server.ReadHeaderTimeout = getDuration("LIBRARY_SERVER_READ_HEADER_TIMEOUT", cfg.Server.ReadHeaderTimeout)
+	cfg.Server.ReadTimeout = getDuration("LIBRARY_SERVER_READ_TIMEOUT", cfg.Server.ReadTimeout)
+	cfg.Server.WriteTimeout = getDuration("LIBRARY_SERVER_WRITE_TIMEOUT", cfg.Server.WriteTimeout)
+	cfg.Server.TransferTimeout = getDuration("LIBRARY_SERVER_TRANSFER_TIMEOUT", cfg.Server.TransferTimeout)
+	cfg.Server.IdleTimeout = getDuration("LIBRARY_SERVER_IDLE_TIMEOUT", cfg.Server.IdleTimeout)
+	cfg.Server.MaxHeaderBytes = getInt("LIBRARY_SERVER_MAX_HEADER_BYTES", cfg.Server.MaxHeaderBytes)
+	cfg.Server.DrainDelay = getDuration("LIBRARY_SERVER_DRAIN_DELAY", cfg.Server.DrainDelay)
+	cfg.Server.ShutdownTimeout = getDuration("LIBRARY_SERVER_SHUTDOWN_TIMEOUT", cfg.Server.ShutdownTimeout)
+
+	cfg.Jobs.StateFile = getString("LIBRARY_JOBS_STATE_FILE", cfg.Jobs.StateFile)
+	cfg.Jobs.OverdueSchedule = getString("LIBRARY_JOBS_OVERDUE_SCHEDULE", cfg.Jobs.OverdueSchedule)
+	cfg.Jobs.HoldExpirySchedule = getString("LIBRARY_JOBS_HOLD_EXPIRY_SCHEDULE", cfg.Jobs.HoldExpirySchedule)
+	cfg.Jobs.CompactionSchedule = getString("LIBRARY_JOBS_COMPACTION_SCHEDULE", cfg.Jobs.CompactionSchedule)
+	cfg.Jobs.ReminderSchedule = getString("LIBRARY_JOBS_REMINDER_SCHEDULE", cfg.Jobs.ReminderSchedule)
+	cfg.Jobs.HoldNoticeSchedule = getString("LIBRARY_JOBS_HOLD_NOTICE_SCHEDULE", cfg.Jobs.HoldNoticeSchedule)
+	cfg.Jobs.EbookExpirySchedule = getString("LIBRARY_JOBS_EBOOK_EXPIRY_SCHEDULE", cfg.Jobs.EbookExpirySchedule)
+	cfg.Jobs.Retention = getDuration("LIBRARY_JOBS_RETENTION", cfg.Jobs.Retention)
+
+	cfg.Notify.DefaultChannel = getString("LIBRARY_NOTIFY_DEFAULT_CHANNEL", cfg.Notify.DefaultChannel)
+	cfg.Notify.SMTPHost = getString("LIBRARY_SMTP_HOST", cfg.Notify.SMTPHost)
+	cfg.Notify.SMTPPort = getInt("LIBRARY_SMTP_PORT", cfg.Notify.SMTPPort)
+	cfg.Notify.SMTPUsername = getString("LIBRARY_SMTP_USERNAME", cfg.Notify.SMTPUsername)
+	cfg.Notify.SMTPPassword = getString("LIBRARY_SMTP_PASSWORD", cfg.Notify.SMTPPassword)
+	cfg.Notify.SMTPFrom = getString("LIBRARY_SMTP_FROM", cfg.Notify.SMTPFrom)
+	cfg.Notify.WebhookURL = getString("LIBRARY_NOTIFY_WEBHOOK_URL", cfg.Notify.WebhookURL)
+	cfg.Notify.OutboxPath = getString("LIBRARY_NOTIFY_OUTBOX_PATH", cfg.Notify.OutboxPath)
+	cfg.Notify.MaxAttempts = getInt("LIBRARY_NOTIFY_MAX_ATTEMPTS", cfg.Notify.MaxAttempts)
+	cfg.Notify.InitialBackoff = getDuration("LIBRARY_NOTIFY_INITIAL_BACKOFF", cfg.Notify.InitialBackoff)
+	cfg.Notify.DueSoonWindow = getDuration("LIBRARY_NOTIFY_DUE_SOON_WINDOW", cfg.Notify.DueSoonWindow)
+
+	cfg.Storage.Dir = getString("LIBRARY_STORAGE_DIR", cfg.Storage.Dir)
+
+	cfg.Lending.SigningKey = getString("LIBRARY_LENDING_SIGNING_KEY", cfg.Lending.SigningKey)
+	cfg.Lending.LoanPeriod = getDuration("LIBRARY_LENDING_LOAN_PERIOD", cfg.Lending.LoanPeriod)
+	cfg.Lending.DownloadTTL = getDuration("LIBRARY_LENDING_DOWNLOAD_TTL", cfg.Lending.DownloadTTL)
+
+	cfg.Auth.Disabled = getBool("LIBRARY_AUTH_DISABLED", cfg.Auth.Disabled)
+	cfg.Auth.APIKeysFile = getString("LIBRARY_AUTH_API_KEYS_FILE", cfg.Auth.APIKeysFile)
+	cfg.Auth.JWKSFile = getString("LIBRARY_AUTH_JWKS_FILE", cfg.Auth.JWKSFile)
+	cfg.Auth.JWTSecret = getString("LIBRARY_AUTH_JWT_SECRET", cfg.Auth.JWTSecret)
+	cfg.Auth.JWTIssuer = getString("LIBRARY_AUTH_JWT_ISSUER", cfg.Auth.JWTIssuer)
+	cfg.Auth.JWTAudience = getString("LIBRARY_AUTH_JWT_AUDIENCE", cfg.Auth.JWTAudience)
+	cfg.Auth.OIDCIssuer = getString("LIBRARY_OIDC_ISSUER", cfg.Auth.OIDCIssuer)
+	cfg.Auth.OIDCClientID = getString("LIBRARY_OIDC_CLIENT_ID", cfg.Auth.OIDCClientID)
+	cfg.Auth.OIDCClientSecret = getString("LIBRARY_OIDC_CLIENT_SECRET", cfg.Auth.OIDCClientSecret)
+	cfg.Auth.OIDCRedirectURL = getString("LIBRARY_OIDC_REDIRECT_URL", cfg.Auth.OIDCRedirectURL)
+	cfg.Auth.OIDCDefaultRole = getString("LIBRARY_OIDC_DEFAULT_ROLE", cfg.Auth.OIDCDefaultRole)
+	cfg.Auth.SessionTTL = getDuration("LIBRARY_AUTH_SESSION_TTL", cfg.Auth.SessionTTL)
+
+	cfg.RateLimit.Disabled = getBool("LIBRARY_RATE_LIMIT_DISABLED", cfg.RateLimit.Disabled)
+	cfg.RateLimit.Default = getString("LIBRARY_RATE_LIMIT_DEFAULT", cfg.RateLimit.Default)
+	cfg.RateLimit.Routes = getString("LIBRARY_RATE_LIMIT_ROUTES", cfg.RateLimit.Routes)
+	cfg.RateLimit.PerIP = getString("LIBRARY_RATE_LIMIT_PER_IP", cfg.RateLimit.PerIP)
+	cfg.RateLimit.TrustProxy = getBool("LIBRARY_RATE_LIMIT_TRUST_PROXY", cfg.RateLimit.TrustProxy)
+
+	cfg.Log.Level = getString("LIBRARY_LOG_LEVEL", cfg.Log.Level)
+	cfg.Log.Format = getString("LIBRARY_LOG_FORMAT", cfg.Log.Format)
+
+	cfg.Tracing.Exporter = getString("LIBRARY_TRACING_EXPORTER", cfg.Tracing.Exporter)
+	cfg.Tracing.Endpoint = getString("LIBRARY_TRACING_ENDPOINT", cfg.Tracing.Endpoint)
+	cfg.Tracing.ServiceName = getString("LIBRARY_TRACING_SERVICE_NAME", cfg.Tracing.ServiceName)
+	return cfg
+}
+
+func getString(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("config: ignoring invalid %s=%q: %v", key, value, err)
+		return fallback
+	}
+	return n
+}
+
+func getBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("config: ignoring invalid %s=%q: %v", key, value, err)
+		return fallback
+	}
+	return b
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("config: ignoring invalid %s=%q: %v", key, value, err)
+		return fallback
+	}
+	return d
+}
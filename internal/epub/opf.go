@@ -0,0 +1,213 @@
+// Package epub reads the package metadata of EPUB files
+package epub
+
+import (
+	"LibraryGo/internal/isbn"
+	"LibraryGo/internal/model"
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxDocumentBytes limits how much of container.xml and the OPF file is read,
+// so a crafted archive cannot inflate into memory
+const maxDocumentBytes = 1 << 20
+
+// Errors returned by ReadMetadata
+var (
+	ErrNotEPUB     = errors.New("file is not an EPUB archive")
+	ErrNoPackage   = errors.New("EPUB has no package document")
+	ErrBadMetadata = errors.New("EPUB package metadata cannot be read")
+)
+
+type container struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type opfPackage struct {
+	Metadata opfMetadata `xml:"metadata"`
+}
+
+type opfMetadata struct {
+	Titles      []dcElement `xml:"http://purl.org/dc/elements/1.1/ title"`
+	Creators    []dcElement `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Dates       []dcElement `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Identifiers []dcElement `xml:"http://purl.org/dc/elements/1.1/ identifier"`
+	Languages   []dcElement `xml:"http://purl.org/dc/elements/1.1/ language"`
+	Subjects    []dcElement `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	Metas       []opfMeta   `xml:"meta"`
+}
+
+// dcElement is a Dublin Core element with the EPUB 2 attributes that qualify it
+type dcElement struct {
+	ID     string `xml:"id,attr"`
+	Role   string `xml:"http://www.idpf.org/2007/opf role,attr"`
+	Scheme string `xml:"http://www.idpf.org/2007/opf scheme,attr"`
+	Event  string `xml:"http://www.idpf.org/2007/opf event,attr"`
+	Value  string `xml:",chardata"`
+}
+
+// opfMeta is an EPUB 3 refinement such as <meta refines="#creator1" property="role">aut</meta>
+type opfMeta struct {
+	Refines  string `xml:"refines,attr"`
+	Property string `xml:"property,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// ReadMetadata extracts title, creators, date, identifiers, language and subjects
+// from the package document of an EPUB 2 or 3 file
+func ReadMetadata(data []byte) (model.EbookMetadata, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return model.EbookMetadata{}, ErrNotEPUB
+	}
+
+	var root container
+	if err := readXML(archive, "META-INF/container.xml", &root); err != nil {
+		return model.EbookMetadata{}, ErrNoPackage
+	}
+	opfPath := ""
+	for _, rootfile := range root.Rootfiles {
+		if rootfile.MediaType == "" || rootfile.MediaType == "application/oebps-package+xml" {
+			opfPath = rootfile.FullPath
+			break
+		}
+	}
+	if opfPath == "" {
+		return model.EbookMetadata{}, ErrNoPackage
+	}
+
+	var pkg opfPackage
+	if err := readXML(archive, strings.TrimPrefix(path.Clean(opfPath), "/"), &pkg); err != nil {
+		return model.EbookMetadata{}, ErrBadMetadata
+	}
+	return pkg.Metadata.convert(), nil
+}
+
+// convert picks the values the catalogue uses from the raw elements
+func (m opfMetadata) convert() model.EbookMetadata {
+	refined := make(map[string]map[string]string)
+	for _, meta := range m.Metas {
+		id := strings.TrimPrefix(meta.Refines, "#")
+		if id == "" {
+			continue
+		}
+		if refined[id] == nil {
+			refined[id] = make(map[string]string)
+		}
+		refined[id][meta.Property] = clean(meta.Value)
+	}
+
+	var metadata model.EbookMetadata
+	if len(m.Titles) > 0 {
+		metadata.Title = clean(m.Titles[0].Value)
+	}
+	for _, title := range m.Titles {
+		// EPUB 3 may list subtitles and collections as titles; the main one wins
+		if refined[title.ID]["title-type"] == "main" {
+			metadata.Title = clean(title.Value)
+			break
+		}
+	}
+
+	// Authors only, unless no creator is marked with a role at all
+	var authors, others []string
+	for _, creator := range m.Creators {
+		name := clean(creator.Value)
+		if name == "" {
+			continue
+		}
+		role := creator.Role
+		if role == "" {
+			role = refined[creator.ID]["role"]
+		}
+		if role == "" || role == "aut" {
+			authors = append(authors, name)
+		} else {
+			others = append(others, name)
+		}
+	}
+	metadata.Creators = authors
+	if len(authors) == 0 {
+		metadata.Creators = others
+	}
+
+	for _, date := range m.Dates {
+		if metadata.Date == "" || date.Event == "publication" {
+			metadata.Date = clean(date.Value)
+		}
+	}
+
+	for _, identifier := range m.Identifiers {
+		value := clean(identifier.Value)
+		if value == "" {
+			continue
+		}
+		scheme := identifier.Scheme
+		if scheme == "" {
+			scheme = refined[identifier.ID]["identifier-type"]
+		}
+		metadata.Identifiers = append(metadata.Identifiers, identify(scheme, value))
+	}
+
+	if len(m.Languages) > 0 {
+		metadata.Language = strings.ToLower(clean(m.Languages[0].Value))
+	}
+	for _, subject := range m.Subjects {
+		if value := clean(subject.Value); value != "" {
+			metadata.Subjects = append(metadata.Subjects, value)
+		}
+	}
+	return metadata
+}
+
+// identify normalises an identifier, recognising ISBNs written as URNs or bare numbers
+func identify(scheme, value string) model.Identifier {
+	lower := strings.ToLower(value)
+	for _, prefix := range []string{"urn:isbn:", "isbn:"} {
+		if strings.HasPrefix(lower, prefix) {
+			return model.Identifier{Scheme: "ISBN", Value: value[len(prefix):]}
+		}
+	}
+	if strings.HasPrefix(lower, "urn:uuid:") {
+		return model.Identifier{Scheme: "UUID", Value: value[len("urn:uuid:"):]}
+	}
+	if _, err := isbn.Normalize(value); scheme == "" && err == nil {
+		scheme = "ISBN"
+	}
+	// EPUB 3 types identifiers with ONIX codes, where 02 is ISBN-10 and 15 is ISBN-13
+	if strings.EqualFold(scheme, "isbn") || scheme == "02" || scheme == "15" {
+		scheme = "ISBN"
+	}
+	return model.Identifier{Scheme: scheme, Value: value}
+}
+
+// readXML decodes one document of the archive
+func readXML(archive *zip.Reader, name string, out interface{}) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxDocumentBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxDocumentBytes {
+		return errors.New("document too large")
+	}
+	return xml.Unmarshal(data, out)
+}
+
+// clean collapses the whitespace of an element's text
+func clean(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+    "net/http"
+    "LibraryGo/internal/auth"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/utils"
+)
+
+// AuthHandler handles HTTP requests about the caller's identity
+type AuthHandler struct {
+    policy *auth.Policy
+}
+
+// NewAuthHandler creates a handler
+func NewAuthHandler(policy *auth.Policy) *AuthHandler {
+    return &AuthHandler{policy: policy}
+}
+
+// Me handles GET /auth/me, describing the principal the request was authenticated as
+// and what it may do
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+    principal, ok := auth.PrincipalFrom(r.Context())
+    if !ok {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("UNAUTHORIZED", "Authentication required", "Send an API key or a bearer token").
+            Send(w, http.StatusUnauthorized)
+        return
+    }
+
+    principal.Permissions = h.policy.Permissions(principal)
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(principal).
+        Send(w, http.StatusOK)
+}
+
+// actorOf returns who the request acts as on patrons' records: the authenticated patron,
+// or staff when the principal holds permission
+func actorOf(r *http.Request, policy *auth.Policy, permission string) model.Actor {
+    principal, _ := auth.PrincipalFrom(r.Context())
+    return policy.Actor(principal, permission)
+}
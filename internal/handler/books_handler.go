@@ -5,11 +5,18 @@ import (
     "net/http"
     "strconv"
     "github.com/gorilla/mux"
+    "LibraryGo/internal/callnumber"
     "LibraryGo/internal/model"
     "LibraryGo/internal/service"
     "LibraryGo/internal/utils"
 )
 
+// Page sizes of GET /books
+const (
+    defaultPerPage = 20
+    maxPerPage     = 100
+)
+
 // BookHandler handles HTTP requests
 type BookHandler struct {
     service *service.BookService
@@ -31,7 +38,7 @@ func (h *BookHandler) AddBook(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    createdBook, err := h.service.AddBook(newBook)
+    createdBook, err := h.service.AddBook(r.Context(), newBook)
     if err != nil {
         utils.NewResponse().
             WithSuccess(false).
@@ -51,6 +58,10 @@ func (h *BookHandler) GetBooks(w http.ResponseWriter, r *http.Request) {
     author := r.URL.Query().Get("author")
     startYear := r.URL.Query().Get("startYear")
     endYear := r.URL.Query().Get("endYear")
+    branch := r.URL.Query().Get("branch")
+    callRange := r.URL.Query().Get("callNumberRange")
+    sortBy := r.URL.Query().Get("sort")
+    decade := r.URL.Query().Get("decade")
 
     // Validate query parameters
     if startYear != "" && !utils.IsValidYear(startYear) {
@@ -69,7 +80,71 @@ func (h *BookHandler) GetBooks(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    books, err := h.service.GetBooks(author, startYear, endYear)
+    if decade != "" && !utils.IsValidYear(decade) {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid decade format", "Decade must be a year such as 1990").
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    page, perPage, ok := pagination(w, r)
+    if !ok {
+        return
+    }
+
+    if callRange != "" {
+        if _, err := callnumber.ParseRange(callRange); err != nil {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid callNumberRange format", "Range must be Dewey or LC call numbers such as 500-599 or QA1-QA76").
+                Send(w, http.StatusBadRequest)
+            return
+        }
+    }
+
+    if sortBy != "" && sortBy != model.SortCallNumber && sortBy != model.SortRating {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid sort value", "Supported values: callNumber, rating").
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    query := model.BookQuery{
+        Author:    author,
+        StartYear: startYear,
+        EndYear:   endYear,
+        Decade:    decade,
+        Language:  r.URL.Query().Get("language"),
+        CallRange: callRange,
+        Tags:      r.URL.Query()["tag"],
+        Sort:      sortBy,
+    }
+    for _, value := range r.URL.Query()["subject"] {
+        subjectID, err := strconv.Atoi(value)
+        if err != nil {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid subject format", "Subject must be a valid ID").
+                Send(w, http.StatusBadRequest)
+            return
+        }
+        query.Subjects = append(query.Subjects, subjectID)
+    }
+    if branch != "" {
+        branchID, err := strconv.Atoi(branch)
+        if err != nil {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid branch format", "Branch must be a valid ID").
+                Send(w, http.StatusBadRequest)
+            return
+        }
+        query.BranchID = branchID
+    }
+
+    books, err := h.service.GetBooks(r.Context(), query)
     if err != nil {
         utils.NewResponse().
             WithSuccess(false).
@@ -78,24 +153,119 @@ func (h *BookHandler) GetBooks(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    // Facets describe the whole filtered set, not just the page being returned
+    meta := &model.MetaData{
+        Total:  len(books),
+        Facets: h.service.Facets(books, query),
+    }
+
     if len(books) == 0 {
         utils.NewResponse().
             WithSuccess(true).
             WithData([]model.Book{}).
-            WithMeta(&model.MetaData{
-                Total: 0,
-                Count: 0,
-            }).
+            WithMeta(meta).
             Send(w, http.StatusOK)
         return
     }
 
+    if perPage > 0 {
+        books = paginate(books, meta, page, perPage)
+    }
+    meta.Count = len(books)
+
     utils.NewResponse().
         WithSuccess(true).
         WithData(books).
+        WithMeta(meta).
+        Send(w, http.StatusOK)
+}
+
+// pagination parses the optional page and perPage parameters; perPage is zero when not paging
+func pagination(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+    page, perPage := 1, 0
+    if value := r.URL.Query().Get("page"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 1 {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid page format", "Page must be a positive number").
+                Send(w, http.StatusBadRequest)
+            return 0, 0, false
+        }
+        page, perPage = parsed, defaultPerPage
+    }
+    if value := r.URL.Query().Get("perPage"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 1 || parsed > maxPerPage {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid perPage format", "perPage must be between 1 and "+strconv.Itoa(maxPerPage)).
+                Send(w, http.StatusBadRequest)
+            return 0, 0, false
+        }
+        perPage = parsed
+    }
+    return page, perPage, true
+}
+
+// paginate returns one page of books and records the page position in meta
+func paginate(books []model.Book, meta *model.MetaData, page, perPage int) []model.Book {
+    totalPages := (len(books) + perPage - 1) / perPage
+    meta.Page = page
+    meta.PerPage = perPage
+    meta.TotalPages = totalPages
+    if page > 1 {
+        prev := page - 1
+        meta.PrevPage = &prev
+    }
+    if page < totalPages {
+        next := page + 1
+        meta.NextPage = &next
+    }
+
+    start := (page - 1) * perPage
+    if start >= len(books) {
+        return []model.Book{}
+    }
+    end := start + perPage
+    if end > len(books) {
+        end = len(books)
+    }
+    return books[start:end]
+}
+
+// SuggestBooks handles GET /books/suggest
+func (h *BookHandler) SuggestBooks(w http.ResponseWriter, r *http.Request) {
+    field := r.URL.Query().Get("field")
+    if field != "" && field != model.SuggestFieldTitle && field != model.SuggestFieldAuthor {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid field value", "Supported values: title, author").
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    limit := 0
+    if value := r.URL.Query().Get("limit"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 1 {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid limit format", "Limit must be a positive number").
+                Send(w, http.StatusBadRequest)
+            return
+        }
+        limit = parsed
+    }
+
+    suggestions := h.service.Suggest(r.URL.Query().Get("q"), field, limit)
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(suggestions).
         WithMeta(&model.MetaData{
-            Total: len(books),
-            Count: len(books),
+            Total: len(suggestions),
+            Count: len(suggestions),
         }).
         Send(w, http.StatusOK)
 }
@@ -112,7 +282,7 @@ func (h *BookHandler) GetBookByID(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    book, err := h.service.GetBookByID(bookID)
+    book, err := h.service.GetBookByID(r.Context(), bookID)
     if err != nil {
         utils.NewResponse().
             WithSuccess(false).
@@ -139,7 +309,7 @@ func (h *BookHandler) DeleteBookByID(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    if err := h.service.DeleteBookByID(bookID); err != nil {
+    if err := h.service.DeleteBookByID(r.Context(), bookID); err != nil {
         utils.NewResponse().
             WithSuccess(false).
             WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
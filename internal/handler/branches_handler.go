@@ -0,0 +1,292 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// BranchHandler handles HTTP requests for branches, copies and transfers
+type BranchHandler struct {
+    service *service.BranchService
+}
+
+// NewBranchHandler creates a handler
+func NewBranchHandler(service *service.BranchService) *BranchHandler {
+    return &BranchHandler{service: service}
+}
+
+// copyRequest is the body of POST /books/{id}/copies
+type copyRequest struct {
+    Barcode      string `json:"barcode"`
+    HomeBranchID int    `json:"homeBranchId"`
+    Shelf        string `json:"shelf,omitempty"`
+}
+
+// transferRequest is the body of POST /transfers
+type transferRequest struct {
+    CopyID     int `json:"copyId"`
+    ToBranchID int `json:"toBranchId"`
+}
+
+// AddBranch handles POST /branches
+func (h *BranchHandler) AddBranch(w http.ResponseWriter, r *http.Request) {
+    var newBranch model.Branch
+    if err := json.NewDecoder(r.Body).Decode(&newBranch); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    createdBranch, err := h.service.AddBranch(newBranch)
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Failed to create branch", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(createdBranch).
+        Send(w, http.StatusCreated)
+}
+
+// GetBranches handles GET /branches
+func (h *BranchHandler) GetBranches(w http.ResponseWriter, r *http.Request) {
+    branches := h.service.GetAllBranches()
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(branches).
+        WithMeta(&model.MetaData{
+            Total: len(branches),
+            Count: len(branches),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetBranchByID handles GET /branches/{id}
+func (h *BranchHandler) GetBranchByID(w http.ResponseWriter, r *http.Request) {
+    branchID, ok := pathID(w, r, "branch")
+    if !ok {
+        return
+    }
+
+    branch, err := h.service.GetBranchByID(branchID)
+    if err != nil {
+        sendBranchError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(branch).
+        Send(w, http.StatusOK)
+}
+
+// AddCopy handles POST /books/{id}/copies
+func (h *BranchHandler) AddCopy(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    var req copyRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    item, err := h.service.AddCopy(bookID, req.Barcode, req.HomeBranchID, req.Shelf)
+    if err != nil {
+        sendBranchError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(item).
+        Send(w, http.StatusCreated)
+}
+
+// GetCopies handles GET /books/{id}/copies
+func (h *BranchHandler) GetCopies(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    copies, err := h.service.GetCopies(bookID)
+    if err != nil {
+        sendBranchError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(copies).
+        WithMeta(&model.MetaData{
+            Total: len(copies),
+            Count: len(copies),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// RequestTransfer handles POST /transfers
+func (h *BranchHandler) RequestTransfer(w http.ResponseWriter, r *http.Request) {
+    var req transferRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    transfer, err := h.service.RequestTransfer(req.CopyID, req.ToBranchID)
+    if err != nil {
+        sendBranchError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(transfer).
+        Send(w, http.StatusCreated)
+}
+
+// GetTransfers handles GET /transfers
+func (h *BranchHandler) GetTransfers(w http.ResponseWriter, r *http.Request) {
+    branchID := 0
+    if value := r.URL.Query().Get("branchId"); value != "" {
+        id, err := strconv.Atoi(value)
+        if err != nil {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid branchId format", "ID must be a valid number").
+                Send(w, http.StatusBadRequest)
+            return
+        }
+        branchID = id
+    }
+
+    transfers := h.service.GetTransfers(branchID, r.URL.Query().Get("status"))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(transfers).
+        WithMeta(&model.MetaData{
+            Total: len(transfers),
+            Count: len(transfers),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetTransferByID handles GET /transfers/{id}
+func (h *BranchHandler) GetTransferByID(w http.ResponseWriter, r *http.Request) {
+    transferID, ok := pathID(w, r, "transfer")
+    if !ok {
+        return
+    }
+
+    transfer, err := h.service.GetTransferByID(transferID)
+    if err != nil {
+        sendBranchError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(transfer).
+        Send(w, http.StatusOK)
+}
+
+// ShipTransfer handles POST /transfers/{id}/ship
+func (h *BranchHandler) ShipTransfer(w http.ResponseWriter, r *http.Request) {
+    h.updateTransfer(w, r, h.service.ShipTransfer)
+}
+
+// ReceiveTransfer handles POST /transfers/{id}/receive
+func (h *BranchHandler) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+    h.updateTransfer(w, r, h.service.ReceiveTransfer)
+}
+
+// CancelTransfer handles POST /transfers/{id}/cancel
+func (h *BranchHandler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+    h.updateTransfer(w, r, h.service.CancelTransfer)
+}
+
+// updateTransfer applies a transfer state change and writes the result
+func (h *BranchHandler) updateTransfer(w http.ResponseWriter, r *http.Request, apply func(int) (model.Transfer, error)) {
+    transferID, ok := pathID(w, r, "transfer")
+    if !ok {
+        return
+    }
+
+    transfer, err := apply(transferID)
+    if err != nil {
+        sendBranchError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(transfer).
+        Send(w, http.StatusOK)
+}
+
+// sendBranchError maps branch, copy and transfer errors to API responses
+func sendBranchError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrBranchNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Branch not found", "No branch exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrCopyNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Copy not found", "No copy exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrTransferNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Transfer not found", "No transfer exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrCopyBarcodeMissing):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Failed to create copy", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, repository.ErrDuplicateBarcode),
+        errors.Is(err, service.ErrCopyUnavailable),
+        errors.Is(err, service.ErrSameBranch),
+        errors.Is(err, service.ErrTransferState):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("CONFLICT", "Request conflicts with the copy's current state", err.Error()).
+            Send(w, http.StatusConflict)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Branch request failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "LibraryGo/internal/epub"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// CatalogHandler handles HTTP requests for e-book imports and their metadata proposals
+type CatalogHandler struct {
+    service *service.CatalogService
+}
+
+// NewCatalogHandler creates a handler
+func NewCatalogHandler(service *service.CatalogService) *CatalogHandler {
+    return &CatalogHandler{service: service}
+}
+
+// approveRequest is the optional body of POST /proposals/{id}/approve
+type approveRequest struct {
+    Fields []string `json:"fields"` // Changes to apply; empty applies all
+}
+
+// ImportEPUB handles POST /books/import.
+// The file is the raw body or the "file" field of a multipart form. bookId names the book
+// it belongs to; without it the book is matched by ISBN, then by title and author.
+// Responds 201 when a book was created, 202 when changes await approval, 200 otherwise.
+func (h *CatalogHandler) ImportEPUB(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := queryID(w, r, "bookId")
+    if !ok {
+        return
+    }
+
+    r.Body = http.MaxBytesReader(w, r.Body, service.MaxAssetBytes+multipartOverhead)
+    data, fileName, err := readUpload(r)
+    if err != nil {
+        var tooLarge *http.MaxBytesError
+        if errors.As(err, &tooLarge) {
+            sendCatalogError(w, service.ErrAssetTooLarge)
+            return
+        }
+        sendInvalidBody(w, err)
+        return
+    }
+
+    result, err := h.service.ImportEPUB(r.Context(), data, fileName, bookID)
+    if err != nil {
+        sendCatalogError(w, err)
+        return
+    }
+
+    status := http.StatusOK
+    switch result.Action {
+    case model.ImportCreated:
+        status = http.StatusCreated
+    case model.ImportProposed:
+        status = http.StatusAccepted
+    }
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(result).
+        Send(w, status)
+}
+
+// GetProposals handles GET /proposals
+func (h *CatalogHandler) GetProposals(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := queryID(w, r, "bookId")
+    if !ok {
+        return
+    }
+
+    proposals := h.service.GetProposals(bookID, r.URL.Query().Get("status"))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(proposals).
+        WithMeta(&model.MetaData{
+            Total: len(proposals),
+            Count: len(proposals),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetProposalByID handles GET /proposals/{id}
+func (h *CatalogHandler) GetProposalByID(w http.ResponseWriter, r *http.Request) {
+    proposalID, ok := pathID(w, r, "proposal")
+    if !ok {
+        return
+    }
+
+    proposal, err := h.service.GetProposalByID(proposalID)
+    if err != nil {
+        sendCatalogError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(proposal).
+        Send(w, http.StatusOK)
+}
+
+// ApproveProposal handles POST /proposals/{id}/approve and responds with the updated book
+func (h *CatalogHandler) ApproveProposal(w http.ResponseWriter, r *http.Request) {
+    proposalID, ok := pathID(w, r, "proposal")
+    if !ok {
+        return
+    }
+
+    var req approveRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    _, book, err := h.service.Approve(r.Context(), proposalID, req.Fields)
+    if err != nil {
+        sendCatalogError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(book).
+        Send(w, http.StatusOK)
+}
+
+// RejectProposal handles POST /proposals/{id}/reject
+func (h *CatalogHandler) RejectProposal(w http.ResponseWriter, r *http.Request) {
+    proposalID, ok := pathID(w, r, "proposal")
+    if !ok {
+        return
+    }
+
+    proposal, err := h.service.Reject(proposalID)
+    if err != nil {
+        sendCatalogError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(proposal).
+        Send(w, http.StatusOK)
+}
+
+// sendCatalogError maps import and proposal errors to API responses; file and book
+// errors are shared with digital lending
+func sendCatalogError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrProposalNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Proposal not found", "No proposal exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, epub.ErrNotEPUB):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("UNSUPPORTED_MEDIA_TYPE", "Unsupported file", err.Error()).
+            Send(w, http.StatusUnsupportedMediaType)
+    case errors.Is(err, epub.ErrNoPackage),
+        errors.Is(err, epub.ErrBadMetadata),
+        errors.Is(err, service.ErrIncompleteMetadata),
+        errors.Is(err, service.ErrUnknownField):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Invalid e-book metadata", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, service.ErrProposalResolved),
+        errors.Is(err, service.ErrProposalStale):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("CONFLICT", "Proposal cannot be approved", err.Error()).
+            Send(w, http.StatusConflict)
+    default:
+        sendLendingError(w, err)
+    }
+}
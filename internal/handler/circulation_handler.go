@@ -0,0 +1,277 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "strconv"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// CirculationHandler handles HTTP requests for loans and holds
+type CirculationHandler struct {
+    service *service.CirculationService
+}
+
+// NewCirculationHandler creates a handler
+func NewCirculationHandler(service *service.CirculationService) *CirculationHandler {
+    return &CirculationHandler{service: service}
+}
+
+// circulationRequest is the body of POST /loans, POST /holds and POST /loans/{id}/return
+type circulationRequest struct {
+    BookID   int `json:"bookId"`
+    PatronID int `json:"patronId"`
+    BranchID int `json:"branchId,omitempty"` // Branch lending or receiving the copy
+}
+
+// Checkout handles POST /loans
+func (h *CirculationHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+    var req circulationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    loan, err := h.service.Checkout(req.BookID, req.PatronID, req.BranchID)
+    if err != nil {
+        sendCirculationError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(loan).
+        Send(w, http.StatusCreated)
+}
+
+// GetLoans handles GET /loans
+func (h *CirculationHandler) GetLoans(w http.ResponseWriter, r *http.Request) {
+    patronID, bookID, ok := circulationFilters(w, r)
+    if !ok {
+        return
+    }
+
+    loans := h.service.GetLoans(patronID, bookID, r.URL.Query().Get("status"))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(loans).
+        WithMeta(&model.MetaData{
+            Total: len(loans),
+            Count: len(loans),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetLoanByID handles GET /loans/{id}
+func (h *CirculationHandler) GetLoanByID(w http.ResponseWriter, r *http.Request) {
+    loanID, ok := pathID(w, r, "loan")
+    if !ok {
+        return
+    }
+
+    loan, err := h.service.GetLoanByID(loanID)
+    if err != nil {
+        sendCirculationError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(loan).
+        Send(w, http.StatusOK)
+}
+
+// ReturnLoan handles POST /loans/{id}/return
+func (h *CirculationHandler) ReturnLoan(w http.ResponseWriter, r *http.Request) {
+    loanID, ok := pathID(w, r, "loan")
+    if !ok {
+        return
+    }
+
+    // The body is optional; it only names the branch the copy was returned to
+    var req circulationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    loan, err := h.service.Return(loanID, req.BranchID)
+    if err != nil {
+        sendCirculationError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(loan).
+        Send(w, http.StatusOK)
+}
+
+// PlaceHold handles POST /holds
+func (h *CirculationHandler) PlaceHold(w http.ResponseWriter, r *http.Request) {
+    var req circulationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    hold, err := h.service.PlaceHold(req.BookID, req.PatronID)
+    if err != nil {
+        sendCirculationError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(hold).
+        Send(w, http.StatusCreated)
+}
+
+// GetHolds handles GET /holds
+func (h *CirculationHandler) GetHolds(w http.ResponseWriter, r *http.Request) {
+    patronID, bookID, ok := circulationFilters(w, r)
+    if !ok {
+        return
+    }
+
+    holds := h.service.GetHolds(patronID, bookID, r.URL.Query().Get("status"))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(holds).
+        WithMeta(&model.MetaData{
+            Total: len(holds),
+            Count: len(holds),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetHoldByID handles GET /holds/{id}
+func (h *CirculationHandler) GetHoldByID(w http.ResponseWriter, r *http.Request) {
+    holdID, ok := pathID(w, r, "hold")
+    if !ok {
+        return
+    }
+
+    hold, err := h.service.GetHoldByID(holdID)
+    if err != nil {
+        sendCirculationError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(hold).
+        Send(w, http.StatusOK)
+}
+
+// CancelHold handles POST /holds/{id}/cancel
+func (h *CirculationHandler) CancelHold(w http.ResponseWriter, r *http.Request) {
+    holdID, ok := pathID(w, r, "hold")
+    if !ok {
+        return
+    }
+
+    hold, err := h.service.CancelHold(holdID)
+    if err != nil {
+        sendCirculationError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(hold).
+        Send(w, http.StatusOK)
+}
+
+// pathID parses the {id} route variable, writing an error response if it is not a number
+func pathID(w http.ResponseWriter, r *http.Request, entity string) (int, bool) {
+    id, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_ID", "Invalid "+entity+" ID", "ID must be a valid number").
+            Send(w, http.StatusBadRequest)
+        return 0, false
+    }
+    return id, true
+}
+
+// circulationFilters parses the optional patronId and bookId query parameters
+func circulationFilters(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+    var ids [2]int
+    for i, name := range []string{"patronId", "bookId"} {
+        value := r.URL.Query().Get(name)
+        if value == "" {
+            continue
+        }
+        id, err := strconv.Atoi(value)
+        if err != nil {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid "+name+" format", "ID must be a valid number").
+                Send(w, http.StatusBadRequest)
+            return 0, 0, false
+        }
+        ids[i] = id
+    }
+    return ids[0], ids[1], true
+}
+
+// sendCirculationError maps circulation errors to API responses
+func sendCirculationError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrLoanNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Loan not found", "No loan exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrHoldNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Hold not found", "No hold exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrPatronNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Patron not found", "No patron exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookOnLoan),
+        errors.Is(err, service.ErrWrongBranch),
+        errors.Is(err, service.ErrBookReserved),
+        errors.Is(err, service.ErrLoanReturned),
+        errors.Is(err, service.ErrHoldClosed),
+        errors.Is(err, service.ErrDuplicateHold),
+        errors.Is(err, service.ErrAlreadyBorrowed):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("CONFLICT", "Request conflicts with current circulation state", err.Error()).
+            Send(w, http.StatusConflict)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Circulation request failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
@@ -0,0 +1,325 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/auth"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// CollectionHandler handles HTTP requests for reading lists.
+// Requests act as the authenticated patron; private lists are only shown to their
+// owner, staff or through their share link, and only the owner or staff change a list.
+type CollectionHandler struct {
+    service *service.CollectionService
+    policy  *auth.Policy
+}
+
+// NewCollectionHandler creates a handler
+func NewCollectionHandler(service *service.CollectionService, policy *auth.Policy) *CollectionHandler {
+    return &CollectionHandler{service: service, policy: policy}
+}
+
+// actor returns who the request acts as
+func (h *CollectionHandler) actor(r *http.Request) model.Actor {
+    return actorOf(r, h.policy, auth.PermCollectionsManage)
+}
+
+// collectionEntryRequest is the body of POST /collections/{id}/entries
+type collectionEntryRequest struct {
+    BookID   int    `json:"bookId"`
+    Note     string `json:"note"`
+    Position int    `json:"position"` // 1-based; 0 appends
+}
+
+// collectionNoteRequest is the body of PUT /collections/{id}/entries/{bookId}
+type collectionNoteRequest struct {
+    Note string `json:"note"`
+}
+
+// collectionOrderRequest is the body of PUT /collections/{id}/order
+type collectionOrderRequest struct {
+    BookIDs []int `json:"bookIds"`
+}
+
+// AddCollection handles POST /collections
+func (h *CollectionHandler) AddCollection(w http.ResponseWriter, r *http.Request) {
+    var newCollection model.Collection
+    if err := json.NewDecoder(r.Body).Decode(&newCollection); err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    collection, err := h.service.AddCollection(newCollection, h.actor(r))
+    if err != nil {
+        sendCollectionError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(collection).
+        Send(w, http.StatusCreated)
+}
+
+// GetCollections handles GET /collections, optionally filtered by ownerId
+func (h *CollectionHandler) GetCollections(w http.ResponseWriter, r *http.Request) {
+    ownerID, ok := queryID(w, r, "ownerId")
+    if !ok {
+        return
+    }
+    collections := h.service.GetCollections(ownerID, h.actor(r))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(collections).
+        WithMeta(&model.MetaData{
+            Total: len(collections),
+            Count: len(collections),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetCollectionByID handles GET /collections/{id}
+func (h *CollectionHandler) GetCollectionByID(w http.ResponseWriter, r *http.Request) {
+    collectionID, ok := pathID(w, r, "collection")
+    if !ok {
+        return
+    }
+    collection, err := h.service.GetCollectionByID(collectionID, h.actor(r))
+    sendCollection(w, collection, err)
+}
+
+// GetSharedCollection handles GET /collections/shared/{token}
+func (h *CollectionHandler) GetSharedCollection(w http.ResponseWriter, r *http.Request) {
+    collection, err := h.service.GetSharedCollection(mux.Vars(r)["token"])
+    sendCollection(w, collection, err)
+}
+
+// UpdateCollection handles PUT /collections/{id}
+func (h *CollectionHandler) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+    collectionID, ok := pathID(w, r, "collection")
+    if !ok {
+        return
+    }
+
+    var changes model.Collection
+    if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    collection, err := h.service.UpdateCollection(collectionID, changes, h.actor(r))
+    sendCollection(w, collection, err)
+}
+
+// DeleteCollectionByID handles DELETE /collections/{id}
+func (h *CollectionHandler) DeleteCollectionByID(w http.ResponseWriter, r *http.Request) {
+    collectionID, ok := pathID(w, r, "collection")
+    if !ok {
+        return
+    }
+
+    if err := h.service.DeleteCollectionByID(collectionID, h.actor(r)); err != nil {
+        sendCollectionError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        Send(w, http.StatusNoContent)
+}
+
+// AddEntry handles POST /collections/{id}/entries
+func (h *CollectionHandler) AddEntry(w http.ResponseWriter, r *http.Request) {
+    collectionID, ok := pathID(w, r, "collection")
+    if !ok {
+        return
+    }
+
+    var request collectionEntryRequest
+    if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    entry := model.CollectionEntry{BookID: request.BookID, Note: request.Note}
+    collection, err := h.service.AddEntry(collectionID, entry, request.Position, h.actor(r))
+    sendCollection(w, collection, err)
+}
+
+// UpdateEntry handles PUT /collections/{id}/entries/{bookId}
+func (h *CollectionHandler) UpdateEntry(w http.ResponseWriter, r *http.Request) {
+    collectionID, bookID, ok := collectionEntryIDs(w, r)
+    if !ok {
+        return
+    }
+
+    var request collectionNoteRequest
+    if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    collection, err := h.service.UpdateEntry(collectionID, bookID, request.Note, h.actor(r))
+    sendCollection(w, collection, err)
+}
+
+// RemoveEntry handles DELETE /collections/{id}/entries/{bookId}
+func (h *CollectionHandler) RemoveEntry(w http.ResponseWriter, r *http.Request) {
+    collectionID, bookID, ok := collectionEntryIDs(w, r)
+    if !ok {
+        return
+    }
+
+    collection, err := h.service.RemoveEntry(collectionID, bookID, h.actor(r))
+    sendCollection(w, collection, err)
+}
+
+// Reorder handles PUT /collections/{id}/order
+func (h *CollectionHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+    collectionID, ok := pathID(w, r, "collection")
+    if !ok {
+        return
+    }
+
+    var request collectionOrderRequest
+    if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    collection, err := h.service.Reorder(collectionID, request.BookIDs, h.actor(r))
+    sendCollection(w, collection, err)
+}
+
+// Share handles POST /collections/{id}/share
+func (h *CollectionHandler) Share(w http.ResponseWriter, r *http.Request) {
+    collectionID, ok := pathID(w, r, "collection")
+    if !ok {
+        return
+    }
+
+    collection, err := h.service.Share(collectionID, h.actor(r))
+    sendCollection(w, collection, err)
+}
+
+// Unshare handles DELETE /collections/{id}/share
+func (h *CollectionHandler) Unshare(w http.ResponseWriter, r *http.Request) {
+    collectionID, ok := pathID(w, r, "collection")
+    if !ok {
+        return
+    }
+
+    collection, err := h.service.Unshare(collectionID, h.actor(r))
+    sendCollection(w, collection, err)
+}
+
+// queryID parses an optional ID query parameter, zero when absent
+func queryID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+    value := r.URL.Query().Get(name)
+    if value == "" {
+        return 0, true
+    }
+    id, err := strconv.Atoi(value)
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid "+name+" format", "ID must be a valid number").
+            Send(w, http.StatusBadRequest)
+        return 0, false
+    }
+    return id, true
+}
+
+func collectionEntryIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+    collectionID, ok := pathID(w, r, "collection")
+    if !ok {
+        return 0, 0, false
+    }
+    bookID, err := strconv.Atoi(mux.Vars(r)["bookId"])
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_ID", "Invalid book ID", "ID must be a valid number").
+            Send(w, http.StatusBadRequest)
+        return 0, 0, false
+    }
+    return collectionID, bookID, true
+}
+
+func sendInvalidBody(w http.ResponseWriter, err error) {
+    utils.NewResponse().
+        WithSuccess(false).
+        WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+        Send(w, http.StatusBadRequest)
+}
+
+// sendCollection writes a list, or the error that prevented reading or changing it
+func sendCollection(w http.ResponseWriter, collection model.Collection, err error) {
+    if err != nil {
+        sendCollectionError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(collection).
+        Send(w, http.StatusOK)
+}
+
+// sendCollectionError maps collection errors to API responses
+func sendCollectionError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrCollectionNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Collection not found", "No collection exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrPatronNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Patron not found", "No patron exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrEntryNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Entry not found", err.Error()).
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrNotListOwner):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("FORBIDDEN", "Not your list", err.Error()).
+            Send(w, http.StatusForbidden)
+    case errors.Is(err, service.ErrDuplicateEntry):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("CONFLICT", "Book already listed", err.Error()).
+            Send(w, http.StatusConflict)
+    case errors.Is(err, service.ErrCollectionTitle),
+        errors.Is(err, service.ErrInvalidVisibility),
+        errors.Is(err, service.ErrCollectionFull),
+        errors.Is(err, service.ErrInvalidPosition),
+        errors.Is(err, service.ErrInvalidOrder):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Invalid collection", err.Error()).
+            Send(w, http.StatusBadRequest)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Collection request failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+    "errors"
+    "io"
+    "mime"
+    "net/http"
+    "strconv"
+    "strings"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// multipartOverhead allows for the form boundaries and headers around an uploaded file
+const multipartOverhead = 64 << 10
+
+// CoverHandler handles HTTP requests for book cover images
+type CoverHandler struct {
+    service *service.CoverService
+}
+
+// NewCoverHandler creates a handler
+func NewCoverHandler(service *service.CoverService) *CoverHandler {
+    return &CoverHandler{service: service}
+}
+
+// UploadCover handles PUT /books/{id}/cover.
+// The image is either the raw request body or the "file" field of a multipart form.
+func (h *CoverHandler) UploadCover(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    r.Body = http.MaxBytesReader(w, r.Body, service.MaxCoverBytes+multipartOverhead)
+    data, _, err := readUpload(r)
+    if err != nil {
+        var tooLarge *http.MaxBytesError
+        if errors.As(err, &tooLarge) {
+            sendCoverError(w, service.ErrCoverTooLarge)
+            return
+        }
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    cover, err := h.service.Upload(bookID, data)
+    if err != nil {
+        sendCoverError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(cover).
+        Send(w, http.StatusOK)
+}
+
+// GetCover handles GET /books/{id}/cover, with an optional size of small, medium or large.
+// URLs carrying the current version may be cached for good; others are revalidated by ETag.
+func (h *CoverHandler) GetCover(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+    size := r.URL.Query().Get("size")
+
+    cover, data, contentType, err := h.service.Image(bookID, size)
+    if err != nil {
+        sendCoverError(w, err)
+        return
+    }
+
+    etag := `"` + cover.ETag + `"`
+    if size != "" && size != model.CoverSizeOriginal {
+        etag = `"` + cover.ETag + "-" + size + `"`
+    }
+    w.Header().Set("ETag", etag)
+    if r.URL.Query().Get("v") == cover.ETag {
+        w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+    } else {
+        w.Header().Set("Cache-Control", "public, no-cache")
+    }
+    if etagMatches(r.Header.Get("If-None-Match"), etag) {
+        w.WriteHeader(http.StatusNotModified)
+        return
+    }
+
+    w.Header().Set("Content-Type", contentType)
+    w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.WriteHeader(http.StatusOK)
+    w.Write(data)
+}
+
+// DeleteCover handles DELETE /books/{id}/cover
+func (h *CoverHandler) DeleteCover(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    if err := h.service.DeleteCover(bookID); err != nil {
+        sendCoverError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        Send(w, http.StatusNoContent)
+}
+
+// readUpload reads an uploaded file from the "file" field of a multipart form or
+// the raw body, with its file name if the client sent one
+func readUpload(r *http.Request) ([]byte, string, error) {
+    file, fileName, err := openUpload(r)
+    if err != nil {
+        return nil, "", err
+    }
+    data, err := io.ReadAll(file)
+    return data, fileName, err
+}
+
+// openUpload finds the uploaded file without reading it, for files too large to buffer.
+// The name comes from the multipart form or the fileName query parameter.
+func openUpload(r *http.Request) (io.Reader, string, error) {
+    mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+    if mediaType != "multipart/form-data" {
+        return r.Body, r.URL.Query().Get("fileName"), nil
+    }
+
+    reader, err := r.MultipartReader()
+    if err != nil {
+        return nil, "", err
+    }
+    for {
+        part, err := reader.NextPart()
+        if err == io.EOF {
+            return nil, "", errors.New("multipart form has no file field")
+        }
+        if err != nil {
+            return nil, "", err
+        }
+        if part.FormName() == "file" {
+            return part, part.FileName(), nil
+        }
+    }
+}
+
+// etagMatches reports whether an If-None-Match header names the ETag
+func etagMatches(header, etag string) bool {
+    for _, candidate := range strings.Split(header, ",") {
+        candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+        if candidate == etag || candidate == "*" {
+            return true
+        }
+    }
+    return false
+}
+
+// sendCoverError maps cover errors to API responses
+func sendCoverError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrCoverNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Cover not found", "The book has no cover image").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrCoverTooLarge):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("PAYLOAD_TOO_LARGE", "Cover image too large", err.Error()).
+            Send(w, http.StatusRequestEntityTooLarge)
+    case errors.Is(err, service.ErrUnsupportedImage):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("UNSUPPORTED_MEDIA_TYPE", "Unsupported cover image", err.Error()).
+            Send(w, http.StatusUnsupportedMediaType)
+    case errors.Is(err, service.ErrCoverDimensions):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Invalid cover image", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, service.ErrUnknownCoverSize):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid size value", "Supported values: small, medium, large").
+            Send(w, http.StatusBadRequest)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Cover request failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
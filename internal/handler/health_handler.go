@@ -0,0 +1,55 @@
+package handler
+
+import (
+    "net/http"
+    "strings"
+    "LibraryGo/internal/health"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/utils"
+)
+
+// HealthHandler answers the orchestrator's liveness and readiness probes
+type HealthHandler struct {
+    registry *health.Registry
+}
+
+// NewHealthHandler creates a handler
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+    return &HealthHandler{registry: registry}
+}
+
+// Liveness handles GET /healthz, failing when the process should be restarted
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+    sendHealthReport(w, h.registry.Live(r.Context()), "Service is unhealthy")
+}
+
+// Readiness handles GET /readyz, failing when the server should get no traffic,
+// including while it shuts down
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+    sendHealthReport(w, h.registry.Ready(r.Context()), "Service is not ready")
+}
+
+// sendHealthReport answers 200 when every check is ok and 503 otherwise, with the
+// report in both cases
+func sendHealthReport(w http.ResponseWriter, report model.HealthReport, message string) {
+    w.Header().Set("Cache-Control", "no-store")
+    if report.Status == model.HealthOK {
+        utils.NewResponse().
+            WithSuccess(true).
+            WithData(report).
+            Send(w, http.StatusOK)
+        return
+    }
+
+    var failing []string
+    for _, check := range report.Checks {
+        if check.Status != model.HealthOK {
+            failing = append(failing, check.Name)
+        }
+    }
+    utils.NewResponse().
+        WithSuccess(false).
+        WithData(report).
+        WithError("SERVICE_UNAVAILABLE", message, "failing checks: "+strings.Join(failing, ", ")).
+        Send(w, http.StatusServiceUnavailable)
+}
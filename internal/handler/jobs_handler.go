@@ -0,0 +1,103 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/jobs"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/utils"
+)
+
+// JobsHandler handles HTTP requests for background jobs
+type JobsHandler struct {
+    scheduler *jobs.Scheduler
+}
+
+// NewJobsHandler creates a handler
+func NewJobsHandler(scheduler *jobs.Scheduler) *JobsHandler {
+    return &JobsHandler{scheduler: scheduler}
+}
+
+// GetJobs handles GET /admin/jobs
+func (h *JobsHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+    statuses := h.scheduler.Jobs()
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(statuses).
+        WithMeta(&model.MetaData{
+            Total: len(statuses),
+            Count: len(statuses),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetJob handles GET /admin/jobs/{name}
+func (h *JobsHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+    status, err := h.scheduler.Status(mux.Vars(r)["name"])
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Job not found", "No job is registered with the provided name").
+            Send(w, http.StatusNotFound)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(status).
+        Send(w, http.StatusOK)
+}
+
+// RunJob handles POST /admin/jobs/{name}/run
+// The job runs in the background unless ?wait=true is given
+func (h *JobsHandler) RunJob(w http.ResponseWriter, r *http.Request) {
+    name := mux.Vars(r)["name"]
+    wait := r.URL.Query().Get("wait") == "true"
+
+    var err error
+    if wait {
+        err = h.scheduler.Run(r.Context(), name)
+    } else {
+        err = h.scheduler.Trigger(name)
+    }
+
+    switch {
+    case errors.Is(err, jobs.ErrJobNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Job not found", "No job is registered with the provided name").
+            Send(w, http.StatusNotFound)
+        return
+    case errors.Is(err, jobs.ErrJobRunning):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("JOB_RUNNING", "Job is already running", err.Error()).
+            Send(w, http.StatusConflict)
+        return
+    case errors.Is(err, jobs.ErrSchedulerStopped):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVICE_UNAVAILABLE", "Jobs are shutting down", err.Error()).
+            Send(w, http.StatusServiceUnavailable)
+        return
+    case err != nil:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("JOB_FAILED", "Job run failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+        return
+    }
+
+    status, _ := h.scheduler.Status(name)
+    statusCode := http.StatusAccepted
+    if wait {
+        statusCode = http.StatusOK
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(status).
+        Send(w, statusCode)
+}
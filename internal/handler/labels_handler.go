@@ -0,0 +1,162 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "LibraryGo/internal/barcode"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// LabelHandler handles HTTP requests for barcodes and label sheets
+type LabelHandler struct {
+    service *service.LabelService
+}
+
+// NewLabelHandler creates a handler
+func NewLabelHandler(service *service.LabelService) *LabelHandler {
+    return &LabelHandler{service: service}
+}
+
+// GetCopyBarcode handles GET /copies/{id}/barcode.
+// Query parameters: symbology (code128 or qr), format (png or svg) and scale in pixels per module.
+func (h *LabelHandler) GetCopyBarcode(w http.ResponseWriter, r *http.Request) {
+    copyID, ok := pathID(w, r, "copy")
+    if !ok {
+        return
+    }
+    scale, ok := barcodeScale(w, r)
+    if !ok {
+        return
+    }
+
+    query := r.URL.Query()
+    data, contentType, err := h.service.CopyBarcode(copyID, query.Get("symbology"), query.Get("format"), scale)
+    sendFile(w, data, contentType, err)
+}
+
+// GetBookBarcode handles GET /books/{id}/barcode, encoding the book ID. Query parameters are
+// those of GET /copies/{id}/barcode, with QR as the default symbology.
+func (h *LabelHandler) GetBookBarcode(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+    scale, ok := barcodeScale(w, r)
+    if !ok {
+        return
+    }
+
+    query := r.URL.Query()
+    symbology := query.Get("symbology")
+    if symbology == "" {
+        symbology = barcode.QR
+    }
+    data, contentType, err := h.service.BookBarcode(bookID, symbology, query.Get("format"), scale)
+    sendFile(w, data, contentType, err)
+}
+
+// GetStocks handles GET /labels/stocks
+func (h *LabelHandler) GetStocks(w http.ResponseWriter, r *http.Request) {
+    stocks := h.service.Stocks()
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(stocks).
+        WithMeta(&model.MetaData{
+            Total: len(stocks),
+            Count: len(stocks),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// PrintSheet handles POST /labels/sheet and responds with a PDF ready for the label printer
+func (h *LabelHandler) PrintSheet(w http.ResponseWriter, r *http.Request) {
+    var req model.LabelSheetRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    data, err := h.service.Sheet(req)
+    if err != nil {
+        sendLabelError(w, err)
+        return
+    }
+
+    w.Header().Set("Content-Disposition", `inline; filename="labels.pdf"`)
+    sendFile(w, data, "application/pdf", nil)
+}
+
+// barcodeScale reads the optional scale query parameter
+func barcodeScale(w http.ResponseWriter, r *http.Request) (int, bool) {
+    value := r.URL.Query().Get("scale")
+    if value == "" {
+        return 0, true
+    }
+    scale, err := strconv.Atoi(value)
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid scale format", "Scale must be a valid number").
+            Send(w, http.StatusBadRequest)
+        return 0, false
+    }
+    return scale, true
+}
+
+// sendFile writes a rendered barcode or sheet, or the error that prevented rendering it
+func sendFile(w http.ResponseWriter, data []byte, contentType string, err error) {
+    if err != nil {
+        sendLabelError(w, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", contentType)
+    w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.WriteHeader(http.StatusOK)
+    w.Write(data)
+}
+
+// sendLabelError maps barcode and label errors to API responses
+func sendLabelError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrCopyNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Copy not found", "No copy exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrUnknownSymbology),
+        errors.Is(err, service.ErrUnknownImageFormat),
+        errors.Is(err, service.ErrInvalidModule):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid barcode options", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, service.ErrUnknownStock),
+        errors.Is(err, service.ErrNoLabels),
+        errors.Is(err, service.ErrTooManyLabels),
+        errors.Is(err, service.ErrInvalidSkip),
+        errors.Is(err, barcode.ErrInvalidChar),
+        errors.Is(err, barcode.ErrTooLong):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Cannot print labels", err.Error()).
+            Send(w, http.StatusBadRequest)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Failed to render barcode", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
@@ -0,0 +1,331 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "mime"
+    "net/http"
+    "strconv"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// LendingHandler handles HTTP requests for digital assets and their loans
+type LendingHandler struct {
+    service *service.LendingService
+}
+
+// NewLendingHandler creates a handler
+func NewLendingHandler(service *service.LendingService) *LendingHandler {
+    return &LendingHandler{service: service}
+}
+
+// borrowRequest is the body of POST /assets/{id}/loans
+type borrowRequest struct {
+    PatronID int `json:"patronId"`
+}
+
+// assetRequest is the body of PUT /assets/{id}
+type assetRequest struct {
+    Licenses int `json:"licenses"`
+}
+
+// AddAsset handles POST /books/{id}/assets.
+// The file is the raw body or the "file" field of a multipart form; licenses is an optional query parameter.
+func (h *LendingHandler) AddAsset(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+    licenses, ok := queryID(w, r, "licenses")
+    if !ok {
+        return
+    }
+
+    r.Body = http.MaxBytesReader(w, r.Body, service.MaxAssetBytes+multipartOverhead)
+    file, fileName, err := openUpload(r)
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        sendLendingError(w, service.ErrAssetTooLarge)
+        return
+    }
+    if err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    asset, err := h.service.AddAsset(bookID, fileName, licenses, file)
+    if errors.As(err, &tooLarge) {
+        sendLendingError(w, service.ErrAssetTooLarge)
+        return
+    }
+    if err != nil {
+        sendLendingError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(asset).
+        Send(w, http.StatusCreated)
+}
+
+// GetAssets handles GET /books/{id}/assets
+func (h *LendingHandler) GetAssets(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    assets, err := h.service.GetAssets(bookID)
+    if err != nil {
+        sendLendingError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(assets).
+        WithMeta(&model.MetaData{
+            Total: len(assets),
+            Count: len(assets),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetAssetByID handles GET /assets/{id}
+func (h *LendingHandler) GetAssetByID(w http.ResponseWriter, r *http.Request) {
+    assetID, ok := pathID(w, r, "asset")
+    if !ok {
+        return
+    }
+
+    asset, err := h.service.GetAssetByID(assetID)
+    if err != nil {
+        sendLendingError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(asset).
+        Send(w, http.StatusOK)
+}
+
+// UpdateAsset handles PUT /assets/{id}, changing its number of licenses
+func (h *LendingHandler) UpdateAsset(w http.ResponseWriter, r *http.Request) {
+    assetID, ok := pathID(w, r, "asset")
+    if !ok {
+        return
+    }
+
+    var req assetRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    asset, err := h.service.SetLicenses(assetID, req.Licenses)
+    if err != nil {
+        sendLendingError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(asset).
+        Send(w, http.StatusOK)
+}
+
+// DeleteAsset handles DELETE /assets/{id}
+func (h *LendingHandler) DeleteAsset(w http.ResponseWriter, r *http.Request) {
+    assetID, ok := pathID(w, r, "asset")
+    if !ok {
+        return
+    }
+
+    if err := h.service.DeleteAsset(assetID); err != nil {
+        sendLendingError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        Send(w, http.StatusNoContent)
+}
+
+// Borrow handles POST /assets/{id}/loans
+func (h *LendingHandler) Borrow(w http.ResponseWriter, r *http.Request) {
+    assetID, ok := pathID(w, r, "asset")
+    if !ok {
+        return
+    }
+
+    var req borrowRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        sendInvalidBody(w, err)
+        return
+    }
+
+    loan, err := h.service.Borrow(assetID, req.PatronID)
+    if err != nil {
+        sendLendingError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(loan).
+        Send(w, http.StatusCreated)
+}
+
+// GetLoans handles GET /digital-loans
+func (h *LendingHandler) GetLoans(w http.ResponseWriter, r *http.Request) {
+    assetID, ok := queryID(w, r, "assetId")
+    if !ok {
+        return
+    }
+    patronID, ok := queryID(w, r, "patronId")
+    if !ok {
+        return
+    }
+
+    loans := h.service.GetLoans(assetID, patronID, r.URL.Query().Get("status"))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(loans).
+        WithMeta(&model.MetaData{
+            Total: len(loans),
+            Count: len(loans),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetLoanByID handles GET /digital-loans/{id}; active loans come with a fresh download link
+func (h *LendingHandler) GetLoanByID(w http.ResponseWriter, r *http.Request) {
+    loanID, ok := pathID(w, r, "loan")
+    if !ok {
+        return
+    }
+
+    loan, err := h.service.GetLoanByID(loanID)
+    if err != nil {
+        sendLendingError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(loan).
+        Send(w, http.StatusOK)
+}
+
+// ReturnLoan handles POST /digital-loans/{id}/return
+func (h *LendingHandler) ReturnLoan(w http.ResponseWriter, r *http.Request) {
+    loanID, ok := pathID(w, r, "loan")
+    if !ok {
+        return
+    }
+
+    loan, err := h.service.Return(loanID)
+    if err != nil {
+        sendLendingError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(loan).
+        Send(w, http.StatusOK)
+}
+
+// Download handles GET /downloads/{id}, the signed link of a digital loan
+func (h *LendingHandler) Download(w http.ResponseWriter, r *http.Request) {
+    loanID, ok := pathID(w, r, "loan")
+    if !ok {
+        return
+    }
+
+    query := r.URL.Query()
+    asset, file, err := h.service.Download(loanID, query.Get("expires"), query.Get("signature"))
+    if err != nil {
+        sendLendingError(w, err)
+        return
+    }
+    defer file.Close()
+
+    w.Header().Set("Content-Type", asset.ContentType)
+    w.Header().Set("Content-Length", strconv.Itoa(asset.Size))
+    w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": asset.FileName}))
+    w.Header().Set("Cache-Control", "private, no-store")
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.WriteHeader(http.StatusOK)
+    io.Copy(w, file)
+}
+
+// sendLendingError maps digital lending errors to API responses
+func sendLendingError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrAssetNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Asset not found", "No digital asset exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrDigitalLoanNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Loan not found", "No digital loan exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrPatronNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Patron not found", "No patron exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrAssetTooLarge):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("PAYLOAD_TOO_LARGE", "File too large", err.Error()).
+            Send(w, http.StatusRequestEntityTooLarge)
+    case errors.Is(err, service.ErrUnsupportedAsset):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("UNSUPPORTED_MEDIA_TYPE", "Unsupported file", err.Error()).
+            Send(w, http.StatusUnsupportedMediaType)
+    case errors.Is(err, service.ErrInvalidLicenses):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Invalid asset", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, service.ErrNoLicense),
+        errors.Is(err, service.ErrAlreadyBorrowed),
+        errors.Is(err, service.ErrDigitalLoanClosed):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("CONFLICT", "Digital loan not possible", err.Error()).
+            Send(w, http.StatusConflict)
+    case errors.Is(err, service.ErrDownloadLinkInvalid):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_SIGNATURE", "Download link is invalid", err.Error()).
+            Send(w, http.StatusForbidden)
+    case errors.Is(err, service.ErrDownloadLinkExpired):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("LINK_EXPIRED", "Download link has expired", "Fetch the loan again for a fresh link").
+            Send(w, http.StatusGone)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Lending request failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "strings"
+    "time"
+    "LibraryGo/internal/auth"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// LoginHandler handles the browser login of staff through the identity provider
+type LoginHandler struct {
+    service *service.LoginService
+    origin  string
+    secure  bool
+}
+
+// NewLoginHandler creates a handler for the server at origin, such as
+// https://library.example.org. Over HTTPS the cookies are marked for HTTPS only.
+func NewLoginHandler(service *service.LoginService, origin string) *LoginHandler {
+    return &LoginHandler{service: service, origin: origin, secure: strings.HasPrefix(origin, "https://")}
+}
+
+// Login handles GET /auth/login, sending the browser to the identity provider.
+// The optional returnTo parameter is the local path to come back to.
+func (h *LoginHandler) Login(w http.ResponseWriter, r *http.Request) {
+    url, state, err := h.service.Begin(r.Context(), r.URL.Query().Get("returnTo"))
+    if err != nil {
+        sendLoginError(w, err)
+        return
+    }
+    // Lax still sends the cookie on the provider's top-level redirect back to the callback
+    http.SetCookie(w, h.stateCookie(state, int(service.LoginTTL/time.Second)))
+    w.Header().Set("Cache-Control", "no-store")
+    http.Redirect(w, r, url, http.StatusFound)
+}
+
+// Callback handles GET /auth/callback, where the identity provider sends the browser
+// back with an authorization code
+func (h *LoginHandler) Callback(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query()
+    if providerError := query.Get("error"); providerError != "" {
+        details := providerError
+        if description := query.Get("error_description"); description != "" {
+            details += ": " + description
+        }
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("UNAUTHORIZED", "Login was refused", details).
+            Send(w, http.StatusUnauthorized)
+        return
+    }
+
+    browserState := ""
+    if cookie, err := r.Cookie(auth.LoginStateCookie); err == nil {
+        browserState = cookie.Value
+    }
+    session, returnTo, err := h.service.Complete(r.Context(), query.Get("state"), browserState, query.Get("code"))
+    if err != nil {
+        sendLoginError(w, err)
+        return
+    }
+    // Lax keeps the cookie off requests started by other sites, except top-level navigation
+    http.SetCookie(w, &http.Cookie{
+        Name:     auth.SessionCookie,
+        Value:    session.ID,
+        Path:     "/",
+        Expires:  session.ExpiresAt,
+        HttpOnly: true,
+        Secure:   h.secure,
+        SameSite: http.SameSiteLaxMode,
+    })
+    http.SetCookie(w, h.stateCookie("", -1))
+    w.Header().Set("Cache-Control", "no-store")
+    http.Redirect(w, r, returnTo, http.StatusFound)
+}
+
+// Logout handles POST /auth/logout, ending the browser's session. Browsers send the
+// cookie whatever page posts the form, so requests from other sites are refused.
+func (h *LoginHandler) Logout(w http.ResponseWriter, r *http.Request) {
+    if !h.sameOrigin(r) {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("FORBIDDEN", "Cross-site request refused", "Log out from a page of this server").
+            Send(w, http.StatusForbidden)
+        return
+    }
+    cookie, err := r.Cookie(auth.SessionCookie)
+    if err != nil {
+        sendLoginError(w, service.ErrNoSession)
+        return
+    }
+    if err := h.service.Logout(cookie.Value); err != nil {
+        sendLoginError(w, err)
+        return
+    }
+    http.SetCookie(w, &http.Cookie{
+        Name:     auth.SessionCookie,
+        Value:    "",
+        Path:     "/",
+        MaxAge:   -1,
+        HttpOnly: true,
+        Secure:   h.secure,
+        SameSite: http.SameSiteLaxMode,
+    })
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// sameOrigin reports whether a browser request comes from a page of this server.
+// Browsers send Origin or Sec-Fetch-Site with every POST; other clients send neither
+// and carry no cookies another site could have them replay.
+func (h *LoginHandler) sameOrigin(r *http.Request) bool {
+    if origin := r.Header.Get("Origin"); origin != "" {
+        return origin == h.origin
+    }
+    site := r.Header.Get("Sec-Fetch-Site")
+    return site == "" || site == "same-origin" || site == "none"
+}
+
+// stateCookie keeps the state of a login in progress in the browser; a negative maxAge
+// removes it
+func (h *LoginHandler) stateCookie(state string, maxAge int) *http.Cookie {
+    return &http.Cookie{
+        Name:     auth.LoginStateCookie,
+        Value:    state,
+        Path:     "/auth/callback",
+        MaxAge:   maxAge,
+        HttpOnly: true,
+        Secure:   h.secure,
+        SameSite: http.SameSiteLaxMode,
+    }
+}
+
+func sendLoginError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, service.ErrUnsafeReturnPath):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid return path", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, service.ErrLoginStateInvalid):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Login could not be completed", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, auth.ErrCodeExchange),
+        errors.Is(err, auth.ErrInvalidToken),
+        errors.Is(err, auth.ErrTokenExpired),
+        errors.Is(err, auth.ErrNonceMismatch):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("UNAUTHORIZED", "Login was refused", err.Error()).
+            Send(w, http.StatusUnauthorized)
+    case errors.Is(err, service.ErrNoSession):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("UNAUTHORIZED", "Not logged in", err.Error()).
+            Send(w, http.StatusUnauthorized)
+    case errors.Is(err, service.ErrTooManyLogins):
+        w.Header().Set("Retry-After", "60")
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVICE_UNAVAILABLE", "Login is busy", err.Error()).
+            Send(w, http.StatusServiceUnavailable)
+    case errors.Is(err, service.ErrNoRole):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("FORBIDDEN", "Login was refused", err.Error()).
+            Send(w, http.StatusForbidden)
+    case errors.Is(err, auth.ErrProviderUnavailable):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("PROVIDER_UNAVAILABLE", "Identity provider is unavailable", err.Error()).
+            Send(w, http.StatusBadGateway)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Login failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+    "net/http"
+    "LibraryGo/internal/metrics"
+)
+
+// MetricsHandler exposes the server's metrics to Prometheus
+type MetricsHandler struct {
+    registry *metrics.Registry
+}
+
+// NewMetricsHandler creates a handler
+func NewMetricsHandler(registry *metrics.Registry) *MetricsHandler {
+    return &MetricsHandler{registry: registry}
+}
+
+// GetMetrics handles GET /metrics in the Prometheus text exposition format
+func (h *MetricsHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", metrics.ContentType)
+    w.Header().Set("Cache-Control", "no-store")
+    h.registry.WriteTo(w)
+}
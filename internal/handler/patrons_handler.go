@@ -0,0 +1,109 @@
+package handler
+
+import (
+    "encoding/json"
+    "net/http"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// PatronHandler handles HTTP requests for patrons and their notifications
+type PatronHandler struct {
+    service       *service.PatronService
+    notifications *service.NotificationService
+}
+
+// NewPatronHandler creates a handler
+func NewPatronHandler(service *service.PatronService, notifications *service.NotificationService) *PatronHandler {
+    return &PatronHandler{service: service, notifications: notifications}
+}
+
+// AddPatron handles POST /patrons
+func (h *PatronHandler) AddPatron(w http.ResponseWriter, r *http.Request) {
+    var newPatron model.Patron
+    if err := json.NewDecoder(r.Body).Decode(&newPatron); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    createdPatron, err := h.service.AddPatron(newPatron)
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Failed to create patron", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(createdPatron).
+        Send(w, http.StatusCreated)
+}
+
+// GetPatrons handles GET /patrons
+func (h *PatronHandler) GetPatrons(w http.ResponseWriter, r *http.Request) {
+    patrons := h.service.GetAllPatrons()
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(patrons).
+        WithMeta(&model.MetaData{
+            Total: len(patrons),
+            Count: len(patrons),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetPatronByID handles GET /patrons/{id}
+func (h *PatronHandler) GetPatronByID(w http.ResponseWriter, r *http.Request) {
+    patronID, ok := pathID(w, r, "patron")
+    if !ok {
+        return
+    }
+
+    patron, err := h.service.GetPatronByID(patronID)
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Patron not found", "No patron exists with the provided ID").
+            Send(w, http.StatusNotFound)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(patron).
+        Send(w, http.StatusOK)
+}
+
+// GetNotifications handles GET /patrons/{id}/notifications
+func (h *PatronHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+    patronID, ok := pathID(w, r, "patron")
+    if !ok {
+        return
+    }
+
+    query := r.URL.Query()
+    notifications, err := h.notifications.GetDeliveries(patronID, query.Get("kind"), query.Get("status"))
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Patron not found", "No patron exists with the provided ID").
+            Send(w, http.StatusNotFound)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(notifications).
+        WithMeta(&model.MetaData{
+            Total: len(notifications),
+            Count: len(notifications),
+        }).
+        Send(w, http.StatusOK)
+}
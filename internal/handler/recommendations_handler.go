@@ -0,0 +1,115 @@
+package handler
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "LibraryGo/internal/auth"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// RecommendationHandler handles HTTP requests for book recommendations
+type RecommendationHandler struct {
+    service *service.RecommendationService
+    policy  *auth.Policy
+}
+
+// NewRecommendationHandler creates a handler
+func NewRecommendationHandler(service *service.RecommendationService, policy *auth.Policy) *RecommendationHandler {
+    return &RecommendationHandler{service: service, policy: policy}
+}
+
+// GetRelatedBooks handles GET /books/{id}/related
+func (h *RecommendationHandler) GetRelatedBooks(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+    limit, ok := recommendationLimit(w, r)
+    if !ok {
+        return
+    }
+
+    recommendations, err := h.service.Related(bookID, limit)
+    if err != nil {
+        sendRecommendationError(w, err)
+        return
+    }
+    sendRecommendations(w, recommendations)
+}
+
+// GetPatronRecommendations handles GET /patrons/{id}/recommendations
+func (h *RecommendationHandler) GetPatronRecommendations(w http.ResponseWriter, r *http.Request) {
+    patronID, ok := pathID(w, r, "patron")
+    if !ok {
+        return
+    }
+    limit, ok := recommendationLimit(w, r)
+    if !ok {
+        return
+    }
+
+    recommendations, err := h.service.ForPatron(patronID, limit, actorOf(r, h.policy, auth.PermPatronsRead))
+    if err != nil {
+        sendRecommendationError(w, err)
+        return
+    }
+    sendRecommendations(w, recommendations)
+}
+
+// recommendationLimit parses the optional limit query parameter
+func recommendationLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+    value := r.URL.Query().Get("limit")
+    if value == "" {
+        return 0, true
+    }
+    limit, err := strconv.Atoi(value)
+    if err != nil || limit < 1 || limit > service.MaxRecommendations {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_PARAMETER", "Invalid limit format", "Limit must be between 1 and "+strconv.Itoa(service.MaxRecommendations)).
+            Send(w, http.StatusBadRequest)
+        return 0, false
+    }
+    return limit, true
+}
+
+func sendRecommendations(w http.ResponseWriter, recommendations []model.Recommendation) {
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(recommendations).
+        WithMeta(&model.MetaData{
+            Total: len(recommendations),
+            Count: len(recommendations),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// sendRecommendationError maps recommendation errors to API responses
+func sendRecommendationError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrPatronNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Patron not found", "No patron exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrNotOwnRecommendations):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("FORBIDDEN", "Not your recommendations", err.Error()).
+            Send(w, http.StatusForbidden)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Recommendation request failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
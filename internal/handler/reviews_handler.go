@@ -0,0 +1,196 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "LibraryGo/internal/auth"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// ReviewHandler handles HTTP requests for book reviews and their moderation.
+// Reviews are written by the authenticated patron, or by moderators on a patron's behalf.
+type ReviewHandler struct {
+    service *service.ReviewService
+    policy  *auth.Policy
+}
+
+// NewReviewHandler creates a handler
+func NewReviewHandler(service *service.ReviewService, policy *auth.Policy) *ReviewHandler {
+    return &ReviewHandler{service: service, policy: policy}
+}
+
+// actor returns who the request acts as
+func (h *ReviewHandler) actor(r *http.Request) model.Actor {
+    return actorOf(r, h.policy, auth.PermReviewsModerate)
+}
+
+// AddReview handles POST /books/{id}/reviews
+func (h *ReviewHandler) AddReview(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    var newReview model.Review
+    if err := json.NewDecoder(r.Body).Decode(&newReview); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+    newReview.BookID = bookID
+
+    review, err := h.service.AddReview(newReview, h.actor(r))
+    if err != nil {
+        sendReviewError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(review).
+        Send(w, http.StatusCreated)
+}
+
+// GetBookReviews handles GET /books/{id}/reviews.
+// Only approved reviews are listed unless a moderator asks for another status; status=all lists every review.
+func (h *ReviewHandler) GetBookReviews(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    status := r.URL.Query().Get("status")
+    switch status {
+    case "":
+        status = model.ReviewStatusApproved
+    case "all":
+        status = ""
+    }
+
+    reviews, err := h.service.GetBookReviews(bookID, status, h.actor(r))
+    if err != nil {
+        sendReviewError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(reviews).
+        WithMeta(&model.MetaData{
+            Total: len(reviews),
+            Count: len(reviews),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetReviews handles GET /reviews, e.g. ?status=pending for the moderation queue
+func (h *ReviewHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+    reviews := h.service.GetReviews(r.URL.Query().Get("status"))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(reviews).
+        WithMeta(&model.MetaData{
+            Total: len(reviews),
+            Count: len(reviews),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetReviewByID handles GET /reviews/{id}
+func (h *ReviewHandler) GetReviewByID(w http.ResponseWriter, r *http.Request) {
+    reviewID, ok := pathID(w, r, "review")
+    if !ok {
+        return
+    }
+
+    review, err := h.service.GetReviewByID(reviewID)
+    if err != nil {
+        sendReviewError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(review).
+        Send(w, http.StatusOK)
+}
+
+// ApproveReview handles POST /reviews/{id}/approve
+func (h *ReviewHandler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+    h.moderate(w, r, model.ReviewStatusApproved)
+}
+
+// RejectReview handles POST /reviews/{id}/reject
+func (h *ReviewHandler) RejectReview(w http.ResponseWriter, r *http.Request) {
+    h.moderate(w, r, model.ReviewStatusRejected)
+}
+
+// moderate sets the moderation status of the review in the path
+func (h *ReviewHandler) moderate(w http.ResponseWriter, r *http.Request, status string) {
+    reviewID, ok := pathID(w, r, "review")
+    if !ok {
+        return
+    }
+
+    review, err := h.service.Moderate(reviewID, status)
+    if err != nil {
+        sendReviewError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(review).
+        Send(w, http.StatusOK)
+}
+
+// sendReviewError maps review errors to API responses
+func sendReviewError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrReviewNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Review not found", "No review exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, repository.ErrPatronNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Patron not found", "No patron exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrInvalidRating),
+        errors.Is(err, service.ErrReviewTooLong),
+        errors.Is(err, service.ErrInvalidModeration):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Invalid review", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, service.ErrNotReviewer),
+        errors.Is(err, service.ErrModeratorsOnly):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("FORBIDDEN", "Permission denied", err.Error()).
+            Send(w, http.StatusForbidden)
+    case errors.Is(err, service.ErrDuplicateReview):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("CONFLICT", "Review already exists", err.Error()).
+            Send(w, http.StatusConflict)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Review request failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// StocktakeHandler handles HTTP requests for stocktake sessions
+type StocktakeHandler struct {
+    service *service.StocktakeService
+}
+
+// NewStocktakeHandler creates a handler
+func NewStocktakeHandler(service *service.StocktakeService) *StocktakeHandler {
+    return &StocktakeHandler{service: service}
+}
+
+// stocktakeRequest is the body of POST /stocktakes
+type stocktakeRequest struct {
+    BranchID int      `json:"branchId"`
+    Shelves  []string `json:"shelves,omitempty"`
+}
+
+// scanRequest is the body of POST /stocktakes/{id}/scans
+type scanRequest struct {
+    Shelf    string   `json:"shelf"`
+    Barcodes []string `json:"barcodes"`
+}
+
+// markLostRequest is the body of POST /stocktakes/{id}/mark-lost
+type markLostRequest struct {
+    CopyIDs []int `json:"copyIds,omitempty"`
+}
+
+// StartSession handles POST /stocktakes
+func (h *StocktakeHandler) StartSession(w http.ResponseWriter, r *http.Request) {
+    var req stocktakeRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    session, err := h.service.StartSession(req.BranchID, req.Shelves)
+    if err != nil {
+        sendStocktakeError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(session).
+        Send(w, http.StatusCreated)
+}
+
+// GetSessions handles GET /stocktakes
+func (h *StocktakeHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+    branchID := 0
+    if value := r.URL.Query().Get("branchId"); value != "" {
+        id, err := strconv.Atoi(value)
+        if err != nil {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_PARAMETER", "Invalid branchId format", "ID must be a valid number").
+                Send(w, http.StatusBadRequest)
+            return
+        }
+        branchID = id
+    }
+
+    sessions := h.service.GetSessions(branchID, r.URL.Query().Get("status"))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(sessions).
+        WithMeta(&model.MetaData{
+            Total: len(sessions),
+            Count: len(sessions),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetSessionByID handles GET /stocktakes/{id}
+func (h *StocktakeHandler) GetSessionByID(w http.ResponseWriter, r *http.Request) {
+    sessionID, ok := pathID(w, r, "stocktake")
+    if !ok {
+        return
+    }
+
+    session, err := h.service.GetSessionByID(sessionID)
+    if err != nil {
+        sendStocktakeError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(session).
+        Send(w, http.StatusOK)
+}
+
+// AddScans handles POST /stocktakes/{id}/scans
+func (h *StocktakeHandler) AddScans(w http.ResponseWriter, r *http.Request) {
+    sessionID, ok := pathID(w, r, "stocktake")
+    if !ok {
+        return
+    }
+
+    var req scanRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    session, err := h.service.AddScans(sessionID, req.Shelf, req.Barcodes)
+    if err != nil {
+        sendStocktakeError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(session).
+        Send(w, http.StatusOK)
+}
+
+// GetReport handles GET /stocktakes/{id}/report
+func (h *StocktakeHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+    sessionID, ok := pathID(w, r, "stocktake")
+    if !ok {
+        return
+    }
+
+    report, err := h.service.Report(sessionID)
+    if err != nil {
+        sendStocktakeError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(report).
+        Send(w, http.StatusOK)
+}
+
+// CloseSession handles POST /stocktakes/{id}/close
+func (h *StocktakeHandler) CloseSession(w http.ResponseWriter, r *http.Request) {
+    sessionID, ok := pathID(w, r, "stocktake")
+    if !ok {
+        return
+    }
+
+    report, err := h.service.CloseSession(sessionID)
+    if err != nil {
+        sendStocktakeError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(report).
+        Send(w, http.StatusOK)
+}
+
+// MarkLost handles POST /stocktakes/{id}/mark-lost
+func (h *StocktakeHandler) MarkLost(w http.ResponseWriter, r *http.Request) {
+    sessionID, ok := pathID(w, r, "stocktake")
+    if !ok {
+        return
+    }
+
+    var req markLostRequest
+    if r.ContentLength != 0 {
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            utils.NewResponse().
+                WithSuccess(false).
+                WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+                Send(w, http.StatusBadRequest)
+            return
+        }
+    }
+
+    copies, err := h.service.MarkMissingLost(sessionID, req.CopyIDs)
+    if err != nil {
+        sendStocktakeError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(copies).
+        WithMeta(&model.MetaData{
+            Total: len(copies),
+            Count: len(copies),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// sendStocktakeError maps stocktake errors to API responses
+func sendStocktakeError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrStocktakeNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Stocktake not found", "No stocktake session exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrEmptyScan):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Failed to record scans", err.Error()).
+            Send(w, http.StatusBadRequest)
+    case errors.Is(err, service.ErrStocktakeClosed),
+        errors.Is(err, service.ErrNotMissing):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("CONFLICT", "Request conflicts with the stocktake's current state", err.Error()).
+            Send(w, http.StatusConflict)
+    default:
+        sendBranchError(w, err)
+    }
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/service"
+    "LibraryGo/internal/utils"
+)
+
+// SubjectHandler handles HTTP requests for the subject taxonomy and book subjects and tags
+type SubjectHandler struct {
+    service *service.SubjectService
+    books   *service.BookService
+}
+
+// NewSubjectHandler creates a handler
+func NewSubjectHandler(service *service.SubjectService, books *service.BookService) *SubjectHandler {
+    return &SubjectHandler{service: service, books: books}
+}
+
+// bookSubjectsRequest is the body of PUT /books/{id}/subjects
+type bookSubjectsRequest struct {
+    SubjectIDs []int `json:"subjectIds"`
+}
+
+// bookTagsRequest is the body of PUT and POST /books/{id}/tags
+type bookTagsRequest struct {
+    Tags []string `json:"tags"`
+}
+
+// AddSubject handles POST /subjects
+func (h *SubjectHandler) AddSubject(w http.ResponseWriter, r *http.Request) {
+    var newSubject model.Subject
+    if err := json.NewDecoder(r.Body).Decode(&newSubject); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    createdSubject, err := h.service.AddSubject(newSubject)
+    if err != nil {
+        sendSubjectError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(createdSubject).
+        Send(w, http.StatusCreated)
+}
+
+// GetSubjects handles GET /subjects
+func (h *SubjectHandler) GetSubjects(w http.ResponseWriter, r *http.Request) {
+    subjects := h.service.GetSubjects(r.URL.Query().Get("kind"))
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(subjects).
+        WithMeta(&model.MetaData{
+            Total: len(subjects),
+            Count: len(subjects),
+        }).
+        Send(w, http.StatusOK)
+}
+
+// GetSubjectByID handles GET /subjects/{id}
+func (h *SubjectHandler) GetSubjectByID(w http.ResponseWriter, r *http.Request) {
+    subjectID, ok := pathID(w, r, "subject")
+    if !ok {
+        return
+    }
+
+    subject, err := h.service.GetSubjectByID(subjectID)
+    if err != nil {
+        sendSubjectError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(subject).
+        Send(w, http.StatusOK)
+}
+
+// UpdateSubject handles PUT /subjects/{id}
+func (h *SubjectHandler) UpdateSubject(w http.ResponseWriter, r *http.Request) {
+    subjectID, ok := pathID(w, r, "subject")
+    if !ok {
+        return
+    }
+
+    var subject model.Subject
+    if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+    subject.ID = subjectID
+
+    updatedSubject, err := h.service.UpdateSubject(subject)
+    if err != nil {
+        sendSubjectError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(updatedSubject).
+        Send(w, http.StatusOK)
+}
+
+// SetBookSubjects handles PUT /books/{id}/subjects
+func (h *SubjectHandler) SetBookSubjects(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    var req bookSubjectsRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    book, err := h.books.SetSubjects(r.Context(), bookID, req.SubjectIDs)
+    sendBookUpdate(w, book, err)
+}
+
+// AddBookSubject handles POST /books/{id}/subjects/{subjectId}
+func (h *SubjectHandler) AddBookSubject(w http.ResponseWriter, r *http.Request) {
+    bookID, subjectID, ok := bookSubjectIDs(w, r)
+    if !ok {
+        return
+    }
+
+    book, err := h.books.AddSubject(r.Context(), bookID, subjectID)
+    sendBookUpdate(w, book, err)
+}
+
+// RemoveBookSubject handles DELETE /books/{id}/subjects/{subjectId}
+func (h *SubjectHandler) RemoveBookSubject(w http.ResponseWriter, r *http.Request) {
+    bookID, subjectID, ok := bookSubjectIDs(w, r)
+    if !ok {
+        return
+    }
+
+    book, err := h.books.RemoveSubject(r.Context(), bookID, subjectID)
+    sendBookUpdate(w, book, err)
+}
+
+// SetBookTags handles PUT /books/{id}/tags
+func (h *SubjectHandler) SetBookTags(w http.ResponseWriter, r *http.Request) {
+    h.updateTags(w, r, h.books.SetTags)
+}
+
+// AddBookTags handles POST /books/{id}/tags
+func (h *SubjectHandler) AddBookTags(w http.ResponseWriter, r *http.Request) {
+    h.updateTags(w, r, h.books.AddTags)
+}
+
+// RemoveBookTag handles DELETE /books/{id}/tags/{tag}
+func (h *SubjectHandler) RemoveBookTag(w http.ResponseWriter, r *http.Request) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    book, err := h.books.RemoveTag(r.Context(), bookID, mux.Vars(r)["tag"])
+    sendBookUpdate(w, book, err)
+}
+
+// updateTags decodes a tag list and applies it to the book in the path
+func (h *SubjectHandler) updateTags(w http.ResponseWriter, r *http.Request, apply func(context.Context, int, []string) (model.Book, error)) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return
+    }
+
+    var req bookTagsRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_REQUEST", "Invalid request body", err.Error()).
+            Send(w, http.StatusBadRequest)
+        return
+    }
+
+    book, err := apply(r.Context(), bookID, req.Tags)
+    sendBookUpdate(w, book, err)
+}
+
+// bookSubjectIDs parses the book and subject IDs of /books/{id}/subjects/{subjectId}
+func bookSubjectIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+    bookID, ok := pathID(w, r, "book")
+    if !ok {
+        return 0, 0, false
+    }
+    subjectID, err := strconv.Atoi(mux.Vars(r)["subjectId"])
+    if err != nil {
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("INVALID_ID", "Invalid subject ID", "ID must be a valid number").
+            Send(w, http.StatusBadRequest)
+        return 0, 0, false
+    }
+    return bookID, subjectID, true
+}
+
+// sendBookUpdate writes the book after a subject or tag change
+func sendBookUpdate(w http.ResponseWriter, book model.Book, err error) {
+    if err != nil {
+        sendSubjectError(w, err)
+        return
+    }
+
+    utils.NewResponse().
+        WithSuccess(true).
+        WithData(book).
+        Send(w, http.StatusOK)
+}
+
+// sendSubjectError maps subject and assignment errors to API responses
+func sendSubjectError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, repository.ErrSubjectNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Subject not found", "No subject exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrBookNotFound):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("NOT_FOUND", "Book not found", "No book exists with the provided ID").
+            Send(w, http.StatusNotFound)
+    case errors.Is(err, service.ErrSubjectDuplicate):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("CONFLICT", "Subject already exists", err.Error()).
+            Send(w, http.StatusConflict)
+    case errors.Is(err, service.ErrSubjectInvalid),
+        errors.Is(err, service.ErrSubjectCycle),
+        errors.Is(err, service.ErrUnknownSubject):
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("VALIDATION_ERROR", "Invalid subject", err.Error()).
+            Send(w, http.StatusBadRequest)
+    default:
+        utils.NewResponse().
+            WithSuccess(false).
+            WithError("SERVER_ERROR", "Subject request failed", err.Error()).
+            Send(w, http.StatusInternalServerError)
+    }
+}
@@ -0,0 +1,150 @@
+// Package health runs the checks behind the liveness and readiness probes
+package health
+
+import (
+	"LibraryGo/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds each check, so one hanging dependency cannot hang the probe
+const DefaultTimeout = 2 * time.Second
+
+// ErrShuttingDown fails readiness once the server has started to shut down
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Check reports whether a dependency is healthy; it must return once ctx is done
+type Check func(ctx context.Context) error
+
+type registered struct {
+	name  string
+	check Check
+	live  bool
+}
+
+// Registry holds the named checks. Liveness checks find faults only a restart fixes;
+// readiness runs them along with checks of dependencies the server needs to serve.
+type Registry struct {
+	timeout time.Duration
+
+	mu       sync.Mutex
+	checks   []registered
+	draining bool
+}
+
+// NewRegistry creates an empty registry; a timeout of zero uses DefaultTimeout
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Registry{timeout: timeout}
+}
+
+// AddLiveness registers a check for both probes
+func (r *Registry) AddLiveness(name string, check Check) {
+	r.add(registered{name: name, check: check, live: true})
+}
+
+// AddReadiness registers a check for the readiness probe only
+func (r *Registry) AddReadiness(name string, check Check) {
+	r.add(registered{name: name, check: check})
+}
+
+func (r *Registry) add(check registered) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check)
+}
+
+// Drain makes readiness fail from now on, so load balancers stop sending requests
+// while the ones in flight finish
+func (r *Registry) Drain() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.draining = true
+}
+
+// Live runs the liveness checks
+func (r *Registry) Live(ctx context.Context) model.HealthReport {
+	r.mu.Lock()
+	var checks []registered
+	for _, check := range r.checks {
+		if check.live {
+			checks = append(checks, check)
+		}
+	}
+	r.mu.Unlock()
+	return r.run(ctx, checks)
+}
+
+// Ready runs every check, and fails while the server is draining
+func (r *Registry) Ready(ctx context.Context) model.HealthReport {
+	r.mu.Lock()
+	checks := append([]registered(nil), r.checks...)
+	if r.draining {
+		checks = append([]registered{{name: "shutdown", check: func(context.Context) error { return ErrShuttingDown }}}, checks...)
+	}
+	r.mu.Unlock()
+	return r.run(ctx, checks)
+}
+
+// run runs the checks concurrently and reports them in registration order
+func (r *Registry) run(ctx context.Context, checks []registered) model.HealthReport {
+	started := time.Now()
+	report := model.HealthReport{Status: model.HealthOK, Checks: make([]model.HealthCheck, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check registered) {
+			defer wg.Done()
+			report.Checks[i] = r.runOne(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status != model.HealthOK {
+			report.Status = model.HealthFailing
+		}
+	}
+	report.DurationMs = milliseconds(time.Since(started))
+	return report
+}
+
+func (r *Registry) runOne(ctx context.Context, check registered) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	started := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- check.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("no answer within %s", r.timeout)
+	}
+
+	result := model.HealthCheck{Name: check.name, Status: model.HealthOK, DurationMs: milliseconds(time.Since(started))}
+	if err != nil {
+		result.Status = model.HealthFailing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
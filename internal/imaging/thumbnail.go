@@ -0,0 +1,77 @@
+// Package imaging scales images using only the standard library
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Thumbnail scales img down to the given width, keeping its aspect ratio.
+// Images no wider than that are returned unchanged.
+func Thumbnail(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 || bounds.Dx() <= width {
+		return img
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	return resize(RGBA(img), width, height)
+}
+
+// RGBA returns img as premultiplied RGBA with its origin at zero, converting it only
+// when it is not already, so an image scaled to several sizes is converted once.
+// Averaging premultiplied pixels treats transparent ones correctly.
+func RGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// resize shrinks src to w×h by averaging the source pixels each target pixel covers
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := span(y, h, sh)
+		for x := 0; x < w; x++ {
+			x0, x1 := span(x, w, sw)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0] = uint8(r / n)
+			d[1] = uint8(g / n)
+			d[2] = uint8(b / n)
+			d[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) covered by target pixel i of n, out of size
+func span(i, n, size int) (int, int) {
+	from := i * size / n
+	to := (i + 1) * size / n
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
@@ -0,0 +1,81 @@
+// Package isbn validates International Standard Book Numbers
+package isbn
+
+import (
+	"errors"
+)
+
+// ErrInvalid is returned when a string is not a valid ISBN-10 or ISBN-13
+var ErrInvalid = errors.New("invalid ISBN")
+
+// Normalize checks the check digit of an ISBN-10 or ISBN-13 and returns it without
+// hyphens or spaces, e.g. "0-306-40615-2" becomes "0306406152"
+func Normalize(value string) (string, error) {
+	var digits []byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case c == 'X' || c == 'x':
+			digits = append(digits, 'X')
+		case c == '-' || c == ' ':
+		default:
+			return "", ErrInvalid
+		}
+	}
+
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, c := range digits {
+			d := int(c - '0')
+			if c == 'X' {
+				// X stands for 10 and only as the check digit
+				if i != 9 {
+					return "", ErrInvalid
+				}
+				d = 10
+			}
+			sum += (10 - i) * d
+		}
+		if sum%11 == 0 {
+			return string(digits), nil
+		}
+	case 13:
+		sum := 0
+		for i, c := range digits {
+			if c == 'X' {
+				return "", ErrInvalid
+			}
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += weight * int(c-'0')
+		}
+		if sum%10 == 0 {
+			return string(digits), nil
+		}
+	}
+	return "", ErrInvalid
+}
+
+// ToISBN13 normalizes an ISBN and converts an ISBN-10 to its ISBN-13 form, prefixing
+// 978 and recomputing the check digit, so both forms of one book compare equal
+func ToISBN13(value string) (string, error) {
+	digits, err := Normalize(value)
+	if err != nil || len(digits) == 13 {
+		return digits, err
+	}
+	digits = "978" + digits[:9]
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(digits[i]-'0')
+	}
+	return digits + string(rune('0'+(10-sum%10)%10)), nil
+}
@@ -0,0 +1,339 @@
+package jobs
+
+import (
+	"LibraryGo/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned for an unknown job name
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when a job is triggered while it is still running
+	ErrJobRunning = errors.New("job is already running")
+	// ErrSchedulerStopped is returned when a job is triggered after shutdown
+	ErrSchedulerStopped = errors.New("scheduler is stopped")
+)
+
+// JobFunc is the work performed by a job; it must return promptly once ctx is cancelled
+type JobFunc func(ctx context.Context) error
+
+// Job describes a unit of background work
+type Job struct {
+	Name        string
+	Description string
+	Spec        string
+	Run         JobFunc
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	running  bool
+	next     time.Time
+	state    model.JobState
+}
+
+// Scheduler runs registered jobs on their cron schedules.
+// A job never runs concurrently with itself: a scheduled activation that
+// finds the previous run still in progress is skipped.
+type Scheduler struct {
+	mu       sync.Mutex
+	saveMu   sync.Mutex
+	entries  map[string]*entry
+	store    StateStore
+	restored map[string]model.JobState
+	now      func() time.Time
+	tick     time.Duration
+	lastTick time.Time
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+	stopped bool
+}
+
+// NewScheduler creates a scheduler that restores and persists run history via store
+func NewScheduler(store StateStore) *Scheduler {
+	// Run history is only a record, so a state that cannot be read is not worth failing
+	// over: no restart would fix it. Jobs start over and the next save replaces it.
+	restored, err := store.Load()
+	if err != nil {
+		slog.Error("failed to load job state; starting without run history", "error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		entries:  make(map[string]*entry),
+		store:    store,
+		restored: restored,
+		now:      time.Now,
+		tick:     time.Second,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register adds a job; it must be called before Start
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("job needs a name and a run function")
+	}
+	schedule, err := ParseSchedule(job.Spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	s.entries[job.Name] = &entry{job: job, schedule: schedule, state: s.restored[job.Name]}
+	return nil
+}
+
+// Start begins dispatching jobs until Stop is called or ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+
+	now := s.now()
+	s.lastTick = now
+	for _, e := range s.entries {
+		e.next = e.schedule.Next(now)
+	}
+
+	s.wg.Add(1)
+	go s.loop(ctx)
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	s.shutdown()
+	s.wg.Wait()
+}
+
+// shutdown refuses new runs and cancels the ones in progress
+func (s *Scheduler) shutdown() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.cancel()
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.shutdown()
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.dispatchDue()
+		}
+	}
+}
+
+// dispatchDue starts every job whose next activation has passed
+func (s *Scheduler) dispatchDue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.lastTick = now
+	for _, e := range s.entries {
+		if e.next.IsZero() || now.Before(e.next) {
+			continue
+		}
+		e.next = e.schedule.Next(now)
+		if e.running {
+			slog.Warn("job skipped, previous run still in progress", "job", e.job.Name)
+			continue
+		}
+		s.launch(e)
+	}
+}
+
+// Check reports whether the scheduler can be relied on: once started, it has neither
+// stopped nor stalled. Failed job runs do not count, as they are retried on schedule
+// and show in the job status.
+func (s *Scheduler) Check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return ErrSchedulerStopped
+	}
+	if s.started {
+		if stalled := s.now().Sub(s.lastTick); stalled > 60*s.tick {
+			return fmt.Errorf("scheduler has not dispatched jobs for %s", stalled.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// Trigger starts a job immediately in the background
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.runnable(name)
+	if err != nil {
+		return err
+	}
+	s.launch(e)
+	return nil
+}
+
+// Run executes a job synchronously and returns its error
+func (s *Scheduler) Run(ctx context.Context, name string) error {
+	s.mu.Lock()
+	e, err := s.runnable(name)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	e.running = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	defer s.wg.Done()
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.ctx, cancel)
+	defer stop()
+
+	return s.execute(runCtx, e)
+}
+
+// runnable looks up a job that may be started now; s.mu must be held
+func (s *Scheduler) runnable(name string) (*entry, error) {
+	if s.stopped {
+		return nil, ErrSchedulerStopped
+	}
+	e, exists := s.entries[name]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	if e.running {
+		return nil, ErrJobRunning
+	}
+	return e, nil
+}
+
+// launch runs a job in its own goroutine; s.mu must be held
+func (s *Scheduler) launch(e *entry) {
+	e.running = true
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.execute(s.ctx, e); err != nil {
+			slog.Error("job failed", "job", e.job.Name, "error", err)
+		}
+	}()
+}
+
+// execute runs the job function and records the outcome
+func (s *Scheduler) execute(ctx context.Context, e *entry) (err error) {
+	started := s.now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		s.finish(e, started, err)
+	}()
+
+	return e.job.Run(ctx)
+}
+
+// finish stores the result of a run and persists all job states
+func (s *Scheduler) finish(e *entry, started time.Time, runErr error) {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	finished := s.now()
+	e.running = false
+	e.state.LastRunAt = &started
+	e.state.LastDurationMs = finished.Sub(started).Milliseconds()
+	e.state.RunCount++
+	if runErr != nil {
+		e.state.LastError = runErr.Error()
+		e.state.FailureCount++
+	} else {
+		e.state.LastError = ""
+		e.state.LastSuccessAt = &finished
+	}
+
+	states := make(map[string]model.JobState, len(s.entries))
+	for name, entry := range s.entries {
+		states[name] = entry.state
+	}
+	s.mu.Unlock()
+
+	if err := s.store.Save(states); err != nil {
+		slog.Error("failed to save job state", "error", err)
+	}
+}
+
+// Status returns the current state of a single job
+func (s *Scheduler) Status(name string) (model.JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, exists := s.entries[name]
+	if !exists {
+		return model.JobStatus{}, ErrJobNotFound
+	}
+	return e.status(), nil
+}
+
+// Jobs returns the state of all registered jobs ordered by name
+func (s *Scheduler) Jobs() []model.JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]model.JobStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		statuses = append(statuses, e.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+func (e *entry) status() model.JobStatus {
+	status := model.JobStatus{
+		Name:        e.job.Name,
+		Description: e.job.Description,
+		Schedule:    e.job.Spec,
+		Running:     e.running,
+		JobState:    e.state,
+	}
+	if !e.next.IsZero() {
+		next := e.next
+		status.NextRunAt = &next
+	}
+	return status
+}
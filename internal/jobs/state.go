@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"LibraryGo/internal/model"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// StateStore persists job run history between restarts
+type StateStore interface {
+	Load() (map[string]model.JobState, error)
+	Save(states map[string]model.JobState) error
+}
+
+// MemoryStateStore keeps job state in memory only
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]model.JobState
+}
+
+// NewMemoryStateStore creates an empty in-memory store
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{states: make(map[string]model.JobState)}
+}
+
+// Load returns a copy of the stored states
+func (s *MemoryStateStore) Load() (map[string]model.JobState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]model.JobState, len(s.states))
+	for name, state := range s.states {
+		out[name] = state
+	}
+	return out, nil
+}
+
+// Save replaces the stored states
+func (s *MemoryStateStore) Save(states map[string]model.JobState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states = make(map[string]model.JobState, len(states))
+	for name, state := range states {
+		s.states[name] = state
+	}
+	return nil
+}
+
+// FileStateStore keeps job state in a JSON file
+type FileStateStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStateStore creates a store backed by the given file
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{path: path}
+}
+
+// Load reads the state file; a missing file yields an empty state
+func (s *FileStateStore) Load() (map[string]model.JobState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make(map[string]model.JobState)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// Save writes the state file atomically via a temporary file
+func (s *FileStateStore) Save(states map[string]model.JobState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
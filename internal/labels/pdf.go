@@ -0,0 +1,103 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// document is a minimal PDF writer: pages of filled rectangles and text in the
+// standard Helvetica fonts, which every viewer has, so nothing is embedded
+type document struct {
+	width, height float64
+	pages         []*bytes.Buffer
+}
+
+// Fonts available on every page
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+func newDocument(width, height float64) *document {
+	return &document{width: width, height: height}
+}
+
+// addPage starts a new page; drawing goes to the last page
+func (d *document) addPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// rect fills a rectangle given by its top left corner, measured from the top of the page
+func (d *document) rect(x, y, width, height float64) {
+	page := d.pages[len(d.pages)-1]
+	fmt.Fprintf(page, "%s %s %s %s re f\n", number(x), number(d.height-y-height), number(width), number(height))
+}
+
+// text writes a line whose baseline is y from the top of the page
+func (d *document) text(font string, size, x, y float64, value string) {
+	page := d.pages[len(d.pages)-1]
+	fmt.Fprintf(page, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, number(size), number(x), number(d.height-y), escape(value))
+}
+
+// bytes serialises the document
+func (d *document) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 4 are fixed; each page then takes a page object and a content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), number(d.width), number(d.height)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			fontRegular, fontBold, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// escape encodes text as a PDF string in WinAnsiEncoding, which matches Latin-1
+// for the characters catalogue records mostly use; anything else becomes "?"
+func escape(value string) string {
+	var out strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r >= 32 && r < 127:
+			out.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&out, "\\%03o", r)
+		default:
+			out.WriteByte('?')
+		}
+	}
+	return out.String()
+}
+
+// number formats a length without trailing zeros
+func number(value float64) string {
+	formatted := strings.TrimRight(fmt.Sprintf("%.2f", value), "0")
+	return strings.TrimSuffix(formatted, ".")
+}
@@ -0,0 +1,137 @@
+// Package labels lays out spine and barcode labels on sheets of label stock and
+// renders them as PDF
+package labels
+
+import (
+	"LibraryGo/internal/barcode"
+	"LibraryGo/internal/model"
+	"fmt"
+	"sort"
+)
+
+// DefaultStock is used when a request names none
+const DefaultStock = "avery-5160"
+
+// stocks are the supported label sheets; lengths are in points
+var stocks = map[string]model.LabelStock{
+	"avery-5160": {
+		Name: "avery-5160", Description: "US Letter, 3 x 10 labels of 2 5/8 x 1 in",
+		PageWidth: 612, PageHeight: 792, Columns: 3, Rows: 10,
+		LabelWidth: 189, LabelHeight: 72, Top: 36, Left: 13.5, PitchX: 198, PitchY: 72,
+	},
+	"avery-5167": {
+		Name: "avery-5167", Description: "US Letter, 4 x 20 spine labels of 1 3/4 x 1/2 in",
+		PageWidth: 612, PageHeight: 792, Columns: 4, Rows: 20,
+		LabelWidth: 126, LabelHeight: 36, Top: 36, Left: 21.6, PitchX: 147.6, PitchY: 36,
+	},
+	"avery-l7160": {
+		Name: "avery-l7160", Description: "A4, 3 x 7 labels of 63.5 x 38.1 mm",
+		PageWidth: 595.28, PageHeight: 841.89, Columns: 3, Rows: 7,
+		LabelWidth: 180, LabelHeight: 108, Top: 42.95, Left: 20.4, PitchX: 187.2, PitchY: 108,
+	},
+}
+
+// Layout of a label, in points
+const (
+	padding     = 4
+	maxModule   = 1.5 // Wider bars only waste label space
+	spineHeight = 60  // Labels shorter than this leave out the title
+
+	averageWidth = 0.55 // Average advance of Helvetica as a share of the font size
+)
+
+// Stocks lists the supported label stocks by name
+func Stocks() []model.LabelStock {
+	list := make([]model.LabelStock, 0, len(stocks))
+	for _, stock := range stocks {
+		list = append(list, stock)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Stock looks up a label stock by name
+func Stock(name string) (model.LabelStock, bool) {
+	stock, ok := stocks[name]
+	return stock, ok
+}
+
+// Sheet renders labels onto as many sheets as needed, row by row. The first skip
+// positions are left blank so a partly used sheet can go through the printer again.
+func Sheet(stock model.LabelStock, labels []model.Label, skip int) ([]byte, error) {
+	doc := newDocument(stock.PageWidth, stock.PageHeight)
+	perPage := stock.Columns * stock.Rows
+	for i, label := range labels {
+		position := (skip + i) % perPage
+		if i == 0 || position == 0 {
+			doc.addPage()
+		}
+		x := stock.Left + float64(position%stock.Columns)*stock.PitchX
+		y := stock.Top + float64(position/stock.Columns)*stock.PitchY
+		if err := drawLabel(doc, stock, x, y, label); err != nil {
+			return nil, fmt.Errorf("copy %d: %w", label.CopyID, err)
+		}
+	}
+	return doc.bytes(), nil
+}
+
+// drawLabel draws the title, call number and Code 128 barcode of one copy with
+// the human-readable barcode underneath
+func drawLabel(doc *document, stock model.LabelStock, x, y float64, label model.Label) error {
+	symbol, err := barcode.EncodeCode128(label.Barcode)
+	if err != nil {
+		return err
+	}
+	width := stock.LabelWidth - 2*padding
+	textSize, captionSize := 8.0, 6.0
+	if stock.LabelHeight < spineHeight {
+		textSize, captionSize = 7, 5
+	}
+
+	top := y + padding
+	if stock.LabelHeight >= spineHeight {
+		top += textSize
+		doc.text(fontRegular, textSize, x+padding, top, fit(label.Title, textSize, width))
+		top += 2
+	}
+	if label.CallNumber != "" {
+		top += textSize
+		doc.text(fontBold, textSize, x+padding, top, fit(label.CallNumber, textSize, width))
+	}
+	top += 2
+
+	bottom := y + stock.LabelHeight - padding - captionSize - 1
+	module := width / float64(symbol.Width())
+	if module > maxModule {
+		module = maxModule
+	}
+	left := x + (stock.LabelWidth-module*float64(symbol.Width()))/2 + module*float64(symbol.Quiet)
+	symbol.Runs(func(_, col, length int) {
+		doc.rect(left+float64(col)*module, top, float64(length)*module, bottom-top)
+	})
+
+	caption := fit(label.Barcode, captionSize, width)
+	doc.text(fontRegular, captionSize, x+(stock.LabelWidth-textWidth(caption, captionSize))/2,
+		y+stock.LabelHeight-padding, caption)
+	return nil
+}
+
+// fit shortens text to about the given width
+func fit(text string, size, width float64) string {
+	runes := []rune(text)
+	limit := int(width / (size * averageWidth))
+	if len(runes) <= limit {
+		return text
+	}
+	if limit < 3 {
+		return ""
+	}
+	return string(runes[:limit-3]) + "..."
+}
+
+// textWidth estimates the printed width of text
+func textWidth(text string, size float64) float64 {
+	return float64(len([]rune(text))) * size * averageWidth
+}
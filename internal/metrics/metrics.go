@@ -0,0 +1,298 @@
+// Package metrics keeps counters, gauges and histograms and writes them in the
+// Prometheus text exposition format, without the Prometheus client library
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are upper bounds in seconds suited to request and storage latencies
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample is one value of a collected metric
+type Sample struct {
+	Labels []string // Values of the metric's label names, in order
+	Value  float64
+}
+
+// metric is anything a registry can write
+type metric interface {
+	describe() (name, help, kind string)
+	write(w *bufio.Writer)
+}
+
+// Registry holds the metrics exposed at /metrics
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds a metric, panicking on duplicate names as that is a programming error
+func (r *Registry) register(m metric) {
+	name, _, _ := m.describe()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[name]; exists {
+		panic("metrics: " + name + " registered twice")
+	}
+	r.metrics[name] = m
+}
+
+// WriteTo writes every metric in the text exposition format, ordered by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]metric, len(names))
+	for i, name := range names {
+		list[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: w}
+	out := bufio.NewWriter(counter)
+	for _, m := range list {
+		name, help, kind := m.describe()
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+		m.write(out)
+	}
+	err := out.Flush()
+	return counter.n, err
+}
+
+// Counter is a monotonically increasing value per label set
+type Counter struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+	r.register(c)
+	return c
+}
+
+// Add increases the counter of a label set, given in label name order
+func (c *Counter) Add(delta float64, labels ...string) {
+	key := strings.Join(labels, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	if !ok {
+		v = &counterValue{labels: append([]string(nil), labels...)}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+// Inc adds one
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *Counter) describe() (string, string, string) {
+	return c.name, c.help, "counter"
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		writeSample(w, c.name, c.labels, v.labels, "", "", v.value)
+	}
+}
+
+// Histogram counts observations into cumulative buckets per label set
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // Per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds and label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &Histogram{name: name, help: help, labels: labels, buckets: sorted, series: make(map[string]*histogramSeries)}
+	r.register(h)
+	return h
+}
+
+// Observe records a value for a label set, given in label name order
+func (h *Histogram) Observe(value float64, labels ...string) {
+	key := strings.Join(labels, "\xff")
+	bucket := sort.SearchFloat64s(h.buckets, value) // First bound >= value
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labels: append([]string(nil), labels...), counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	s.counts[bucket]++
+	s.sum += value
+	s.count++
+}
+
+func (h *Histogram) describe() (string, string, string) {
+	return h.name, h.help, "histogram"
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labels, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labels, "", "", float64(s.count))
+	}
+}
+
+// collector reads its samples when the registry is written, for values that are
+// cheaper to look up than to keep in step, such as the number of books
+type collector struct {
+	name, help, kind string
+	labels           []string
+	collect          func() []Sample
+}
+
+// NewGaugeFunc registers a gauge whose value is read on every scrape
+func (r *Registry) NewGaugeFunc(name, help string, value func() float64) {
+	r.NewCollector(name, help, "gauge", nil, func() []Sample {
+		return []Sample{{Value: value()}}
+	})
+}
+
+// NewCollector registers a metric of kind "gauge" or "counter" whose labelled
+// samples are read on every scrape
+func (r *Registry) NewCollector(name, help, kind string, labels []string, collect func() []Sample) {
+	r.register(&collector{name: name, help: help, kind: kind, labels: labels, collect: collect})
+}
+
+func (c *collector) describe() (string, string, string) {
+	return c.name, c.help, c.kind
+}
+
+func (c *collector) write(w *bufio.Writer) {
+	for _, sample := range c.collect() {
+		writeSample(w, c.name, c.labels, sample.Labels, "", "", sample.Value)
+	}
+}
+
+// writeSample writes one line, with an extra label such as le appended when given
+func writeSample(w *bufio.Writer, name string, names, values []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(names) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, label := range names {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			labelValue := ""
+			if i < len(values) {
+				labelValue = values[i]
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(labelValue))
+		}
+		if extraName != "" {
+			if len(names) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func escapeHelp(value string) string {
+	return helpEscaper.Replace(value)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
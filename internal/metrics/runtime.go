@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// RegisterRuntime adds Go runtime and process metrics under the names the Prometheus
+// client library uses, so existing dashboards work
+func (r *Registry) RegisterRuntime() {
+	started := time.Now()
+	// Reading memory stats stops the world, so one read serves a whole scrape
+	var mu sync.Mutex
+	var cached runtime.MemStats
+	var readAt time.Time
+	stats := func() runtime.MemStats {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(readAt) > time.Second {
+			runtime.ReadMemStats(&cached)
+			readAt = time.Now()
+		}
+		return cached
+	}
+
+	r.NewCollector("go_info", "Information about the Go environment.", "gauge", []string{"version"}, func() []Sample {
+		return []Sample{{Labels: []string{runtime.Version()}, Value: 1}}
+	})
+	r.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+	r.NewGaugeFunc("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", func() float64 {
+		return float64(stats().Alloc)
+	})
+	r.NewGaugeFunc("go_memstats_heap_objects", "Number of allocated objects.", func() float64 {
+		return float64(stats().HeapObjects)
+	})
+	r.NewGaugeFunc("go_memstats_sys_bytes", "Number of bytes obtained from system.", func() float64 {
+		return float64(stats().Sys)
+	})
+	r.NewCollector("go_memstats_mallocs_total", "Total number of mallocs.", "counter", nil, func() []Sample {
+		return []Sample{{Value: float64(stats().Mallocs)}}
+	})
+	r.NewCollector("go_gc_cycles_total", "Number of completed GC cycles.", "counter", nil, func() []Sample {
+		return []Sample{{Value: float64(stats().NumGC)}}
+	})
+	r.NewCollector("go_gc_pause_seconds_total", "Total time the world was stopped for GC.", "counter", nil, func() []Sample {
+		return []Sample{{Value: time.Duration(stats().PauseTotalNs).Seconds()}}
+	})
+	r.NewGaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", func() float64 {
+		return float64(started.UnixNano()) / 1e9
+	})
+}
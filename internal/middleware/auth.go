@@ -0,0 +1,109 @@
+// Package middleware holds the HTTP middleware that wraps every route
+package middleware
+
+import (
+	"LibraryGo/internal/auth"
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/utils"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries a static API key; keys may also be sent as bearer tokens
+const APIKeyHeader = "X-API-Key"
+
+// Authenticate attaches the principal of a request's API key, bearer token or session
+// cookie to its context. Requests without credentials go on as auth.Anonymous, leaving
+// it to Authorize to decide what they may do; bad keys and tokens are refused everywhere.
+// A session cookie that has expired is ignored instead, so the browser can still log in
+// again. tokens and sessions may be nil when not configured.
+func Authenticate(keys *auth.KeyStore, tokens *auth.JWTVerifier, sessions *auth.SessionStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credential, isToken, ok := credentials(r)
+			if !ok {
+				sendUnauthorized(w, `Bearer realm="library", error="invalid_request"`, "Use a Bearer token or the "+APIKeyHeader+" header")
+				return
+			}
+			if credential == "" {
+				principal := auth.Anonymous
+				if session, ok := sessionOf(r, sessions); ok {
+					principal = session.Principal
+				}
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+				return
+			}
+
+			var principal model.Principal
+			var err error
+			if isToken {
+				err = auth.ErrInvalidToken
+				if tokens != nil {
+					principal, err = tokens.Authenticate(credential)
+				}
+			} else {
+				principal, err = keys.Authenticate(credential)
+			}
+			if err != nil {
+				details := "The credentials are not valid"
+				if errors.Is(err, auth.ErrTokenExpired) {
+					details = "The bearer token has expired"
+				}
+				sendUnauthorized(w, `Bearer realm="library", error="invalid_token"`, details)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// Trust attaches the same principal to every request, for when authentication is turned off
+func Trust(principal model.Principal) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// credentials extracts the API key or bearer token of a request. Bearer values shaped
+// like a JWT are tokens; anything else is taken as an API key. ok is false for
+// unsupported authorization schemes.
+func credentials(r *http.Request) (credential string, isToken, ok bool) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key, false, true
+	}
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", false, true
+	}
+	scheme, value, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(value) == "" {
+		return "", false, false
+	}
+	value = strings.TrimSpace(value)
+	return value, strings.Count(value, ".") == 2, true
+}
+
+// sessionOf looks up the live session named by a request's cookie
+func sessionOf(r *http.Request, sessions *auth.SessionStore) (auth.Session, bool) {
+	if sessions == nil {
+		return auth.Session{}, false
+	}
+	cookie, err := r.Cookie(auth.SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return auth.Session{}, false
+	}
+	return sessions.Get(cookie.Value)
+}
+
+// sendUnauthorized refuses a request in the standard envelope
+func sendUnauthorized(w http.ResponseWriter, challenge, details string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+	utils.NewResponse().
+		WithSuccess(false).
+		WithError("UNAUTHORIZED", "Authentication required", details).
+		Send(w, http.StatusUnauthorized)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"LibraryGo/internal/auth"
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/utils"
+	"net/http"
+)
+
+// Authorize lets a request through when the policy grants its principal the permission
+// the route is declared with. Anonymous callers are asked to authenticate; authenticated
+// ones are refused with the missing permission in the error details. Routes without a
+// declaration are refused for everyone.
+func Authorize(policy *auth.Policy, permissionOf func(r *http.Request) (string, bool)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			permission, declared := permissionOf(r)
+			if !declared {
+				sendForbidden(w, "route declares no permission")
+				return
+			}
+
+			principal, ok := auth.PrincipalFrom(r.Context())
+			if !ok {
+				principal = auth.Anonymous
+			}
+			if policy.Allows(principal, permission) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if principal.Kind == model.PrincipalAnonymous {
+				sendUnauthorized(w, `Bearer realm="library"`, "Send an API key or a bearer token")
+				return
+			}
+			sendForbidden(w, permission)
+		})
+	}
+}
+
+// sendForbidden refuses a request, naming the permission it lacks
+func sendForbidden(w http.ResponseWriter, permission string) {
+	utils.NewResponse().
+		WithSuccess(false).
+		WithError("FORBIDDEN", "Permission denied", permission).
+		Send(w, http.StatusForbidden)
+}
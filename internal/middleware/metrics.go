@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"LibraryGo/internal/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics records the latency of every request in a histogram labelled by method,
+// route template and status. Templates rather than paths keep the number of series
+// bounded however many books there are.
+func Metrics(requests *metrics.Histogram) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			requests.Observe(time.Since(started).Seconds(), r.Method, RouteTemplate(r), strconv.Itoa(recorder.status))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"LibraryGo/internal/auth"
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/ratelimit"
+	"LibraryGo/internal/utils"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit refuses requests beyond the limit of their route. Each client has a
+// bucket per scope, where scope names the route for routes with a limit of their
+// own and is shared by all routes on the default limit. Clients are told where they
+// stand in RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy
+// headers, and when to come back in Retry-After.
+func RateLimit(limiter *ratelimit.Limiter, limitOf func(r *http.Request) (scope string, limit ratelimit.Limit), trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope, limit := limitOf(r)
+			if limit.Unlimited() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision := limiter.Allow(scope+"|"+clientKey(r, trustProxy), limit)
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			header.Set("RateLimit-Reset", wholeSeconds(decision.Reset))
+			header.Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+wholeSeconds(limit.Window))
+			if !decision.Allowed {
+				refuse(w, decision)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientLimit caps the requests of each IP address across all routes. It goes before
+// authentication, so requests with bad credentials, which never reach RateLimit, are
+// throttled too. Allowed requests carry no headers; those come from RateLimit.
+func ClientLimit(limiter *ratelimit.Limiter, limit ratelimit.Limit, trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit.Unlimited() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			decision := limiter.Allow("ip:"+ClientIP(r, trustProxy), limit)
+			if !decision.Allowed {
+				refuse(w, decision)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// refuse answers a request over its limit, saying when to come back
+func refuse(w http.ResponseWriter, decision ratelimit.Decision) {
+	w.Header().Set("Retry-After", wholeSeconds(decision.RetryAfter))
+	utils.NewResponse().
+		WithSuccess(false).
+		WithError("RATE_LIMITED", "Too many requests", "Retry in "+wholeSeconds(decision.RetryAfter)+" seconds").
+		Send(w, http.StatusTooManyRequests)
+}
+
+// clientKey identifies who a request counts against: the authenticated principal,
+// which for API keys is the key, or else the client's IP address
+func clientKey(r *http.Request, trustProxy bool) string {
+	if principal, ok := auth.PrincipalFrom(r.Context()); ok && principal.Kind != model.PrincipalAnonymous {
+		return "principal:" + principal.ID
+	}
+	return "ip:" + ClientIP(r, trustProxy)
+}
+
+// ClientIP returns the address a request came from. Behind a reverse proxy it is
+// the last X-Forwarded-For entry, the one the proxy added; entries before it are
+// whatever the client claimed and are not trusted.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// wholeSeconds rounds a duration up to whole seconds, as the headers carry them
+func wholeSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"LibraryGo/internal/requestid"
+	"net/http"
+)
+
+// RequestID gives every request an ID: the client's X-Request-ID when it sent a
+// usable one, or a new one. The ID goes into the request context and the response
+// header, where utils.ResponseBuilder picks it up for the response body.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.With(r.Context(), id)))
+	})
+}
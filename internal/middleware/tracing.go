@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"LibraryGo/internal/requestid"
+	"LibraryGo/internal/tracing"
+	"net/http"
+)
+
+// Trace runs every request in a server span named after its method and route template,
+// continuing the caller's trace when it sent a valid traceparent header. The span's
+// traceparent is returned in the response, so a client can look its request up.
+func Trace(tracer *tracing.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if parent, err := tracing.ParseTraceparent(r.Header.Get(tracing.TraceparentHeader)); err == nil {
+				ctx = tracing.WithRemoteParent(ctx, parent)
+			}
+			route := RouteTemplate(r)
+			ctx, span := tracer.Start(ctx, r.Method+" "+route, tracing.KindServer,
+				tracing.String("http.request.method", r.Method),
+				tracing.String("http.route", route),
+				tracing.String("url.path", r.URL.Path),
+				tracing.String("request.id", requestid.From(ctx)),
+			)
+			defer span.End()
+			w.Header().Set(tracing.TraceparentHeader, span.SpanContext().Traceparent())
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+			span.SetAttributes(tracing.Int("http.response.status_code", recorder.status))
+			if recorder.status >= http.StatusInternalServerError {
+				span.SetError(errorStatus(recorder.status))
+			}
+		})
+	}
+}
+
+// errorStatus describes a server error response for a failed span
+type errorStatus int
+
+func (s errorStatus) Error() string {
+	return http.StatusText(int(s))
+}
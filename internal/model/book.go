@@ -6,4 +6,12 @@ type Book struct {
     Title         string `json:"title"`
     Author        string `json:"author"`
     PublishedYear int    `json:"publishedYear"`
+    ISBN          string `json:"isbn,omitempty"` // ISBN-10 or ISBN-13 without separators
+    Language      string `json:"language,omitempty"` // ISO 639 code such as "en" or "he"
+    CallNumber    string `json:"callNumber,omitempty"` // Dewey or LC, e.g. "823.914 R69h" or "QA76.73.G63"
+    SubjectIDs    []int  `json:"subjectIds,omitempty"`
+    Tags          []string `json:"tags,omitempty"` // Free-form, lower-cased
+    Rating        *RatingSummary `json:"rating,omitempty"` // Computed from approved reviews, not stored
+    Cover         *Cover `json:"cover,omitempty"` // Looked up from the cover store, not stored
+    Availability  []BranchAvailability `json:"availability,omitempty"` // Computed per branch, not stored
 }
\ No newline at end of file
@@ -0,0 +1,21 @@
+package model
+
+// BookQuery holds the filters of GET /books
+type BookQuery struct {
+    Author    string
+    StartYear string
+    EndYear   string
+    Decade    string // First year of a decade, e.g. "1990" for 1990-1999
+    Language  string
+    BranchID  int // Only books with a copy currently at this branch
+    CallRange string // Call number range such as "500-599"; books without a call number are left out
+    Subjects  []int    // Books under every one of these subjects, narrower terms included
+    Tags      []string // Books carrying every one of these tags
+    Sort      string // "" lists by ID, otherwise one of the Sort constants
+}
+
+// Orders of GET /books besides the default ID order
+const (
+    SortCallNumber = "callNumber" // Call number shelf order
+    SortRating     = "rating"     // Best average rating first
+)
@@ -0,0 +1,19 @@
+package model
+
+// Branch represents a library location
+type Branch struct {
+    ID      int    `json:"id"`
+    Code    string `json:"code"`
+    Name    string `json:"name"`
+    Address string `json:"address,omitempty"`
+}
+
+// BranchAvailability summarises the copies of a book at one branch
+type BranchAvailability struct {
+    BranchID   int    `json:"branchId"`
+    BranchName string `json:"branchName"`
+    Total      int    `json:"total"`
+    Available  int    `json:"available"`
+    OnLoan     int    `json:"onLoan"`
+    InTransit  int    `json:"inTransit"`
+}
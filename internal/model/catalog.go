@@ -0,0 +1,62 @@
+package model
+
+import (
+    "time"
+)
+
+// Outcomes of importing an e-book
+const (
+    ImportCreated   = "created"   // A new book was catalogued from the file
+    ImportProposed  = "proposed"  // The book exists; changes await staff approval
+    ImportUnchanged = "unchanged" // The book exists and already matches the file
+)
+
+// Metadata proposal statuses
+const (
+    ProposalStatusPending  = "pending"
+    ProposalStatusApproved = "approved"
+    ProposalStatusRejected = "rejected"
+)
+
+// Identifier is an identifier of a publication, such as its ISBN
+type Identifier struct {
+    Scheme string `json:"scheme,omitempty"` // e.g. ISBN, UUID, DOI
+    Value  string `json:"value"`
+}
+
+// EbookMetadata is the Dublin Core metadata of an e-book's package document
+type EbookMetadata struct {
+    Title       string       `json:"title"`
+    Creators    []string     `json:"creators,omitempty"`
+    Date        string       `json:"date,omitempty"`
+    Identifiers []Identifier `json:"identifiers,omitempty"`
+    Language    string       `json:"language,omitempty"`
+    Subjects    []string     `json:"subjects,omitempty"`
+}
+
+// FieldChange is one difference between a book and the metadata of a file
+type FieldChange struct {
+    Field    string      `json:"field"` // JSON name of the book field
+    Current  interface{} `json:"current"`
+    Proposed interface{} `json:"proposed"`
+}
+
+// MetadataProposal is a set of field updates for a book awaiting staff approval
+type MetadataProposal struct {
+    ID         int           `json:"id"`
+    BookID     int           `json:"bookId"`
+    Source     string        `json:"source,omitempty"` // File the metadata came from
+    Changes    []FieldChange `json:"changes"`
+    Status     string        `json:"status"`
+    CreatedAt  time.Time     `json:"createdAt"`
+    ResolvedAt *time.Time    `json:"resolvedAt,omitempty"`
+}
+
+// ImportResult reports what importing an e-book did
+type ImportResult struct {
+    Action   string            `json:"action"`
+    Book     Book              `json:"book"`
+    Proposal *MetadataProposal `json:"proposal,omitempty"`
+    Asset    *DigitalAsset     `json:"asset,omitempty"`
+    Metadata EbookMetadata     `json:"metadata"`
+}
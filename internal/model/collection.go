@@ -0,0 +1,35 @@
+package model
+
+import (
+    "time"
+)
+
+// Collection visibilities
+const (
+    VisibilityPrivate = "private" // Only the owner, or whoever has the share link, can see it
+    VisibilityPublic  = "public"
+)
+
+// Collection is an ordered reading list such as "Summer reading 2026"
+type Collection struct {
+    ID          int               `json:"id"`
+    Title       string            `json:"title"`
+    Description string            `json:"description,omitempty"`
+    OwnerID     int               `json:"ownerId"` // Patron who curates the list
+    Visibility  string            `json:"visibility"`
+    ShareToken  string            `json:"shareToken,omitempty"`
+    Entries     []CollectionEntry `json:"entries"`
+    CreatedAt   time.Time         `json:"createdAt"`
+    UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+// CollectionEntry is one book on a list with the curator's note about it.
+// When the book is deleted from the catalog the entry stays, marked removed,
+// with the title it had so the list still reads sensibly.
+type CollectionEntry struct {
+    BookID  int    `json:"bookId"`
+    Note    string `json:"note,omitempty"`
+    Book    *Book  `json:"book,omitempty"`
+    Removed bool   `json:"removed,omitempty"`
+    Title   string `json:"title,omitempty"` // Title of a removed book
+}
@@ -0,0 +1,25 @@
+package model
+
+// Copy statuses
+const (
+    CopyStatusAvailable = "available"
+    CopyStatusOnLoan    = "on_loan"
+    CopyStatusInTransit = "in_transit"
+    CopyStatusLost      = "lost"
+)
+
+// Copy is a physical item of a book held by a branch
+type Copy struct {
+    ID              int    `json:"id"`
+    BookID          int    `json:"bookId"`
+    Barcode         string `json:"barcode"`
+    HomeBranchID    int    `json:"homeBranchId"`    // Branch the copy belongs to
+    CurrentBranchID int    `json:"currentBranchId"` // Branch the copy is at, or is travelling to
+    Shelf           string `json:"shelf,omitempty"`  // Shelf the copy belongs on at its home branch
+    Status          string `json:"status"`
+}
+
+// Circulates reports whether the copy can be lent now or after it is returned
+func (c Copy) Circulates() bool {
+    return c.Status == CopyStatusAvailable || c.Status == CopyStatusOnLoan
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+    "time"
+)
+
+// Cover image sizes; thumbnails are generated on upload
+const (
+    CoverSizeOriginal = "original"
+    CoverSizeSmall    = "small"  // 120 pixels wide
+    CoverSizeMedium   = "medium" // 300 pixels wide
+    CoverSizeLarge    = "large"  // 600 pixels wide
+)
+
+// Cover describes the cover image of a book
+type Cover struct {
+    BookID      int               `json:"bookId"`
+    ContentType string            `json:"contentType"` // Of the original; sniffed, not taken from the upload
+    Size        int               `json:"size"`        // Bytes of the original
+    Width       int               `json:"width"`
+    Height      int               `json:"height"`
+    ETag        string            `json:"etag"`
+    URLs        map[string]string `json:"urls"` // By size, including the original
+    UpdatedAt   time.Time         `json:"updatedAt"`
+}
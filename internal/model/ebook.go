@@ -0,0 +1,47 @@
+package model
+
+import (
+    "time"
+)
+
+// Digital asset formats
+const (
+    AssetFormatEPUB = "epub"
+    AssetFormatPDF  = "pdf"
+)
+
+// Digital loan statuses
+const (
+    DigitalLoanStatusActive   = "active"
+    DigitalLoanStatusReturned = "returned" // Given back before the loan period ended
+    DigitalLoanStatusExpired  = "expired"
+)
+
+// DigitalAsset is a lendable file of a book, such as an EPUB edition
+type DigitalAsset struct {
+    ID          int       `json:"id"`
+    BookID      int       `json:"bookId"`
+    Format      string    `json:"format"`
+    FileName    string    `json:"fileName"`
+    ContentType string    `json:"contentType"`
+    Size        int       `json:"size"`      // Bytes
+    Licenses    int       `json:"licenses"`  // How many patrons may borrow it at once
+    Available   int       `json:"available"` // Licenses not on loan; computed, not stored
+    CreatedAt   time.Time `json:"createdAt"`
+}
+
+// DigitalLoan lends a digital asset to a patron. While it is active the patron
+// can fetch short-lived signed download links; when it expires the license
+// returns to the pool.
+type DigitalLoan struct {
+    ID            int        `json:"id"`
+    AssetID       int        `json:"assetId"`
+    BookID        int        `json:"bookId"`
+    PatronID      int        `json:"patronId"`
+    Status        string     `json:"status"`
+    CheckedOutAt  time.Time  `json:"checkedOutAt"`
+    DueDate       time.Time  `json:"dueDate"`
+    ClosedAt      *time.Time `json:"closedAt,omitempty"`
+    DownloadURL   string     `json:"downloadUrl,omitempty"` // Computed, not stored
+    LinkExpiresAt *time.Time `json:"linkExpiresAt,omitempty"`
+}
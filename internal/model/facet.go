@@ -0,0 +1,24 @@
+package model
+
+// Facet fields of GET /books; each is also the query parameter that filters on it
+const (
+    FacetAuthor   = "author"
+    FacetDecade   = "decade"
+    FacetLanguage = "language"
+    FacetSubject  = "subject"
+)
+
+// Facet counts the books of a result set per value of one field
+type Facet struct {
+    Field  string       `json:"field"`
+    Values []FacetValue `json:"values"`
+}
+
+// FacetValue is one entry of a facet. Value can be passed back as
+// ?{field}={value} to narrow the results to it.
+type FacetValue struct {
+    Value    string `json:"value"`
+    Label    string `json:"label,omitempty"`
+    Count    int    `json:"count"`
+    Selected bool   `json:"selected,omitempty"`
+}
@@ -0,0 +1,23 @@
+package model
+
+// Health statuses of a check and of a report
+const (
+    HealthOK      = "ok"
+    HealthFailing = "failing"
+)
+
+// HealthCheck is the outcome of one health check
+type HealthCheck struct {
+    Name       string  `json:"name"`
+    Status     string  `json:"status"`
+    Error      string  `json:"error,omitempty"`
+    DurationMs float64 `json:"durationMs"`
+}
+
+// HealthReport aggregates the checks of a liveness or readiness probe; it is ok only
+// when every check is
+type HealthReport struct {
+    Status     string        `json:"status"`
+    DurationMs float64       `json:"durationMs"`
+    Checks     []HealthCheck `json:"checks"`
+}
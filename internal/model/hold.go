@@ -0,0 +1,31 @@
+package model
+
+import (
+    "time"
+)
+
+// Hold statuses
+const (
+    HoldStatusPending   = "pending"   // Waiting for the book to come back
+    HoldStatusReady     = "ready"     // Book is set aside for pickup
+    HoldStatusFulfilled = "fulfilled" // Patron checked the book out
+    HoldStatusExpired   = "expired"   // Pickup window passed
+    HoldStatusCancelled = "cancelled"
+)
+
+// Hold represents a patron's reservation of a book
+type Hold struct {
+    ID        int        `json:"id"`
+    BookID    int        `json:"bookId"`
+    PatronID  int        `json:"patronId"`
+    Status    string     `json:"status"`
+    PlacedAt  time.Time  `json:"placedAt"`
+    ReadyAt   *time.Time `json:"readyAt,omitempty"`
+    ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+    ClosedAt  *time.Time `json:"closedAt,omitempty"`
+}
+
+// IsOpen reports whether the hold is still waiting or set aside
+func (h Hold) IsOpen() bool {
+    return h.Status == HoldStatusPending || h.Status == HoldStatusReady
+}
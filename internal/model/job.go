@@ -0,0 +1,25 @@
+package model
+
+import (
+    "time"
+)
+
+// JobState is the persisted run history of a background job
+type JobState struct {
+    LastRunAt      *time.Time `json:"lastRunAt,omitempty"`
+    LastSuccessAt  *time.Time `json:"lastSuccessAt,omitempty"`
+    LastDurationMs int64      `json:"lastDurationMs"`
+    LastError      string     `json:"lastError,omitempty"`
+    RunCount       int        `json:"runCount"`
+    FailureCount   int        `json:"failureCount"`
+}
+
+// JobStatus describes a registered background job and its current state
+type JobStatus struct {
+    Name        string     `json:"name"`
+    Description string     `json:"description,omitempty"`
+    Schedule    string     `json:"schedule"`
+    Running     bool       `json:"running"`
+    NextRunAt   *time.Time `json:"nextRunAt,omitempty"`
+    JobState
+}
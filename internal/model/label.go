@@ -0,0 +1,39 @@
+package model
+
+// Barcode image formats
+const (
+    LabelFormatPNG = "png"
+    LabelFormatSVG = "svg"
+)
+
+// LabelStock is a sheet of adhesive labels. Lengths are in points (1/72 inch).
+type LabelStock struct {
+    Name        string  `json:"name"`
+    Description string  `json:"description"`
+    PageWidth   float64 `json:"pageWidth"`
+    PageHeight  float64 `json:"pageHeight"`
+    Columns     int     `json:"columns"`
+    Rows        int     `json:"rows"`
+    LabelWidth  float64 `json:"labelWidth"`
+    LabelHeight float64 `json:"labelHeight"`
+    Top         float64 `json:"top"`    // Margin above the first row
+    Left        float64 `json:"left"`   // Margin left of the first column
+    PitchX      float64 `json:"pitchX"` // Distance between the left edges of neighbouring labels
+    PitchY      float64 `json:"pitchY"` // Distance between the top edges of neighbouring labels
+}
+
+// Label is what is printed for one copy
+type Label struct {
+    CopyID     int    `json:"copyId"`
+    Title      string `json:"title"`
+    CallNumber string `json:"callNumber,omitempty"`
+    Barcode    string `json:"barcode"`
+}
+
+// LabelSheetRequest selects the copies to print labels for
+type LabelSheetRequest struct {
+    Stock   string `json:"stock"`   // Name of a label stock; defaults to avery-5160
+    CopyIDs []int  `json:"copyIds"`
+    BookIDs []int  `json:"bookIds"` // Every copy of these books
+    Skip    int    `json:"skip"`    // Labels already used on the first sheet
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+    "time"
+)
+
+// Loan statuses
+const (
+    LoanStatusActive   = "active"
+    LoanStatusOverdue  = "overdue"
+    LoanStatusReturned = "returned"
+)
+
+// Loan represents a book checked out by a patron
+type Loan struct {
+    ID           int        `json:"id"`
+    BookID       int        `json:"bookId"`
+    PatronID     int        `json:"patronId"`
+    CopyID       int        `json:"copyId,omitempty"`   // Set when the book has registered copies
+    BranchID     int        `json:"branchId,omitempty"` // Branch the copy was lent from
+    Status       string     `json:"status"`
+    CheckedOutAt time.Time  `json:"checkedOutAt"`
+    DueDate      time.Time  `json:"dueDate"`
+    ReturnedAt   *time.Time `json:"returnedAt,omitempty"`
+}
+
+// IsOpen reports whether the book has not been returned yet
+func (l Loan) IsOpen() bool {
+    return l.Status != LoanStatusReturned
+}
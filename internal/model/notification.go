@@ -0,0 +1,35 @@
+package model
+
+import (
+    "time"
+)
+
+// Notification kinds
+const (
+    NotificationDueSoon   = "due_soon"
+    NotificationOverdue   = "overdue"
+    NotificationHoldReady = "hold_ready"
+)
+
+// Notification delivery statuses
+const (
+    DeliveryStatusSent          = "sent"
+    DeliveryStatusFailed        = "failed"        // Retried on the next run
+    DeliveryStatusUndeliverable = "undeliverable" // Permanent failure, not retried
+)
+
+// Notification is an entry in the delivery log
+type Notification struct {
+    ID        int        `json:"id"`
+    PatronID  int        `json:"patronId"`
+    Kind      string     `json:"kind"`
+    Reference string     `json:"reference"` // What the message is about, e.g. "loan:12"
+    Channel   string     `json:"channel"`
+    Subject   string     `json:"subject"`
+    Body      string     `json:"body"`
+    Status    string     `json:"status"`
+    Attempts  int        `json:"attempts"`
+    Error     string     `json:"error,omitempty"`
+    CreatedAt time.Time  `json:"createdAt"`
+    SentAt    *time.Time `json:"sentAt,omitempty"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// Patron represents a library member
+type Patron struct {
+    ID               int    `json:"id"`
+    Name             string `json:"name"`
+    Email            string `json:"email,omitempty"`
+    WebhookURL       string `json:"webhookUrl,omitempty"`
+    Locale           string `json:"locale,omitempty"`           // Language of notifications, e.g. "en" or "he"
+    PreferredChannel string `json:"preferredChannel,omitempty"` // Notification channel; empty uses the server default
+}
@@ -0,0 +1,33 @@
+package model
+
+// Ways a principal can be authenticated
+const (
+    PrincipalAnonymous = "anonymous" // No credentials were sent
+    PrincipalAPIKey    = "api_key"
+    PrincipalToken     = "token" // JWT bearer token
+    PrincipalSession   = "session" // Browser session started by an OpenID Connect login
+    PrincipalLocal     = "local" // Authentication is turned off and every request is trusted
+)
+
+// Principal is the caller a request is made on behalf of
+type Principal struct {
+    ID          string   `json:"id"`
+    Name        string   `json:"name,omitempty"`
+    Kind        string   `json:"kind"`
+    Roles       []string `json:"roles,omitempty"`
+    PatronID    int      `json:"patronId,omitempty"` // Patron the caller is, for clients acting for library members
+    Permissions []string `json:"permissions,omitempty"` // Granted by the roles; filled in by GET /auth/me
+}
+
+// Actor is who reads or changes a patron's records: the patron, or staff acting for
+// any patron
+type Actor struct {
+    ID       string // Principal ID, recorded when staff act for someone else
+    PatronID int    // Zero when the caller is not a patron
+    Staff    bool
+}
+
+// Owns reports whether the actor may act on a record of the patron
+func (a Actor) Owns(patronID int) bool {
+    return a.Staff || (a.PatronID != 0 && a.PatronID == patronID)
+}
@@ -0,0 +1,16 @@
+package model
+
+// Why a book was recommended
+const (
+    ReasonBorrowedTogether = "borrowed_together" // Patrons who borrowed one also borrowed the other
+    ReasonSameAuthor       = "same_author"
+    ReasonSameSubject      = "same_subject"
+    ReasonPopular          = "popular" // Most borrowed overall, for patrons without history
+)
+
+// Recommendation is a suggested book with how strongly and why it was picked
+type Recommendation struct {
+    Book   Book    `json:"book"`
+    Score  float64 `json:"score"`
+    Reason string  `json:"reason"`
+}
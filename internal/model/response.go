@@ -38,4 +38,5 @@ type MetaData struct {
     NextPage    *int      `json:"nextPage,omitempty"`
     PrevPage    *int      `json:"prevPage,omitempty"`
     ProcessedAt time.Time `json:"processedAt,omitempty"`
+    Facets      []Facet   `json:"facets,omitempty"`
 }
@@ -0,0 +1,31 @@
+package model
+
+import (
+    "time"
+)
+
+// Review moderation statuses
+const (
+    ReviewStatusPending  = "pending" // Awaiting moderation, not shown publicly
+    ReviewStatusApproved = "approved"
+    ReviewStatusRejected = "rejected"
+)
+
+// Review is a patron's rating and optional text about a book
+type Review struct {
+    ID          int        `json:"id"`
+    BookID      int        `json:"bookId"`
+    PatronID    int        `json:"patronId"`
+    EnteredBy   string     `json:"enteredBy,omitempty"` // Staff member who entered the review for the patron
+    Rating      int        `json:"rating"` // 1 to 5
+    Text        string     `json:"text,omitempty"`
+    Status      string     `json:"status"`
+    CreatedAt   time.Time  `json:"createdAt"`
+    ModeratedAt *time.Time `json:"moderatedAt,omitempty"`
+}
+
+// RatingSummary aggregates the approved reviews of a book
+type RatingSummary struct {
+    Average float64 `json:"average"`
+    Count   int     `json:"count"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+    "time"
+)
+
+// Stocktake session statuses
+const (
+    StocktakeStatusOpen   = "open"
+    StocktakeStatusClosed = "closed"
+)
+
+// Reasons an item shows up in a stocktake report
+const (
+    StocktakeReasonNotScanned  = "not_scanned"     // Expected on the shelf but not found
+    StocktakeReasonUnknown     = "unknown_barcode" // Barcode is not in the catalog
+    StocktakeReasonOtherBranch = "other_branch"    // Copy belongs at another branch
+    StocktakeReasonNotOnShelf  = "not_on_shelf"    // Copy is recorded as on loan, in transit or lost
+    StocktakeReasonWrongShelf  = "wrong_shelf"     // Copy is at the right branch on the wrong shelf
+)
+
+// StocktakeSession is an audit of a branch's shelves against the catalog
+type StocktakeSession struct {
+    ID        int        `json:"id"`
+    BranchID  int        `json:"branchId"`
+    Shelves   []string   `json:"shelves,omitempty"` // Shelves in scope; empty means the whole branch
+    Status    string     `json:"status"`
+    ScanCount int        `json:"scanCount"`
+    StartedAt time.Time  `json:"startedAt"`
+    ClosedAt  *time.Time `json:"closedAt,omitempty"`
+}
+
+// StocktakeScan is one barcode read on a shelf
+type StocktakeScan struct {
+    Shelf     string    `json:"shelf"`
+    Barcode   string    `json:"barcode"`
+    ScannedAt time.Time `json:"scannedAt"`
+}
+
+// StocktakeItem is a discrepancy found by a stocktake
+type StocktakeItem struct {
+    Barcode          string `json:"barcode"`
+    CopyID           int    `json:"copyId,omitempty"`
+    BookID           int    `json:"bookId,omitempty"`
+    Title            string `json:"title,omitempty"`
+    Reason           string `json:"reason"`
+    ExpectedBranchID int    `json:"expectedBranchId,omitempty"`
+    ExpectedShelf    string `json:"expectedShelf,omitempty"`
+    ScannedShelf     string `json:"scannedShelf,omitempty"`
+    CopyStatus       string `json:"copyStatus,omitempty"`
+}
+
+// StocktakeReport compares the scans of a session with the catalog
+type StocktakeReport struct {
+    SessionID  int             `json:"sessionId"`
+    BranchID   int             `json:"branchId"`
+    ClosedAt   *time.Time      `json:"closedAt,omitempty"` // Set on the final report, frozen when the session closed
+    Expected   int             `json:"expected"`
+    Scanned    int             `json:"scanned"`
+    Found      int             `json:"found"`
+    Missing    []StocktakeItem `json:"missing"`
+    Unexpected []StocktakeItem `json:"unexpected"`
+    Misplaced  []StocktakeItem `json:"misplaced"`
+}
@@ -0,0 +1,16 @@
+package model
+
+// Subject kinds
+const (
+    SubjectKindTopic = "topic"
+    SubjectKindGenre = "genre"
+)
+
+// Subject is a term of the subject/genre taxonomy
+type Subject struct {
+    ID          int    `json:"id"`
+    Name        string `json:"name"`
+    Kind        string `json:"kind"`
+    BroaderID   int    `json:"broaderId,omitempty"`   // Parent term; zero for a top-level term
+    NarrowerIDs []int  `json:"narrowerIds,omitempty"` // Computed from the other terms, not stored
+}
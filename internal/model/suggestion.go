@@ -0,0 +1,14 @@
+package model
+
+// Suggestion fields of GET /books/suggest
+const (
+    SuggestFieldTitle  = "title"
+    SuggestFieldAuthor = "author"
+)
+
+// Suggestion is a typeahead completion for the search box
+type Suggestion struct {
+    Text  string `json:"text"`
+    Field string `json:"field"`
+    Count int    `json:"count"` // Books with this title or by this author
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+    "time"
+)
+
+// Transfer statuses
+const (
+    TransferStatusRequested = "requested"
+    TransferStatusInTransit = "in_transit"
+    TransferStatusReceived  = "received"
+    TransferStatusCancelled = "cancelled"
+)
+
+// Transfer moves a copy from one branch to another
+type Transfer struct {
+    ID           int        `json:"id"`
+    CopyID       int        `json:"copyId"`
+    FromBranchID int        `json:"fromBranchId"`
+    ToBranchID   int        `json:"toBranchId"`
+    Status       string     `json:"status"`
+    RequestedAt  time.Time  `json:"requestedAt"`
+    ShippedAt    *time.Time `json:"shippedAt,omitempty"`
+    ReceivedAt   *time.Time `json:"receivedAt,omitempty"`
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is a rendered notification addressed to one patron
+type Message struct {
+	PatronID   int    `json:"patronId"`
+	Name       string `json:"name"`
+	Email      string `json:"email,omitempty"`
+	WebhookURL string `json:"-"`
+	Kind       string `json:"kind"`
+	Reference  string `json:"reference"`
+	Subject    string `json:"subject"`
+	Body       string `json:"body"`
+}
+
+// Channel delivers messages over one transport
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that delivery is not retried
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// OutboxChannel appends messages as JSON lines to a file for another process to pick up
+type OutboxChannel struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewOutboxChannel creates a file outbox channel
+func NewOutboxChannel(path string) *OutboxChannel {
+	return &OutboxChannel{path: path}
+}
+
+// Name identifies the channel
+func (c *OutboxChannel) Name() string { return "outbox" }
+
+// Send appends msg to the outbox file
+func (c *OutboxChannel) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(struct {
+		Message
+		QueuedAt time.Time `json:"queuedAt"`
+	}{msg, time.Now()})
+	if err != nil {
+		return Permanent(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy controls how often a failed delivery is attempted again
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy tries three times, waiting 1s and then 2s between attempts
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// Deliver sends msg through channel, backing off exponentially between failed attempts.
+// It returns the number of attempts made and the last error.
+func (p RetryPolicy) Deliver(ctx context.Context, channel Channel, msg Message) (int, error) {
+	attempts := p.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := p.InitialBackoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = channel.Send(ctx, msg); err == nil || IsPermanent(err) {
+			return attempt, err
+		}
+		if attempt == attempts {
+			return attempt, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, ctx.Err()
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+	return attempts, err
+}
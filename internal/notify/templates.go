@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"LibraryGo/internal/model"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultLocale is used when a patron has no locale or it has no translation
+const DefaultLocale = "en"
+
+// TemplateData is the input of every message template
+type TemplateData struct {
+	PatronName string
+	BookTitle  string
+	BookAuthor string
+	DueDate    time.Time
+	ExpiresAt  time.Time
+}
+
+type messageTemplate struct {
+	subject string
+	body    string
+}
+
+// builtinTemplates holds the message texts per locale and notification kind
+var builtinTemplates = map[string]map[string]messageTemplate{
+	"en": {
+		model.NotificationDueSoon: {
+			subject: `Reminder: "{{.BookTitle}}" is due on {{date .DueDate}}`,
+			body: `Hello {{.PatronName}},
+
+"{{.BookTitle}}" by {{.BookAuthor}} is due back on {{date .DueDate}}.
+Please return it on time so others can enjoy it too.`,
+		},
+		model.NotificationOverdue: {
+			subject: `Overdue: "{{.BookTitle}}"`,
+			body: `Hello {{.PatronName}},
+
+"{{.BookTitle}}" by {{.BookAuthor}} was due on {{date .DueDate}} and is now overdue.
+Please return it as soon as possible.`,
+		},
+		model.NotificationHoldReady: {
+			subject: `Your hold on "{{.BookTitle}}" is ready`,
+			body: `Hello {{.PatronName}},
+
+"{{.BookTitle}}" by {{.BookAuthor}} is waiting for you at the desk.
+Please pick it up by {{date .ExpiresAt}}.`,
+		},
+	},
+	"he": {
+		model.NotificationDueSoon: {
+			subject: `תזכורת: יש להחזיר את "{{.BookTitle}}" עד {{date .DueDate}}`,
+			body: `שלום {{.PatronName}},
+
+יש להחזיר את הספר "{{.BookTitle}}" מאת {{.BookAuthor}} עד {{date .DueDate}}.
+נא להחזיר בזמן כדי שגם אחרים יוכלו ליהנות ממנו.`,
+		},
+		model.NotificationOverdue: {
+			subject: `איחור בהחזרה: "{{.BookTitle}}"`,
+			body: `שלום {{.PatronName}},
+
+מועד ההחזרה של הספר "{{.BookTitle}}" מאת {{.BookAuthor}} היה {{date .DueDate}}.
+נא להחזיר אותו בהקדם.`,
+		},
+		model.NotificationHoldReady: {
+			subject: `ההזמנה שלך ל"{{.BookTitle}}" מוכנה`,
+			body: `שלום {{.PatronName}},
+
+הספר "{{.BookTitle}}" מאת {{.BookAuthor}} ממתין לך בדלפק.
+נא לאסוף אותו עד {{date .ExpiresAt}}.`,
+		},
+	},
+}
+
+// Templates renders localized notification messages
+type Templates struct {
+	parsed map[string]map[string][2]*template.Template
+}
+
+// NewTemplates parses the built-in message templates
+func NewTemplates() (*Templates, error) {
+	funcs := template.FuncMap{
+		"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	}
+
+	parsed := make(map[string]map[string][2]*template.Template)
+	for locale, kinds := range builtinTemplates {
+		parsed[locale] = make(map[string][2]*template.Template)
+		for kind, tmpl := range kinds {
+			subject, err := template.New(kind + ".subject").Funcs(funcs).Parse(tmpl.subject)
+			if err != nil {
+				return nil, fmt.Errorf("template %s/%s subject: %w", locale, kind, err)
+			}
+			body, err := template.New(kind + ".body").Funcs(funcs).Parse(tmpl.body)
+			if err != nil {
+				return nil, fmt.Errorf("template %s/%s body: %w", locale, kind, err)
+			}
+			parsed[locale][kind] = [2]*template.Template{subject, body}
+		}
+	}
+	return &Templates{parsed: parsed}, nil
+}
+
+// Render produces the subject and body of a message, falling back to DefaultLocale
+func (t *Templates) Render(kind, locale string, data TemplateData) (string, string, error) {
+	tmpl, ok := t.parsed[normalizeLocale(locale)][kind]
+	if !ok {
+		tmpl, ok = t.parsed[DefaultLocale][kind]
+	}
+	if !ok {
+		return "", "", fmt.Errorf("no template for notification kind %q", kind)
+	}
+
+	var subject, body strings.Builder
+	if err := tmpl[0].Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl[1].Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return subject.String(), body.String(), nil
+}
+
+// normalizeLocale reduces tags such as "he-IL" or "en_US" to their language
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
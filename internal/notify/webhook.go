@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"LibraryGo/internal/tracing"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookChannel posts messages as JSON to an HTTP endpoint
+type WebhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookChannel creates a webhook channel; a patron's own webhook URL takes precedence over url
+func NewWebhookChannel(url string, timeout time.Duration) *WebhookChannel {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &WebhookChannel{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Name identifies the channel
+func (c *WebhookChannel) Name() string { return "webhook" }
+
+// Send posts msg; 4xx responses other than 429 are treated as permanent failures
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	url := msg.WebhookURL
+	if url == "" {
+		url = c.url
+	}
+	if url == "" {
+		return Permanent(errors.New("no webhook URL configured"))
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("webhook responded with %s", resp.Status)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
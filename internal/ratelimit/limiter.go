@@ -0,0 +1,170 @@
+// Package ratelimit limits how often each client may call the API, with a token
+// bucket per client and route
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidLimit is returned for limits not written as requests per window
+var ErrInvalidLimit = errors.New(`limit must look like "60/1m" or "off"`)
+
+// sweepEvery is how many calls go by between removing idle buckets
+const sweepEvery = 1024
+
+// Limit allows Requests per Window, in bursts of up to Requests. The zero Limit
+// allows everything.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Unlimited reports whether the limit allows everything
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Window <= 0
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// ParseLimit reads a limit such as "60/1m" or "5/s"; "off" turns limiting off
+func ParseLimit(spec string) (Limit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "off" {
+		return Limit{}, nil
+	}
+	count, window, found := strings.Cut(spec, "/")
+	if !found {
+		return Limit{}, ErrInvalidLimit
+	}
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests < 1 {
+		return Limit{}, ErrInvalidLimit
+	}
+	if window != "" && (window[0] < '0' || window[0] > '9') {
+		window = "1" + window // "s" means "1s"
+	}
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+	return Limit{Requests: requests, Window: duration}, nil
+}
+
+// ParseRoutes reads per-route limits separated by semicolons, each a route such as
+// "GET /books" and a limit joined by "=", e.g. "GET /books=60/1m;POST /loans=30/1m"
+func ParseRoutes(spec string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, entry := range strings.Split(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("%q: expected ROUTE=LIMIT", entry)
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", entry, err)
+		}
+		limits[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	return limits, nil
+}
+
+// Decision is the outcome of a request against a limit
+type Decision struct {
+	Allowed    bool
+	Limit      int           // Requests allowed per window
+	Remaining  int           // Requests that could be made right now
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next request is allowed; zero when allowed
+}
+
+// bucket holds the tokens of one client on one route as of last
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// Limiter keeps a token bucket for each key it is asked about. Buckets refill
+// continuously, so a client that spent its burst gets requests back one by one
+// rather than all at once when a window ends.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+// NewLimiter creates a limiter with no buckets
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// SetClock replaces the clock, for tests
+func (l *Limiter) SetClock(now func() time.Time) {
+	l.now = now
+}
+
+// Allow takes a token from the bucket of key, creating it full on first use
+func (l *Limiter) Allow(key string, limit Limit) Decision {
+	if limit.Unlimited() {
+		return Decision{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	capacity := float64(limit.Requests)
+	perSecond := capacity / limit.Window.Seconds()
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: capacity, last: now, limit: limit}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+
+	decision := Decision{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - b.tokens) / perSecond)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = seconds((capacity - b.tokens) / perSecond)
+	return decision
+}
+
+// sweep drops buckets that have refilled completely, which behave the same as new ones
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		full := float64(b.limit.Requests)
+		if b.tokens+now.Sub(b.last).Seconds()*full/b.limit.Window.Seconds() >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrAssetNotFound is returned when no digital asset exists with the given ID
+var ErrAssetNotFound = errors.New("digital asset not found")
+
+// AssetRepository manages digital asset metadata; the files live in blob storage
+type AssetRepository struct {
+	assets map[int]model.DigitalAsset
+	nextID int
+	mu     sync.Mutex
+}
+
+// NewAssetRepository initializes an asset repository
+func NewAssetRepository() *AssetRepository {
+	return &AssetRepository{
+		assets: make(map[int]model.DigitalAsset),
+		nextID: 1,
+	}
+}
+
+// AddAsset saves a new asset
+func (repo *AssetRepository) AddAsset(asset model.DigitalAsset) model.DigitalAsset {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	asset.ID = repo.nextID
+	repo.assets[repo.nextID] = asset
+	repo.nextID++
+
+	return asset
+}
+
+// GetAssetByID retrieves an asset by its ID
+func (repo *AssetRepository) GetAssetByID(id int) (model.DigitalAsset, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	asset, exists := repo.assets[id]
+	if !exists {
+		return model.DigitalAsset{}, ErrAssetNotFound
+	}
+	return asset, nil
+}
+
+// UpdateAsset replaces a stored asset
+func (repo *AssetRepository) UpdateAsset(asset model.DigitalAsset) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.assets[asset.ID]; !exists {
+		return ErrAssetNotFound
+	}
+	repo.assets[asset.ID] = asset
+	return nil
+}
+
+// DeleteAssetByID removes an asset
+func (repo *AssetRepository) DeleteAssetByID(id int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.assets[id]; !exists {
+		return ErrAssetNotFound
+	}
+	delete(repo.assets, id)
+	return nil
+}
+
+// GetAssets retrieves the assets of a book ordered by ID; zero matches every book
+func (repo *AssetRepository) GetAssets(bookID int) []model.DigitalAsset {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	assets := []model.DigitalAsset{}
+	for _, asset := range repo.assets {
+		if bookID == 0 || asset.BookID == bookID {
+			assets = append(assets, asset)
+		}
+	}
+
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].ID < assets[j].ID
+	})
+	return assets
+}
@@ -2,40 +2,69 @@ package repository
 
 import (
 	"LibraryGo/internal/model"
+	"context"
 	"errors"
+	"log/slog"
+	"sort"
 	"sync"
 	"strconv"
 )
 
 // BookRepository manages book storage
 type BookRepository struct {
-	books  map[int]model.Book
-	nextID int
-	mu     sync.Mutex
+	books   map[int]model.Book
+	nextID  int
+	titles  *suggestTrie // Typeahead indexes kept in step with books
+	authors *suggestTrie
+	logger  *slog.Logger
+	observe Observer
+	mu      sync.Mutex
 }
 
 // NewBookRepository initializes a book repository
-func NewBookRepository() *BookRepository {
+func NewBookRepository(logger *slog.Logger) *BookRepository {
 	return &BookRepository{
-		books:  make(map[int]model.Book),
-		nextID: 1,
+		books:   make(map[int]model.Book),
+		nextID:  1,
+		titles:  newSuggestTrie(),
+		authors: newSuggestTrie(),
+		logger:  logger,
 	}
 }
 
+// SetObserver reports the duration of every operation to observe
+func (repo *BookRepository) SetObserver(observe Observer) {
+	repo.observe = observe
+}
+
+// Count returns the number of books
+func (repo *BookRepository) Count() int {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return len(repo.books)
+}
+
 // AddBook saves a new book
-func (repo *BookRepository) AddBook(book model.Book) model.Book {
+func (repo *BookRepository) AddBook(ctx context.Context, book model.Book) model.Book {
+	defer timer(repo.observe, "books", "add")()
+	defer trace(ctx, "books", "add")()
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
 	book.ID = repo.nextID
 	repo.books[repo.nextID] = book
 	repo.nextID++
+	repo.titles.add(book.Title)
+	repo.authors.add(book.Author)
+	repo.logger.DebugContext(ctx, "book stored", "book_id", book.ID, "books", len(repo.books))
 
 	return book
 }
 
 // GetAllBooks retrieves all books
-func (repo *BookRepository) GetAllBooks() []model.Book {
+func (repo *BookRepository) GetAllBooks(ctx context.Context) []model.Book {
+	defer timer(repo.observe, "books", "list")()
+	defer trace(ctx, "books", "list")()
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
@@ -47,7 +76,9 @@ func (repo *BookRepository) GetAllBooks() []model.Book {
 }
 
 // GetBookByID retrieves a book by its ID
-func (repo *BookRepository) GetBookByID(id int) (model.Book, error) {
+func (repo *BookRepository) GetBookByID(ctx context.Context, id int) (model.Book, error) {
+	defer timer(repo.observe, "books", "get")()
+	defer trace(ctx, "books", "get")()
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
@@ -59,21 +90,77 @@ func (repo *BookRepository) GetBookByID(id int) (model.Book, error) {
 	return book, nil
 }
 
+// UpdateBook replaces a stored book
+func (repo *BookRepository) UpdateBook(ctx context.Context, book model.Book) error {
+	defer timer(repo.observe, "books", "update")()
+	defer trace(ctx, "books", "update")()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	existing, exists := repo.books[book.ID]
+	if !exists {
+		return errors.New("book not found")
+	}
+
+	repo.books[book.ID] = book
+	if existing.Title != book.Title {
+		repo.titles.remove(existing.Title)
+		repo.titles.add(book.Title)
+	}
+	if existing.Author != book.Author {
+		repo.authors.remove(existing.Author)
+		repo.authors.add(book.Author)
+	}
+	return nil
+}
+
 // DeleteBookByID removes a book
-func (repo *BookRepository) DeleteBookByID(id int) error {
+func (repo *BookRepository) DeleteBookByID(ctx context.Context, id int) error {
+	defer timer(repo.observe, "books", "delete")()
+	defer trace(ctx, "books", "delete")()
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	if _, exists := repo.books[id]; !exists {
+	book, exists := repo.books[id]
+	if !exists {
 		return errors.New("book not found")
 	}
 
 	delete(repo.books, id)
+	repo.titles.remove(book.Title)
+	repo.authors.remove(book.Author)
+	repo.logger.DebugContext(ctx, "book removed", "book_id", id, "books", len(repo.books))
 	return nil
 }
 
+// Suggest returns titles and/or authors with a word starting with the prefix,
+// most common first; an empty field searches both
+func (repo *BookRepository) Suggest(field, prefix string, limit int) []model.Suggestion {
+	defer timer(repo.observe, "books", "suggest")()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	results := []model.Suggestion{}
+	if field == "" || field == model.SuggestFieldTitle {
+		results = append(results, suggestions(model.SuggestFieldTitle, repo.titles.search(prefix, limit))...)
+	}
+	if field == "" || field == model.SuggestFieldAuthor {
+		results = append(results, suggestions(model.SuggestFieldAuthor, repo.authors.search(prefix, limit))...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Count > results[j].Count
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
 // GetBooks retrieves books by author and/or published year range
-func (repo *BookRepository) GetBooks(author, startYear, endYear string) ([]model.Book, error) {
+func (repo *BookRepository) GetBooks(ctx context.Context, author, startYear, endYear string) ([]model.Book, error) {
+    defer timer(repo.observe, "books", "search")()
+    defer trace(ctx, "books", "search")()
     repo.mu.Lock()
     defer repo.mu.Unlock()
 
@@ -111,6 +198,9 @@ func (repo *BookRepository) GetBooks(author, startYear, endYear string) ([]model
         filteredBooks = append(filteredBooks, book)
     }
 
+    repo.logger.DebugContext(ctx, "books filtered", "author", author, "startYear", startYear, "endYear", endYear,
+        "matched", len(filteredBooks), "of", len(repo.books))
+
     // אם לא נמצאו ספרים, נחזור עם מערך ריק
     if len(filteredBooks) == 0 {
         return []model.Book{}, nil
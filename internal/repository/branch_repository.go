@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrBranchNotFound is returned when no branch exists with the given ID
+	ErrBranchNotFound = errors.New("branch not found")
+	// ErrDuplicateBranchCode is returned when another branch already has the code
+	ErrDuplicateBranchCode = errors.New("branch code is already in use")
+)
+
+// BranchRepository manages branch storage
+type BranchRepository struct {
+	branches map[int]model.Branch
+	nextID   int
+	mu       sync.Mutex
+}
+
+// NewBranchRepository initializes a branch repository
+func NewBranchRepository() *BranchRepository {
+	return &BranchRepository{
+		branches: make(map[int]model.Branch),
+		nextID:   1,
+	}
+}
+
+// AddBranch saves a new branch; codes must be unique, ignoring case
+func (repo *BranchRepository) AddBranch(branch model.Branch) (model.Branch, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, existing := range repo.branches {
+		if strings.EqualFold(existing.Code, branch.Code) {
+			return model.Branch{}, ErrDuplicateBranchCode
+		}
+	}
+
+	branch.ID = repo.nextID
+	repo.branches[repo.nextID] = branch
+	repo.nextID++
+
+	return branch, nil
+}
+
+// GetBranchByID retrieves a branch by its ID
+func (repo *BranchRepository) GetBranchByID(id int) (model.Branch, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	branch, exists := repo.branches[id]
+	if !exists {
+		return model.Branch{}, ErrBranchNotFound
+	}
+	return branch, nil
+}
+
+// GetAllBranches retrieves all branches ordered by ID
+func (repo *BranchRepository) GetAllBranches() []model.Branch {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	branches := make([]model.Branch, 0, len(repo.branches))
+	for _, branch := range repo.branches {
+		branches = append(branches, branch)
+	}
+
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].ID < branches[j].ID
+	})
+	return branches
+}
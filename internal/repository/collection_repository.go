@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrCollectionNotFound is returned when no collection exists with the given ID or share token
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// CollectionRepository manages reading list storage
+type CollectionRepository struct {
+	collections map[int]model.Collection
+	nextID      int
+	mu          sync.Mutex
+}
+
+// NewCollectionRepository initializes a collection repository
+func NewCollectionRepository() *CollectionRepository {
+	return &CollectionRepository{
+		collections: make(map[int]model.Collection),
+		nextID:      1,
+	}
+}
+
+// AddCollection saves a new collection
+func (repo *CollectionRepository) AddCollection(collection model.Collection) model.Collection {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	collection.ID = repo.nextID
+	repo.collections[repo.nextID] = copyCollection(collection)
+	repo.nextID++
+
+	return collection
+}
+
+// GetCollectionByID retrieves a collection by its ID
+func (repo *CollectionRepository) GetCollectionByID(id int) (model.Collection, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	collection, exists := repo.collections[id]
+	if !exists {
+		return model.Collection{}, ErrCollectionNotFound
+	}
+	return copyCollection(collection), nil
+}
+
+// GetCollectionByShareToken retrieves the collection a share link points to
+func (repo *CollectionRepository) GetCollectionByShareToken(token string) (model.Collection, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, collection := range repo.collections {
+		if token != "" && collection.ShareToken == token {
+			return copyCollection(collection), nil
+		}
+	}
+	return model.Collection{}, ErrCollectionNotFound
+}
+
+// UpdateCollection replaces a stored collection
+func (repo *CollectionRepository) UpdateCollection(collection model.Collection) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.collections[collection.ID]; !exists {
+		return ErrCollectionNotFound
+	}
+	repo.collections[collection.ID] = copyCollection(collection)
+	return nil
+}
+
+// DeleteCollectionByID removes a collection
+func (repo *CollectionRepository) DeleteCollectionByID(id int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.collections[id]; !exists {
+		return ErrCollectionNotFound
+	}
+	delete(repo.collections, id)
+	return nil
+}
+
+// GetCollections retrieves collections ordered by ID; zero or empty arguments match everything
+func (repo *CollectionRepository) GetCollections(ownerID int, visibility string) []model.Collection {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	collections := []model.Collection{}
+	for _, collection := range repo.collections {
+		if ownerID != 0 && collection.OwnerID != ownerID {
+			continue
+		}
+		if visibility != "" && collection.Visibility != visibility {
+			continue
+		}
+		collections = append(collections, copyCollection(collection))
+	}
+
+	sort.Slice(collections, func(i, j int) bool {
+		return collections[i].ID < collections[j].ID
+	})
+	return collections
+}
+
+// MarkBookRemoved flags the entries of a deleted book on every list, keeping its title
+func (repo *CollectionRepository) MarkBookRemoved(bookID int, title string) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for id, collection := range repo.collections {
+		for i, entry := range collection.Entries {
+			if entry.BookID == bookID {
+				collection.Entries[i].Removed = true
+				collection.Entries[i].Title = title
+			}
+		}
+		repo.collections[id] = collection
+	}
+}
+
+// copyCollection detaches the entries so callers cannot change stored lists in place
+func copyCollection(collection model.Collection) model.Collection {
+	collection.Entries = append([]model.CollectionEntry{}, collection.Entries...)
+	return collection
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrCopyNotFound is returned when no copy exists with the given ID or barcode
+	ErrCopyNotFound = errors.New("copy not found")
+	// ErrDuplicateBarcode is returned when a barcode is already assigned to another copy
+	ErrDuplicateBarcode = errors.New("barcode is already in use")
+	// ErrCopyStatusChanged is returned when a copy is no longer in the status a change expected
+	ErrCopyStatusChanged = errors.New("copy status has changed")
+)
+
+// CopyRepository manages storage of physical copies
+type CopyRepository struct {
+	copies    map[int]model.Copy
+	byBarcode map[string]int
+	nextID    int
+	mu        sync.Mutex
+}
+
+// NewCopyRepository initializes a copy repository
+func NewCopyRepository() *CopyRepository {
+	return &CopyRepository{
+		copies:    make(map[int]model.Copy),
+		byBarcode: make(map[string]int),
+		nextID:    1,
+	}
+}
+
+// AddCopy saves a new copy; barcodes must be unique
+func (repo *CopyRepository) AddCopy(item model.Copy) (model.Copy, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.byBarcode[item.Barcode]; exists {
+		return model.Copy{}, ErrDuplicateBarcode
+	}
+
+	item.ID = repo.nextID
+	repo.copies[repo.nextID] = item
+	repo.byBarcode[item.Barcode] = item.ID
+	repo.nextID++
+
+	return item, nil
+}
+
+// GetCopyByID retrieves a copy by its ID
+func (repo *CopyRepository) GetCopyByID(id int) (model.Copy, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	item, exists := repo.copies[id]
+	if !exists {
+		return model.Copy{}, ErrCopyNotFound
+	}
+	return item, nil
+}
+
+// GetCopyByBarcode retrieves a copy by its barcode
+func (repo *CopyRepository) GetCopyByBarcode(barcode string) (model.Copy, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	id, exists := repo.byBarcode[barcode]
+	if !exists {
+		return model.Copy{}, ErrCopyNotFound
+	}
+	return repo.copies[id], nil
+}
+
+// UpdateCopyIf changes a stored copy with fn if it is still in the expected status, or in
+// any status when expectedStatus is empty. The check and the change happen under one lock,
+// so services changing the same copy cannot overwrite each other; an error from fn leaves
+// the copy unchanged. The barcode cannot change.
+func (repo *CopyRepository) UpdateCopyIf(id int, expectedStatus string, fn func(*model.Copy) error) (model.Copy, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	existing, exists := repo.copies[id]
+	if !exists {
+		return model.Copy{}, ErrCopyNotFound
+	}
+	if expectedStatus != "" && existing.Status != expectedStatus {
+		return model.Copy{}, ErrCopyStatusChanged
+	}
+	item := existing
+	if err := fn(&item); err != nil {
+		return model.Copy{}, err
+	}
+	item.ID = existing.ID
+	item.Barcode = existing.Barcode
+	repo.copies[id] = item
+	return item, nil
+}
+
+// GetCopies retrieves copies ordered by ID; zero or empty arguments match everything.
+// branchID matches the branch a copy is currently at.
+func (repo *CopyRepository) GetCopies(bookID, branchID int, status string) []model.Copy {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	copies := []model.Copy{}
+	for _, item := range repo.copies {
+		if bookID != 0 && item.BookID != bookID {
+			continue
+		}
+		if branchID != 0 && item.CurrentBranchID != branchID {
+			continue
+		}
+		if status != "" && item.Status != status {
+			continue
+		}
+		copies = append(copies, item)
+	}
+
+	sort.Slice(copies, func(i, j int) bool {
+		return copies[i].ID < copies[j].ID
+	})
+	return copies
+}
+
+// DeleteCopiesOfBook removes every copy of a book
+func (repo *CopyRepository) DeleteCopiesOfBook(bookID int) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for id, item := range repo.copies {
+		if item.BookID == bookID {
+			delete(repo.byBarcode, item.Barcode)
+			delete(repo.copies, id)
+		}
+	}
+}
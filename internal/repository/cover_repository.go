@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sync"
+)
+
+// ErrCoverNotFound is returned when a book has no cover image
+var ErrCoverNotFound = errors.New("cover not found")
+
+// CoverRepository manages cover metadata by book ID; the images live in blob storage
+type CoverRepository struct {
+	covers map[int]model.Cover
+	mu     sync.Mutex
+}
+
+// NewCoverRepository initializes a cover repository
+func NewCoverRepository() *CoverRepository {
+	return &CoverRepository{covers: make(map[int]model.Cover)}
+}
+
+// SaveCover adds or replaces the cover of a book
+func (repo *CoverRepository) SaveCover(cover model.Cover) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.covers[cover.BookID] = cover
+}
+
+// GetCover retrieves the cover of a book
+func (repo *CoverRepository) GetCover(bookID int) (model.Cover, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	cover, exists := repo.covers[bookID]
+	if !exists {
+		return model.Cover{}, ErrCoverNotFound
+	}
+	return cover, nil
+}
+
+// DeleteCover removes the cover of a book
+func (repo *CoverRepository) DeleteCover(bookID int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.covers[bookID]; !exists {
+		return ErrCoverNotFound
+	}
+	delete(repo.covers, bookID)
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrDigitalLoanNotFound is returned when no digital loan exists with the given ID
+var ErrDigitalLoanNotFound = errors.New("digital loan not found")
+
+// DigitalLoanRepository manages digital loan storage
+type DigitalLoanRepository struct {
+	loans  map[int]model.DigitalLoan
+	nextID int
+	mu     sync.Mutex
+}
+
+// NewDigitalLoanRepository initializes a digital loan repository
+func NewDigitalLoanRepository() *DigitalLoanRepository {
+	return &DigitalLoanRepository{
+		loans:  make(map[int]model.DigitalLoan),
+		nextID: 1,
+	}
+}
+
+// AddLoan saves a new digital loan
+func (repo *DigitalLoanRepository) AddLoan(loan model.DigitalLoan) model.DigitalLoan {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	loan.ID = repo.nextID
+	repo.loans[repo.nextID] = loan
+	repo.nextID++
+
+	return loan
+}
+
+// GetLoanByID retrieves a digital loan by its ID
+func (repo *DigitalLoanRepository) GetLoanByID(id int) (model.DigitalLoan, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	loan, exists := repo.loans[id]
+	if !exists {
+		return model.DigitalLoan{}, ErrDigitalLoanNotFound
+	}
+	return loan, nil
+}
+
+// UpdateLoan replaces a stored digital loan
+func (repo *DigitalLoanRepository) UpdateLoan(loan model.DigitalLoan) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.loans[loan.ID]; !exists {
+		return ErrDigitalLoanNotFound
+	}
+	repo.loans[loan.ID] = loan
+	return nil
+}
+
+// GetLoans retrieves digital loans ordered by ID; zero or empty arguments match everything
+func (repo *DigitalLoanRepository) GetLoans(assetID, patronID int, status string) []model.DigitalLoan {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	loans := []model.DigitalLoan{}
+	for _, loan := range repo.loans {
+		if assetID != 0 && loan.AssetID != assetID {
+			continue
+		}
+		if patronID != 0 && loan.PatronID != patronID {
+			continue
+		}
+		if status != "" && loan.Status != status {
+			continue
+		}
+		loans = append(loans, loan)
+	}
+
+	sort.Slice(loans, func(i, j int) bool {
+		return loans[i].ID < loans[j].ID
+	})
+	return loans
+}
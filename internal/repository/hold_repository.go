@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrHoldNotFound is returned when no hold exists with the given ID
+var ErrHoldNotFound = errors.New("hold not found")
+
+// HoldRepository manages hold storage
+type HoldRepository struct {
+	holds  map[int]model.Hold
+	nextID int
+	mu     sync.Mutex
+}
+
+// NewHoldRepository initializes a hold repository
+func NewHoldRepository() *HoldRepository {
+	return &HoldRepository{
+		holds:  make(map[int]model.Hold),
+		nextID: 1,
+	}
+}
+
+// AddHold saves a new hold
+func (repo *HoldRepository) AddHold(hold model.Hold) model.Hold {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	hold.ID = repo.nextID
+	repo.holds[repo.nextID] = hold
+	repo.nextID++
+
+	return hold
+}
+
+// GetHoldByID retrieves a hold by its ID
+func (repo *HoldRepository) GetHoldByID(id int) (model.Hold, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	hold, exists := repo.holds[id]
+	if !exists {
+		return model.Hold{}, ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// UpdateHold replaces a stored hold
+func (repo *HoldRepository) UpdateHold(hold model.Hold) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.holds[hold.ID]; !exists {
+		return ErrHoldNotFound
+	}
+	repo.holds[hold.ID] = hold
+	return nil
+}
+
+// GetHolds retrieves holds in queue order (oldest first); zero or empty arguments match everything
+func (repo *HoldRepository) GetHolds(patronID, bookID int, status string) []model.Hold {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	holds := []model.Hold{}
+	for _, hold := range repo.holds {
+		if patronID != 0 && hold.PatronID != patronID {
+			continue
+		}
+		if bookID != 0 && hold.BookID != bookID {
+			continue
+		}
+		if status != "" && hold.Status != status {
+			continue
+		}
+		holds = append(holds, hold)
+	}
+
+	sort.Slice(holds, func(i, j int) bool {
+		return holds[i].ID < holds[j].ID
+	})
+	return holds
+}
+
+// PurgeClosed removes holds closed before the given time and reports how many were removed
+func (repo *HoldRepository) PurgeClosed(before time.Time) int {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	removed := 0
+	for id, hold := range repo.holds {
+		if hold.ClosedAt != nil && hold.ClosedAt.Before(before) {
+			delete(repo.holds, id)
+			removed++
+		}
+	}
+	return removed
+}
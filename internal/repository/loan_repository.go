@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrLoanNotFound is returned when no loan exists with the given ID
+var ErrLoanNotFound = errors.New("loan not found")
+
+// LoanRepository manages loan storage
+type LoanRepository struct {
+	loans   map[int]model.Loan
+	nextID  int
+	observe Observer
+	mu      sync.Mutex
+}
+
+// NewLoanRepository initializes a loan repository
+func NewLoanRepository() *LoanRepository {
+	return &LoanRepository{
+		loans:  make(map[int]model.Loan),
+		nextID: 1,
+	}
+}
+
+// SetObserver reports the duration of every operation to observe
+func (repo *LoanRepository) SetObserver(observe Observer) {
+	repo.observe = observe
+}
+
+// CountOpen returns the number of loans not yet returned, overdue ones included
+func (repo *LoanRepository) CountOpen() int {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	count := 0
+	for _, loan := range repo.loans {
+		if loan.IsOpen() {
+			count++
+		}
+	}
+	return count
+}
+
+// AddLoan saves a new loan
+func (repo *LoanRepository) AddLoan(loan model.Loan) model.Loan {
+	defer timer(repo.observe, "loans", "add")()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	loan.ID = repo.nextID
+	repo.loans[repo.nextID] = loan
+	repo.nextID++
+
+	return loan
+}
+
+// GetLoanByID retrieves a loan by its ID
+func (repo *LoanRepository) GetLoanByID(id int) (model.Loan, error) {
+	defer timer(repo.observe, "loans", "get")()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	loan, exists := repo.loans[id]
+	if !exists {
+		return model.Loan{}, ErrLoanNotFound
+	}
+	return loan, nil
+}
+
+// UpdateLoan replaces a stored loan
+func (repo *LoanRepository) UpdateLoan(loan model.Loan) error {
+	defer timer(repo.observe, "loans", "update")()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.loans[loan.ID]; !exists {
+		return ErrLoanNotFound
+	}
+	repo.loans[loan.ID] = loan
+	return nil
+}
+
+// GetLoans retrieves loans ordered by ID; zero or empty arguments match everything
+func (repo *LoanRepository) GetLoans(patronID, bookID int, status string) []model.Loan {
+	defer timer(repo.observe, "loans", "list")()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	loans := []model.Loan{}
+	for _, loan := range repo.loans {
+		if patronID != 0 && loan.PatronID != patronID {
+			continue
+		}
+		if bookID != 0 && loan.BookID != bookID {
+			continue
+		}
+		if status != "" && loan.Status != status {
+			continue
+		}
+		loans = append(loans, loan)
+	}
+
+	sort.Slice(loans, func(i, j int) bool {
+		return loans[i].ID < loans[j].ID
+	})
+	return loans
+}
+
+// PurgeReturned removes loans returned before the given time and reports how many were removed
+func (repo *LoanRepository) PurgeReturned(before time.Time) int {
+	defer timer(repo.observe, "loans", "purge")()
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	removed := 0
+	for id, loan := range repo.loans {
+		if loan.ReturnedAt != nil && loan.ReturnedAt.Before(before) {
+			delete(repo.loans, id)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"sort"
+	"sync"
+)
+
+// NotificationRepository stores the notification delivery log
+type NotificationRepository struct {
+	notifications map[int]model.Notification
+	nextID        int
+	mu            sync.Mutex
+}
+
+// NewNotificationRepository initializes a notification repository
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{
+		notifications: make(map[int]model.Notification),
+		nextID:        1,
+	}
+}
+
+// AddNotification records a delivery attempt
+func (repo *NotificationRepository) AddNotification(notification model.Notification) model.Notification {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	notification.ID = repo.nextID
+	repo.notifications[repo.nextID] = notification
+	repo.nextID++
+
+	return notification
+}
+
+// GetNotifications retrieves the delivery log of a patron, newest first; empty filters match everything
+func (repo *NotificationRepository) GetNotifications(patronID int, kind, status string) []model.Notification {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	notifications := []model.Notification{}
+	for _, notification := range repo.notifications {
+		if patronID != 0 && notification.PatronID != patronID {
+			continue
+		}
+		if kind != "" && notification.Kind != kind {
+			continue
+		}
+		if status != "" && notification.Status != status {
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].ID > notifications[j].ID
+	})
+	return notifications
+}
+
+// IsSettled reports whether a notification about reference has been sent or given up on
+func (repo *NotificationRepository) IsSettled(patronID int, kind, reference string) bool {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, notification := range repo.notifications {
+		if notification.PatronID != patronID || notification.Kind != kind || notification.Reference != reference {
+			continue
+		}
+		if notification.Status == model.DeliveryStatusSent || notification.Status == model.DeliveryStatusUndeliverable {
+			return true
+		}
+	}
+	return false
+}
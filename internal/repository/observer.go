@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"LibraryGo/internal/tracing"
+	"context"
+	"time"
+)
+
+// Observer is told how long each repository operation took, e.g. to export timings
+type Observer func(repository, operation string, took time.Duration)
+
+// timer starts timing an operation; the returned function reports it when called
+func timer(observe Observer, repository, operation string) func() {
+	if observe == nil {
+		return func() {}
+	}
+	started := time.Now()
+	return func() {
+		observe(repository, operation, time.Since(started))
+	}
+}
+
+// trace starts a span for an operation within the trace of ctx; the returned function ends it
+func trace(ctx context.Context, repository, operation string) func() {
+	_, span := tracing.Start(ctx, repository+"."+operation,
+		tracing.String("db.system", "memory"),
+		tracing.String("db.collection.name", repository),
+		tracing.String("db.operation.name", operation),
+	)
+	return span.End
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrPatronNotFound is returned when no patron exists with the given ID
+var ErrPatronNotFound = errors.New("patron not found")
+
+// PatronRepository manages patron storage
+type PatronRepository struct {
+	patrons map[int]model.Patron
+	nextID  int
+	mu      sync.Mutex
+}
+
+// NewPatronRepository initializes a patron repository
+func NewPatronRepository() *PatronRepository {
+	return &PatronRepository{
+		patrons: make(map[int]model.Patron),
+		nextID:  1,
+	}
+}
+
+// AddPatron saves a new patron
+func (repo *PatronRepository) AddPatron(patron model.Patron) model.Patron {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	patron.ID = repo.nextID
+	repo.patrons[repo.nextID] = patron
+	repo.nextID++
+
+	return patron
+}
+
+// GetPatronByID retrieves a patron by its ID
+func (repo *PatronRepository) GetPatronByID(id int) (model.Patron, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	patron, exists := repo.patrons[id]
+	if !exists {
+		return model.Patron{}, ErrPatronNotFound
+	}
+	return patron, nil
+}
+
+// GetAllPatrons retrieves all patrons ordered by ID
+func (repo *PatronRepository) GetAllPatrons() []model.Patron {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	patrons := make([]model.Patron, 0, len(repo.patrons))
+	for _, patron := range repo.patrons {
+		patrons = append(patrons, patron)
+	}
+
+	sort.Slice(patrons, func(i, j int) bool {
+		return patrons[i].ID < patrons[j].ID
+	})
+	return patrons
+}
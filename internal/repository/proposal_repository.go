@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrProposalNotFound is returned when no metadata proposal exists with the given ID
+var ErrProposalNotFound = errors.New("proposal not found")
+
+// ProposalRepository manages metadata proposals awaiting staff review
+type ProposalRepository struct {
+	proposals map[int]model.MetadataProposal
+	nextID    int
+	mu        sync.Mutex
+}
+
+// NewProposalRepository initializes a proposal repository
+func NewProposalRepository() *ProposalRepository {
+	return &ProposalRepository{
+		proposals: make(map[int]model.MetadataProposal),
+		nextID:    1,
+	}
+}
+
+// AddProposal saves a new proposal
+func (repo *ProposalRepository) AddProposal(proposal model.MetadataProposal) model.MetadataProposal {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	proposal.ID = repo.nextID
+	repo.proposals[repo.nextID] = proposal
+	repo.nextID++
+
+	return proposal
+}
+
+// GetProposalByID retrieves a proposal by its ID
+func (repo *ProposalRepository) GetProposalByID(id int) (model.MetadataProposal, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	proposal, exists := repo.proposals[id]
+	if !exists {
+		return model.MetadataProposal{}, ErrProposalNotFound
+	}
+	return proposal, nil
+}
+
+// UpdateProposal replaces a stored proposal
+func (repo *ProposalRepository) UpdateProposal(proposal model.MetadataProposal) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.proposals[proposal.ID]; !exists {
+		return ErrProposalNotFound
+	}
+	repo.proposals[proposal.ID] = proposal
+	return nil
+}
+
+// GetProposals retrieves proposals ordered by ID; zero values match everything
+func (repo *ProposalRepository) GetProposals(bookID int, status string) []model.MetadataProposal {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	proposals := []model.MetadataProposal{}
+	for _, proposal := range repo.proposals {
+		if bookID != 0 && proposal.BookID != bookID {
+			continue
+		}
+		if status != "" && proposal.Status != status {
+			continue
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].ID < proposals[j].ID
+	})
+	return proposals
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrReviewNotFound is returned when no review exists with the given ID
+var ErrReviewNotFound = errors.New("review not found")
+
+// ReviewRepository manages review storage
+type ReviewRepository struct {
+	reviews map[int]model.Review
+	nextID  int
+	mu      sync.Mutex
+}
+
+// NewReviewRepository initializes a review repository
+func NewReviewRepository() *ReviewRepository {
+	return &ReviewRepository{
+		reviews: make(map[int]model.Review),
+		nextID:  1,
+	}
+}
+
+// AddReview saves a new review
+func (repo *ReviewRepository) AddReview(review model.Review) model.Review {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	review.ID = repo.nextID
+	repo.reviews[repo.nextID] = review
+	repo.nextID++
+
+	return review
+}
+
+// GetReviewByID retrieves a review by its ID
+func (repo *ReviewRepository) GetReviewByID(id int) (model.Review, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	review, exists := repo.reviews[id]
+	if !exists {
+		return model.Review{}, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// UpdateReview replaces a stored review
+func (repo *ReviewRepository) UpdateReview(review model.Review) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.reviews[review.ID]; !exists {
+		return ErrReviewNotFound
+	}
+	repo.reviews[review.ID] = review
+	return nil
+}
+
+// GetReviews retrieves reviews ordered by ID; zero or empty arguments match everything
+func (repo *ReviewRepository) GetReviews(bookID, patronID int, status string) []model.Review {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	reviews := []model.Review{}
+	for _, review := range repo.reviews {
+		if bookID != 0 && review.BookID != bookID {
+			continue
+		}
+		if patronID != 0 && review.PatronID != patronID {
+			continue
+		}
+		if status != "" && review.Status != status {
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].ID < reviews[j].ID
+	})
+	return reviews
+}
+
+// DeleteReviewsOfBook removes every review of a book
+func (repo *ReviewRepository) DeleteReviewsOfBook(bookID int) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for id, review := range repo.reviews {
+		if review.BookID == bookID {
+			delete(repo.reviews, id)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrStocktakeNotFound is returned when no stocktake session exists with the given ID
+var ErrStocktakeNotFound = errors.New("stocktake session not found")
+
+// StocktakeRepository manages stocktake sessions, their scans and final reports
+type StocktakeRepository struct {
+	sessions map[int]model.StocktakeSession
+	scans    map[int][]model.StocktakeScan
+	reports  map[int]model.StocktakeReport
+	nextID   int
+	mu       sync.Mutex
+}
+
+// NewStocktakeRepository initializes a stocktake repository
+func NewStocktakeRepository() *StocktakeRepository {
+	return &StocktakeRepository{
+		sessions: make(map[int]model.StocktakeSession),
+		scans:    make(map[int][]model.StocktakeScan),
+		reports:  make(map[int]model.StocktakeReport),
+		nextID:   1,
+	}
+}
+
+// AddSession saves a new session
+func (repo *StocktakeRepository) AddSession(session model.StocktakeSession) model.StocktakeSession {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	session.ID = repo.nextID
+	repo.sessions[repo.nextID] = session
+	repo.nextID++
+
+	return session
+}
+
+// GetSessionByID retrieves a session by its ID
+func (repo *StocktakeRepository) GetSessionByID(id int) (model.StocktakeSession, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	session, exists := repo.sessions[id]
+	if !exists {
+		return model.StocktakeSession{}, ErrStocktakeNotFound
+	}
+	session.ScanCount = len(repo.scans[id])
+	return session, nil
+}
+
+// UpdateSession replaces a stored session
+func (repo *StocktakeRepository) UpdateSession(session model.StocktakeSession) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.sessions[session.ID]; !exists {
+		return ErrStocktakeNotFound
+	}
+	repo.sessions[session.ID] = session
+	return nil
+}
+
+// GetSessions retrieves sessions ordered by ID; zero or empty arguments match everything
+func (repo *StocktakeRepository) GetSessions(branchID int, status string) []model.StocktakeSession {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	sessions := []model.StocktakeSession{}
+	for id, session := range repo.sessions {
+		if branchID != 0 && session.BranchID != branchID {
+			continue
+		}
+		if status != "" && session.Status != status {
+			continue
+		}
+		session.ScanCount = len(repo.scans[id])
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions
+}
+
+// AddScans appends scans to a session
+func (repo *StocktakeRepository) AddScans(sessionID int, scans []model.StocktakeScan) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.sessions[sessionID]; !exists {
+		return ErrStocktakeNotFound
+	}
+	repo.scans[sessionID] = append(repo.scans[sessionID], scans...)
+	return nil
+}
+
+// GetScans retrieves the scans of a session in the order they were uploaded
+func (repo *StocktakeRepository) GetScans(sessionID int) []model.StocktakeScan {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return append([]model.StocktakeScan(nil), repo.scans[sessionID]...)
+}
+
+// SaveReport stores the final report of a session
+func (repo *StocktakeRepository) SaveReport(report model.StocktakeReport) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.sessions[report.SessionID]; !exists {
+		return ErrStocktakeNotFound
+	}
+	repo.reports[report.SessionID] = report
+	return nil
+}
+
+// GetReport retrieves the final report of a session, if one was saved
+func (repo *StocktakeRepository) GetReport(sessionID int) (model.StocktakeReport, bool) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	report, exists := repo.reports[sessionID]
+	return report, exists
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrSubjectNotFound is returned when no subject exists with the given ID
+var ErrSubjectNotFound = errors.New("subject not found")
+
+// SubjectRepository manages the subject taxonomy
+type SubjectRepository struct {
+	subjects map[int]model.Subject
+	nextID   int
+	mu       sync.Mutex
+}
+
+// NewSubjectRepository initializes a subject repository
+func NewSubjectRepository() *SubjectRepository {
+	return &SubjectRepository{
+		subjects: make(map[int]model.Subject),
+		nextID:   1,
+	}
+}
+
+// AddSubject saves a new subject
+func (repo *SubjectRepository) AddSubject(subject model.Subject) model.Subject {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	subject.ID = repo.nextID
+	subject.NarrowerIDs = nil
+	repo.subjects[repo.nextID] = subject
+	repo.nextID++
+
+	return subject
+}
+
+// GetSubjectByID retrieves a subject by its ID
+func (repo *SubjectRepository) GetSubjectByID(id int) (model.Subject, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	subject, exists := repo.subjects[id]
+	if !exists {
+		return model.Subject{}, ErrSubjectNotFound
+	}
+	return subject, nil
+}
+
+// UpdateSubject replaces a stored subject
+func (repo *SubjectRepository) UpdateSubject(subject model.Subject) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.subjects[subject.ID]; !exists {
+		return ErrSubjectNotFound
+	}
+	subject.NarrowerIDs = nil
+	repo.subjects[subject.ID] = subject
+	return nil
+}
+
+// GetAllSubjects retrieves all subjects ordered by ID
+func (repo *SubjectRepository) GetAllSubjects() []model.Subject {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	subjects := make([]model.Subject, 0, len(repo.subjects))
+	for _, subject := range repo.subjects {
+		subjects = append(subjects, subject)
+	}
+
+	sort.Slice(subjects, func(i, j int) bool {
+		return subjects[i].ID < subjects[j].ID
+	})
+	return subjects
+}
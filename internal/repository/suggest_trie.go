@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MaxSuggestions is how many ranked suggestions each trie node keeps, and so the most a lookup returns
+const MaxSuggestions = 10
+
+// suggestTerm is one distinct value, such as a title, with the number of books carrying it
+type suggestTerm struct {
+	key   string
+	text  string
+	count int
+}
+
+// trieNode is a node of a path-compressed trie. It caches the best terms of its
+// subtree so a lookup only walks the prefix, however many books match it.
+type trieNode struct {
+	label    string // Bytes on the edge from the parent
+	children []*trieNode
+	terms    []*suggestTerm // Terms with an indexed key ending at this node
+	top      []*suggestTerm
+}
+
+// suggestTrie indexes values by the start of every word they contain, so
+// "lord" and "rings" both find "The Lord of the Rings"
+type suggestTrie struct {
+	root  *trieNode
+	terms map[string]*suggestTerm
+}
+
+func newSuggestTrie() *suggestTrie {
+	return &suggestTrie{root: &trieNode{}, terms: make(map[string]*suggestTerm)}
+}
+
+// add counts one more book carrying the value
+func (t *suggestTrie) add(text string) {
+	key := suggestKey(text)
+	if key == "" {
+		return
+	}
+	term, exists := t.terms[key]
+	if !exists {
+		term = &suggestTerm{key: key, text: strings.TrimSpace(text)}
+		t.terms[key] = term
+	}
+	term.count++
+
+	for _, suffix := range wordSuffixes(key) {
+		path := t.insert(suffix)
+		if !exists {
+			end := path[len(path)-1]
+			end.terms = append(end.terms, term)
+		}
+		// A term that gained a book can only move up the rankings
+		for i := len(path) - 1; i >= 0; i-- {
+			path[i].top = promote(path[i].top, term)
+		}
+	}
+}
+
+// remove counts one book fewer carrying the value, dropping it when none are left
+func (t *suggestTrie) remove(text string) {
+	key := suggestKey(text)
+	term, exists := t.terms[key]
+	if !exists {
+		return
+	}
+	term.count--
+	if term.count <= 0 {
+		delete(t.terms, key)
+	}
+
+	for _, suffix := range wordSuffixes(key) {
+		path := t.path(suffix)
+		if path == nil {
+			continue
+		}
+		if term.count <= 0 {
+			end := path[len(path)-1]
+			end.terms = without(end.terms, term)
+		}
+
+		for i := len(path) - 1; i >= 0; i-- {
+			node := path[i]
+			if !ranks(node.top, term) {
+				continue // Terms below the cut stay below it
+			}
+			node.top = rankTerms(node)
+			if i > 0 && len(node.terms) == 0 {
+				t.prune(path[i-1], node)
+			}
+		}
+	}
+}
+
+// search returns the best values with a word starting with the prefix
+func (t *suggestTrie) search(prefix string, limit int) []*suggestTerm {
+	key := suggestKey(prefix)
+	node := t.root
+	for key != "" {
+		child := node.child(key[0])
+		if child == nil {
+			return nil
+		}
+		if len(key) <= len(child.label) {
+			// The prefix ends part-way along an edge; everything below it matches
+			if !strings.HasPrefix(child.label, key) {
+				return nil
+			}
+			node = child
+			break
+		}
+		if !strings.HasPrefix(key, child.label) {
+			return nil
+		}
+		key = key[len(child.label):]
+		node = child
+	}
+
+	if limit > len(node.top) {
+		limit = len(node.top)
+	}
+	return node.top[:limit]
+}
+
+// insert makes sure a node ends exactly at key and returns the nodes from the root to it
+func (t *suggestTrie) insert(key string) []*trieNode {
+	path := []*trieNode{t.root}
+	node := t.root
+	for key != "" {
+		child := node.child(key[0])
+		if child == nil {
+			child = &trieNode{label: key}
+			node.children = append(node.children, child)
+			return append(path, child)
+		}
+
+		common := commonPrefix(child.label, key)
+		if common < len(child.label) {
+			// Split the edge so that a node ends where the keys diverge
+			split := &trieNode{
+				label:    child.label[:common],
+				children: []*trieNode{child},
+				top:      append([]*suggestTerm(nil), child.top...),
+			}
+			child.label = child.label[common:]
+			node.replaceChild(child, split)
+			child = split
+		}
+		path = append(path, child)
+		key = key[common:]
+		node = child
+	}
+	return path
+}
+
+// path returns the nodes from the root to the node ending exactly at key, or nil
+func (t *suggestTrie) path(key string) []*trieNode {
+	path := []*trieNode{t.root}
+	node := t.root
+	for key != "" {
+		child := node.child(key[0])
+		if child == nil || !strings.HasPrefix(key, child.label) {
+			return nil
+		}
+		path = append(path, child)
+		key = key[len(child.label):]
+		node = child
+	}
+	return path
+}
+
+// prune removes a node that no longer ends any key, merging it into its only child
+func (t *suggestTrie) prune(parent, node *trieNode) {
+	switch len(node.children) {
+	case 0:
+		parent.children = without(parent.children, node)
+	case 1:
+		child := node.children[0]
+		child.label = node.label + child.label
+		parent.replaceChild(node, child)
+	}
+}
+
+// child returns the child whose edge starts with b, or nil
+func (n *trieNode) child(b byte) *trieNode {
+	for _, child := range n.children {
+		if child.label[0] == b {
+			return child
+		}
+	}
+	return nil
+}
+
+func (n *trieNode) replaceChild(old, replacement *trieNode) {
+	for i, child := range n.children {
+		if child == old {
+			n.children[i] = replacement
+			return
+		}
+	}
+}
+
+// promote moves a term up a ranking after its count went up, adding it if it now qualifies
+func promote(top []*suggestTerm, term *suggestTerm) []*suggestTerm {
+	top = without(top, term)
+	if len(top) == MaxSuggestions && !ranksBefore(term, top[len(top)-1]) {
+		return top
+	}
+
+	i := sort.Search(len(top), func(i int) bool { return ranksBefore(term, top[i]) })
+	top = append(top, nil)
+	copy(top[i+1:], top[i:])
+	top[i] = term
+	if len(top) > MaxSuggestions {
+		top = top[:MaxSuggestions]
+	}
+	return top
+}
+
+// rankTerms selects the best of a node's own terms and its children's rankings
+func rankTerms(node *trieNode) []*suggestTerm {
+	var top []*suggestTerm
+	for _, term := range node.terms {
+		top = promote(top, term)
+	}
+	for _, child := range node.children {
+		for _, term := range child.top {
+			top = promote(top, term)
+		}
+	}
+	return top
+}
+
+// ranks reports whether the term is part of the ranking
+func ranks(top []*suggestTerm, term *suggestTerm) bool {
+	for _, ranked := range top {
+		if ranked == term {
+			return true
+		}
+	}
+	return false
+}
+
+// ranksBefore orders terms by book count, then alphabetically
+func ranksBefore(a, b *suggestTerm) bool {
+	if a.count != b.count {
+		return a.count > b.count
+	}
+	return a.key < b.key
+}
+
+// without removes an element from a slice in place
+func without[T comparable](items []T, item T) []T {
+	for i, existing := range items {
+		if existing == item {
+			return append(items[:i], items[i+1:]...)
+		}
+	}
+	return items
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// suggestKey lower-cases a value and reduces punctuation to single spaces
+func suggestKey(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// wordSuffixes lists the key from each word onwards
+func wordSuffixes(key string) []string {
+	suffixes := []string{key}
+	for i, r := range key {
+		if r == ' ' {
+			suffixes = append(suffixes, key[i+1:])
+		}
+	}
+	return suffixes
+}
+
+// suggestions converts ranked terms of one field into API results
+func suggestions(field string, terms []*suggestTerm) []model.Suggestion {
+	results := make([]model.Suggestion, 0, len(terms))
+	for _, term := range terms {
+		results = append(results, model.Suggestion{Text: term.text, Field: field, Count: term.count})
+	}
+	return results
+}
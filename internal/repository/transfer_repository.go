@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"LibraryGo/internal/model"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrTransferNotFound is returned when no transfer exists with the given ID
+var ErrTransferNotFound = errors.New("transfer not found")
+
+// TransferRepository manages inter-branch transfer storage
+type TransferRepository struct {
+	transfers map[int]model.Transfer
+	nextID    int
+	mu        sync.Mutex
+}
+
+// NewTransferRepository initializes a transfer repository
+func NewTransferRepository() *TransferRepository {
+	return &TransferRepository{
+		transfers: make(map[int]model.Transfer),
+		nextID:    1,
+	}
+}
+
+// AddTransfer saves a new transfer
+func (repo *TransferRepository) AddTransfer(transfer model.Transfer) model.Transfer {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	transfer.ID = repo.nextID
+	repo.transfers[repo.nextID] = transfer
+	repo.nextID++
+
+	return transfer
+}
+
+// GetTransferByID retrieves a transfer by its ID
+func (repo *TransferRepository) GetTransferByID(id int) (model.Transfer, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	transfer, exists := repo.transfers[id]
+	if !exists {
+		return model.Transfer{}, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+// UpdateTransfer replaces a stored transfer
+func (repo *TransferRepository) UpdateTransfer(transfer model.Transfer) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.transfers[transfer.ID]; !exists {
+		return ErrTransferNotFound
+	}
+	repo.transfers[transfer.ID] = transfer
+	return nil
+}
+
+// GetTransfers retrieves transfers ordered by ID; zero or empty arguments match everything.
+// branchID matches either end of the transfer.
+func (repo *TransferRepository) GetTransfers(copyID, branchID int, status string) []model.Transfer {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	transfers := []model.Transfer{}
+	for _, transfer := range repo.transfers {
+		if copyID != 0 && transfer.CopyID != copyID {
+			continue
+		}
+		if branchID != 0 && transfer.FromBranchID != branchID && transfer.ToBranchID != branchID {
+			continue
+		}
+		if status != "" && transfer.Status != status {
+			continue
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].ID < transfers[j].ID
+	})
+	return transfers
+}
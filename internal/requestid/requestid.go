@@ -0,0 +1,47 @@
+// Package requestid carries the ID of the request being served in its context, so
+// responses, log lines and the client's own records can be matched up
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the request and response header the ID travels in
+const Header = "X-Request-ID"
+
+// maxLength bounds IDs taken from clients, which end up in every log line
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates an ID
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid reports whether an ID sent by a client can be used as is: at most 128
+// printable ASCII characters
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// With returns a copy of ctx carrying id
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// From returns the request ID of ctx, or "" outside a request
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
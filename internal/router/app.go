@@ -0,0 +1,170 @@
+package router
+
+import (
+	"LibraryGo/internal/blob"
+	"LibraryGo/internal/config"
+	"LibraryGo/internal/health"
+	"LibraryGo/internal/jobs"
+	"LibraryGo/internal/notify"
+	"LibraryGo/internal/service"
+	"LibraryGo/internal/tracing"
+	"context"
+	"log"
+	"log/slog"
+
+	"github.com/gorilla/mux"
+)
+
+// App bundles the HTTP router with the components that outlive a single request
+type App struct {
+	Router    *mux.Router
+	Scheduler *jobs.Scheduler
+	Logger    *slog.Logger
+	Tracer    *tracing.Tracer
+	Health    *health.Registry
+	Storage   blob.Store
+}
+
+// newStateStore picks file-backed job state when a path is configured
+func newStateStore(cfg config.JobsConfig) jobs.StateStore {
+	if cfg.StateFile == "" {
+		return jobs.NewMemoryStateStore()
+	}
+	return jobs.NewFileStateStore(cfg.StateFile)
+}
+
+// newBlobStore picks local filesystem storage when a directory is configured
+func newBlobStore(cfg config.StorageConfig) blob.Store {
+	if cfg.Dir == "" {
+		return blob.NewMemoryStore()
+	}
+	return blob.NewFileStore(cfg.Dir)
+}
+
+// newChannels creates the notification channels that have a destination configured and
+// stops the server from starting when the default channel is not one of them
+func newChannels(cfg config.NotifyConfig) []notify.Channel {
+	var channels []notify.Channel
+	if cfg.SMTPHost != "" {
+		channels = append(channels, notify.NewSMTPChannel(notify.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		}))
+	}
+	// Without a global URL the webhook channel still serves patrons with their own webhook
+	channels = append(channels, notify.NewWebhookChannel(cfg.WebhookURL, 0))
+	if cfg.OutboxPath != "" {
+		channels = append(channels, notify.NewOutboxChannel(cfg.OutboxPath))
+	}
+	for _, channel := range channels {
+		if channel.Name() == cfg.DefaultChannel {
+			return channels
+		}
+	}
+	log.Fatalf("notify: default channel %q is not configured", cfg.DefaultChannel)
+	return nil
+}
+
+// channelNames lists the names of channels
+func channelNames(channels []notify.Channel) []string {
+	names := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		names = append(names, channel.Name())
+	}
+	return names
+}
+
+// registerJobs adds the library's background jobs to the scheduler
+func registerJobs(scheduler *jobs.Scheduler, cfg config.JobsConfig, circulation *service.CirculationService, notifications *service.NotificationService,
+	lending *service.LendingService, logger *slog.Logger) {
+	all := []jobs.Job{
+		{
+			Name:        "overdue-detection",
+			Description: "Flags active loans whose due date has passed",
+			Spec:        cfg.OverdueSchedule,
+			Run: func(ctx context.Context) error {
+				loans, err := circulation.MarkOverdueLoans(ctx)
+				if len(loans) > 0 {
+					logger.InfoContext(ctx, "loans marked overdue", "count", len(loans))
+				}
+				if err != nil {
+					return err
+				}
+				sent, err := notifications.SendOverdueNotices(ctx)
+				if sent > 0 {
+					logger.InfoContext(ctx, "overdue notices sent", "count", sent)
+				}
+				return err
+			},
+		},
+		{
+			Name:        "hold-expiry",
+			Description: "Expires ready holds that were not picked up in time",
+			Spec:        cfg.HoldExpirySchedule,
+			Run: func(ctx context.Context) error {
+				holds, err := circulation.ExpireHolds(ctx)
+				if len(holds) > 0 {
+					logger.InfoContext(ctx, "holds expired", "count", len(holds))
+				}
+				return err
+			},
+		},
+		{
+			Name:        "due-reminders",
+			Description: "Reminds patrons of loans that are due soon",
+			Spec:        cfg.ReminderSchedule,
+			Run: func(ctx context.Context) error {
+				sent, err := notifications.SendDueReminders(ctx)
+				if sent > 0 {
+					logger.InfoContext(ctx, "due date reminders sent", "count", sent)
+				}
+				return err
+			},
+		},
+		{
+			Name:        "hold-ready-notices",
+			Description: "Tells patrons that a held book is waiting for pickup",
+			Spec:        cfg.HoldNoticeSchedule,
+			Run: func(ctx context.Context) error {
+				sent, err := notifications.SendHoldReadyNotices(ctx)
+				if sent > 0 {
+					logger.InfoContext(ctx, "hold ready notices sent", "count", sent)
+				}
+				return err
+			},
+		},
+		{
+			Name:        "ebook-expiry",
+			Description: "Ends digital loans whose period is over, returning their licenses",
+			Spec:        cfg.EbookExpirySchedule,
+			Run: func(ctx context.Context) error {
+				loans, err := lending.ExpireLoans(ctx)
+				if len(loans) > 0 {
+					logger.InfoContext(ctx, "digital loans expired", "count", len(loans))
+				}
+				return err
+			},
+		},
+		{
+			Name:        "compaction",
+			Description: "Removes returned loans and closed holds past the retention period",
+			Spec:        cfg.CompactionSchedule,
+			Run: func(ctx context.Context) error {
+				removed, err := circulation.Compact(ctx, cfg.Retention)
+				if removed > 0 {
+					logger.InfoContext(ctx, "circulation records compacted", "count", removed)
+				}
+				return err
+			},
+		},
+	}
+
+	for _, job := range all {
+		if err := scheduler.Register(job); err != nil {
+			log.Fatalf("jobs: %v", err)
+		}
+	}
+}
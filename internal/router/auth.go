@@ -0,0 +1,129 @@
+package router
+
+import (
+	"LibraryGo/internal/auth"
+	"LibraryGo/internal/config"
+	"LibraryGo/internal/handler"
+	"LibraryGo/internal/middleware"
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/service"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+// routeOf names the matched route by method and path template, e.g. "GET /books/{id}"
+func routeOf(r *http.Request) (string, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "", false
+	}
+	return r.Method + " " + template, true
+}
+
+// routeTable registers routes together with the permission each one needs, so no
+// route can exist without a declaration
+type routeTable struct {
+	router      *mux.Router
+	permissions map[*mux.Route]string
+}
+
+func newRouteTable(r *mux.Router) *routeTable {
+	return &routeTable{router: r, permissions: make(map[*mux.Route]string)}
+}
+
+// handle registers a handler for the method and path template, requiring the permission
+func (t *routeTable) handle(method, path, permission string, handler http.Handler) {
+	route := t.router.Handle(path, handler).Methods(method)
+	t.permissions[route] = permission
+}
+
+// handleFunc registers a handler function for the method and path template, requiring the permission
+func (t *routeTable) handleFunc(method, path, permission string, handler http.HandlerFunc) {
+	t.handle(method, path, permission, handler)
+}
+
+// permissionOf looks up the permission the matched route was registered with
+func (t *routeTable) permissionOf(r *http.Request) (string, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+	permission, ok := t.permissions[route]
+	return permission, ok
+}
+
+// newAuthMiddleware loads the configured API keys and token keys. sessions is nil
+// when browser login is off.
+func newAuthMiddleware(cfg config.AuthConfig, sessions *auth.SessionStore, logger *slog.Logger) mux.MiddlewareFunc {
+	if cfg.Disabled {
+		logger.Warn("authentication is disabled; every request is trusted")
+		return middleware.Trust(model.Principal{ID: "local", Name: "Local", Kind: model.PrincipalLocal, Roles: []string{auth.RoleAdmin}})
+	}
+
+	var apiKeys []auth.APIKey
+	if cfg.APIKeysFile != "" {
+		var err error
+		if apiKeys, err = auth.LoadAPIKeys(cfg.APIKeysFile); err != nil {
+			log.Fatalf("auth: %v", err)
+		}
+	}
+	keys, err := auth.NewKeyStore(apiKeys)
+	if err != nil {
+		log.Fatalf("auth: %v", err)
+	}
+
+	keySet := auth.NewKeySet()
+	if cfg.JWKSFile != "" {
+		if keySet, err = auth.LoadKeySet(cfg.JWKSFile); err != nil {
+			log.Fatalf("auth: %v", err)
+		}
+	}
+	if cfg.JWTSecret != "" {
+		keySet.AddHMAC("", []byte(cfg.JWTSecret))
+	}
+	var tokens *auth.JWTVerifier
+	if keySet.Len() > 0 {
+		tokens = auth.NewJWTVerifier(keySet, cfg.JWTIssuer, cfg.JWTAudience)
+	}
+
+	if keys.Len() == 0 && tokens == nil && sessions == nil {
+		logger.Warn("no API keys, token keys or login provider are configured; every caller is anonymous")
+	}
+	return middleware.Authenticate(keys, tokens, sessions)
+}
+
+// newLoginHandler sets up browser login through the configured OpenID Connect
+// provider; both results are nil when there is none
+func newLoginHandler(cfg config.AuthConfig) (*handler.LoginHandler, *auth.SessionStore) {
+	if cfg.Disabled || cfg.OIDCIssuer == "" {
+		return nil, nil
+	}
+	if cfg.OIDCClientID == "" || cfg.OIDCRedirectURL == "" {
+		log.Fatalf("auth: LIBRARY_OIDC_CLIENT_ID and LIBRARY_OIDC_REDIRECT_URL are required with LIBRARY_OIDC_ISSUER")
+	}
+	provider := auth.NewOIDCClient(auth.OIDCConfig{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+	}, nil)
+	sessions := auth.NewSessionStore(cfg.SessionTTL)
+	var roles []string
+	if cfg.OIDCDefaultRole != "" {
+		roles = []string{cfg.OIDCDefaultRole}
+	}
+	login := service.NewLoginService(provider, sessions, roles)
+	redirect, err := url.Parse(cfg.OIDCRedirectURL)
+	if err != nil || redirect.Scheme == "" || redirect.Host == "" {
+		log.Fatalf("auth: LIBRARY_OIDC_REDIRECT_URL must be an absolute URL")
+	}
+	return handler.NewLoginHandler(login, redirect.Scheme+"://"+redirect.Host), sessions
+}
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"LibraryGo/internal/blob"
+	"LibraryGo/internal/health"
+	"LibraryGo/internal/jobs"
+	"context"
+)
+
+// newHealth registers the checks behind the probes. A stalled scheduler fails liveness,
+// since only a restart brings it back; storage and migrations only fail readiness.
+func newHealth(scheduler *jobs.Scheduler, blobs blob.Store) *health.Registry {
+	registry := health.NewRegistry(health.DefaultTimeout)
+	registry.AddLiveness("jobs", func(context.Context) error {
+		return scheduler.Check()
+	})
+	registry.AddReadiness("storage", func(context.Context) error {
+		return blob.Check(blobs)
+	})
+	// The repositories are in memory and always start at the current schema, so there is
+	// nothing to migrate yet; a database would report its pending migrations here
+	registry.AddReadiness("migrations", func(context.Context) error {
+		return nil
+	})
+	return registry
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"LibraryGo/internal/metrics"
+	"LibraryGo/internal/repository"
+	"LibraryGo/internal/tracing"
+	"time"
+)
+
+// newRegistry registers the library's metrics: request and repository latencies,
+// catalogue and circulation gauges, spans lost by the tracer and Go runtime
+// statistics. It returns the histogram the HTTP middleware records requests in.
+func newRegistry(books *repository.BookRepository, loans *repository.LoanRepository, tracer *tracing.Tracer) (*metrics.Registry, *metrics.Histogram) {
+	registry := metrics.NewRegistry()
+	requests := registry.NewHistogram("http_request_duration_seconds", "Latency of HTTP requests by route template and status.",
+		metrics.DefaultBuckets, "method", "route", "status")
+
+	operations := registry.NewHistogram("library_repository_operation_duration_seconds", "Latency of repository operations.",
+		metrics.DefaultBuckets, "repository", "operation")
+	observe := func(repository, operation string, took time.Duration) {
+		operations.Observe(took.Seconds(), repository, operation)
+	}
+	books.SetObserver(observe)
+	loans.SetObserver(observe)
+
+	registry.NewGaugeFunc("library_books", "Number of books in the catalogue.", func() float64 {
+		return float64(books.Count())
+	})
+	registry.NewGaugeFunc("library_active_loans", "Number of loans not yet returned, overdue ones included.", func() float64 {
+		return float64(loans.CountOpen())
+	})
+	registry.NewCollector("library_trace_spans_dropped_total", "Finished spans lost to a full export queue or a failed export.", "counter", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(tracer.Dropped())}}
+	})
+	registry.RegisterRuntime()
+	return registry, requests
+}
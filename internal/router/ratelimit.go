@@ -0,0 +1,64 @@
+package router
+
+import (
+	"LibraryGo/internal/config"
+	"LibraryGo/internal/middleware"
+	"LibraryGo/internal/ratelimit"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// newRateLimitMiddleware parses the configured limits and checks every per-route
+// limit names a route of r, so a typo cannot silently leave a route unlimited
+func newRateLimitMiddleware(cfg config.RateLimitConfig, r *mux.Router) mux.MiddlewareFunc {
+	if cfg.Disabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	fallback, err := ratelimit.ParseLimit(cfg.Default)
+	if err != nil {
+		log.Fatalf("ratelimit: default limit %q: %v", cfg.Default, err)
+	}
+	routes, err := ratelimit.ParseRoutes(cfg.Routes)
+	if err != nil {
+		log.Fatalf("ratelimit: %v", err)
+	}
+
+	known := make(map[string]bool)
+	r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, _ := route.GetPathTemplate()
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			known[method+" "+template] = true
+		}
+		return nil
+	})
+	for name := range routes {
+		if !known[name] {
+			log.Fatalf("ratelimit: no route %s", name)
+		}
+	}
+
+	limitOf := func(r *http.Request) (string, ratelimit.Limit) {
+		if name, ok := routeOf(r); ok {
+			if limit, ok := routes[name]; ok {
+				return name, limit
+			}
+		}
+		return "default", fallback
+	}
+	return middleware.RateLimit(ratelimit.NewLimiter(), limitOf, cfg.TrustProxy)
+}
+
+// newClientLimitMiddleware parses the per-address limit applied before authentication
+func newClientLimitMiddleware(cfg config.RateLimitConfig) mux.MiddlewareFunc {
+	if cfg.Disabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	limit, err := ratelimit.ParseLimit(cfg.PerIP)
+	if err != nil {
+		log.Fatalf("ratelimit: per-IP limit %q: %v", cfg.PerIP, err)
+	}
+	return middleware.ClientLimit(ratelimit.NewLimiter(), limit, cfg.TrustProxy)
+}
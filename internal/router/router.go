@@ -1,24 +1,252 @@
 package router
 
 import (
+	"LibraryGo/internal/auth"
+	"LibraryGo/internal/config"
 	"LibraryGo/internal/handler"
+	"LibraryGo/internal/jobs"
+	"LibraryGo/internal/logging"
+	"LibraryGo/internal/middleware"
+	"LibraryGo/internal/notify"
 	"LibraryGo/internal/repository"
 	"LibraryGo/internal/service"
+	"log"
+	"net/http"
+	"os"
 
 	"github.com/gorilla/mux"
 )
 
-// SetupRouter initializes the router
+// SetupRouter initializes the router with the default configuration and authentication
+// and rate limiting turned off, for tests and local tools
 func SetupRouter() *mux.Router {
+	cfg := config.Default()
+	cfg.Auth.Disabled = true
+	cfg.RateLimit.Disabled = true
+	return NewApp(cfg).Router
+}
+
+// NewApp wires repositories, services, handlers and background jobs
+func NewApp(cfg config.Config) *App {
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatalf("logging: %v", err)
+	}
+
+	tracer := newTracer(cfg.Tracing, logger.With("component", "tracing"))
+
 	r := mux.NewRouter()
-	repo := repository.NewBookRepository()
-	bookService := service.NewBookService(repo)
+	repo := repository.NewBookRepository(logger.With("component", "books"))
+	loanRepo := repository.NewLoanRepository()
+	holdRepo := repository.NewHoldRepository()
+	patronRepo := repository.NewPatronRepository()
+	notificationRepo := repository.NewNotificationRepository()
+	branchRepo := repository.NewBranchRepository()
+	copyRepo := repository.NewCopyRepository()
+	transferRepo := repository.NewTransferRepository()
+	stocktakeRepo := repository.NewStocktakeRepository()
+	subjectRepo := repository.NewSubjectRepository()
+	reviewRepo := repository.NewReviewRepository()
+	collectionRepo := repository.NewCollectionRepository()
+	coverRepo := repository.NewCoverRepository()
+	assetRepo := repository.NewAssetRepository()
+	digitalLoanRepo := repository.NewDigitalLoanRepository()
+	proposalRepo := repository.NewProposalRepository()
+
+	templates, err := notify.NewTemplates()
+	if err != nil {
+		log.Fatalf("notify: %v", err)
+	}
+	retry := notify.DefaultRetryPolicy
+	retry.MaxAttempts = cfg.Notify.MaxAttempts
+	retry.InitialBackoff = cfg.Notify.InitialBackoff
+
+	branchService := service.NewBranchService(branchRepo, copyRepo, transferRepo, repo)
+	subjectService := service.NewSubjectService(subjectRepo)
+	reviewService := service.NewReviewService(reviewRepo, repo, patronRepo)
+	collectionService := service.NewCollectionService(collectionRepo, repo, patronRepo)
+	blobs := newBlobStore(cfg.Storage)
+	coverService := service.NewCoverService(coverRepo, repo, blobs)
+	lendingService := service.NewLendingService(assetRepo, digitalLoanRepo, repo, patronRepo, blobs, []byte(cfg.Lending.SigningKey))
+	lendingService.LoanPeriod = cfg.Lending.LoanPeriod
+	lendingService.DownloadTTL = cfg.Lending.DownloadTTL
+	bookService := service.NewBookService(repo, copyRepo, branchService, subjectService, reviewService, collectionService,
+		coverService, lendingService, logger.With("component", "books"))
+	catalogService := service.NewCatalogService(bookService, repo, proposalRepo, lendingService)
+	labelService := service.NewLabelService(copyRepo, repo)
+	stocktakeService := service.NewStocktakeService(stocktakeRepo, copyRepo, branchRepo, repo)
+	channels := newChannels(cfg.Notify)
+	patronService := service.NewPatronService(patronRepo, channelNames(channels)...)
+	circulationService := service.NewCirculationService(loanRepo, holdRepo, repo, patronRepo, copyRepo)
+	recommendationService := service.NewRecommendationService(repo, patronRepo, loanRepo)
+	circulationService.Observe(recommendationService)
+	notificationService := service.NewNotificationService(patronRepo, repo, circulationService, notificationRepo,
+		templates, retry, cfg.Notify.DefaultChannel, channels...)
+	notificationService.DueSoonWindow = cfg.Notify.DueSoonWindow
+
+	registry, requests := newRegistry(repo, loanRepo, tracer)
+
+	scheduler := jobs.NewScheduler(newStateStore(cfg.Jobs))
+	registerJobs(scheduler, cfg.Jobs, circulationService, notificationService, lendingService, logger.With("component", "jobs"))
+	probes := newHealth(scheduler, blobs)
+
+	policy := auth.DefaultPolicy()
 	bookHandler := handler.NewBookHandler(bookService)
+	branchHandler := handler.NewBranchHandler(branchService)
+	stocktakeHandler := handler.NewStocktakeHandler(stocktakeService)
+	subjectHandler := handler.NewSubjectHandler(subjectService, bookService)
+	reviewHandler := handler.NewReviewHandler(reviewService, policy)
+	collectionHandler := handler.NewCollectionHandler(collectionService, policy)
+	coverHandler := handler.NewCoverHandler(coverService)
+	lendingHandler := handler.NewLendingHandler(lendingService)
+	catalogHandler := handler.NewCatalogHandler(catalogService)
+	labelHandler := handler.NewLabelHandler(labelService)
+	patronHandler := handler.NewPatronHandler(patronService, notificationService)
+	circulationHandler := handler.NewCirculationHandler(circulationService)
+	recommendationHandler := handler.NewRecommendationHandler(recommendationService, policy)
+	jobsHandler := handler.NewJobsHandler(scheduler)
+	metricsHandler := handler.NewMetricsHandler(registry)
+	healthHandler := handler.NewHealthHandler(probes)
+	authHandler := handler.NewAuthHandler(policy)
+	loginHandler, sessions := newLoginHandler(cfg.Auth)
+
+	// Routes moving book files get longer than the server's timeouts to read and write them
+	transfer := middleware.ExtendDeadlines(cfg.Server.TransferTimeout)
+
+	// Each route names the permission it needs. Public routes are the login itself, links
+	// handed out to patrons, which carry their own signature or token, images embedded in
+	// web pages and the orchestrator's probes.
+	routes := newRouteTable(r)
+	routes.handleFunc("GET", "/auth/me", auth.PermPublic, authHandler.Me)
+	if loginHandler != nil {
+		routes.handleFunc("GET", "/auth/login", auth.PermPublic, loginHandler.Login)
+		routes.handleFunc("GET", "/auth/callback", auth.PermPublic, loginHandler.Callback)
+		routes.handleFunc("POST", "/auth/logout", auth.PermPublic, loginHandler.Logout)
+	}
+
+	routes.handleFunc("GET", "/books", auth.PermCatalogRead, bookHandler.GetBooks)
+	routes.handleFunc("GET", "/books/suggest", auth.PermCatalogRead, bookHandler.SuggestBooks)
+	routes.handle("POST", "/books/import", auth.PermCatalogWrite, transfer(http.HandlerFunc(catalogHandler.ImportEPUB)))
+	routes.handleFunc("GET", "/books/{id}", auth.PermCatalogRead, bookHandler.GetBookByID)
+	routes.handleFunc("POST", "/books", auth.PermCatalogWrite, bookHandler.AddBook)
+	routes.handleFunc("DELETE", "/books/{id}", auth.PermCatalogDelete, bookHandler.DeleteBookByID)
+	routes.handleFunc("GET", "/books/{id}/copies", auth.PermCatalogRead, branchHandler.GetCopies)
+	routes.handleFunc("POST", "/books/{id}/copies", auth.PermCatalogWrite, branchHandler.AddCopy)
+	routes.handleFunc("PUT", "/books/{id}/subjects", auth.PermCatalogWrite, subjectHandler.SetBookSubjects)
+	routes.handleFunc("POST", "/books/{id}/subjects/{subjectId}", auth.PermCatalogWrite, subjectHandler.AddBookSubject)
+	routes.handleFunc("DELETE", "/books/{id}/subjects/{subjectId}", auth.PermCatalogWrite, subjectHandler.RemoveBookSubject)
+	routes.handleFunc("PUT", "/books/{id}/tags", auth.PermCatalogWrite, subjectHandler.SetBookTags)
+	routes.handleFunc("POST", "/books/{id}/tags", auth.PermCatalogWrite, subjectHandler.AddBookTags)
+	routes.handleFunc("DELETE", "/books/{id}/tags/{tag}", auth.PermCatalogWrite, subjectHandler.RemoveBookTag)
+	routes.handleFunc("GET", "/books/{id}/related", auth.PermCatalogRead, recommendationHandler.GetRelatedBooks)
+	routes.handleFunc("GET", "/books/{id}/cover", auth.PermPublic, coverHandler.GetCover)
+	routes.handleFunc("PUT", "/books/{id}/cover", auth.PermCatalogWrite, coverHandler.UploadCover)
+	routes.handleFunc("DELETE", "/books/{id}/cover", auth.PermCatalogDelete, coverHandler.DeleteCover)
+	routes.handleFunc("GET", "/books/{id}/assets", auth.PermCatalogRead, lendingHandler.GetAssets)
+	routes.handle("POST", "/books/{id}/assets", auth.PermCatalogWrite, transfer(http.HandlerFunc(lendingHandler.AddAsset)))
+	routes.handleFunc("GET", "/books/{id}/barcode", auth.PermLabelsPrint, labelHandler.GetBookBarcode)
+	routes.handleFunc("GET", "/copies/{id}/barcode", auth.PermLabelsPrint, labelHandler.GetCopyBarcode)
+	routes.handleFunc("GET", "/labels/stocks", auth.PermLabelsPrint, labelHandler.GetStocks)
+	routes.handleFunc("POST", "/labels/sheet", auth.PermLabelsPrint, labelHandler.PrintSheet)
+
+	routes.handleFunc("GET", "/books/{id}/reviews", auth.PermCatalogRead, reviewHandler.GetBookReviews)
+	routes.handleFunc("POST", "/books/{id}/reviews", auth.PermReviewsWrite, reviewHandler.AddReview)
+
+	routes.handleFunc("GET", "/proposals", auth.PermCatalogWrite, catalogHandler.GetProposals)
+	routes.handleFunc("GET", "/proposals/{id}", auth.PermCatalogWrite, catalogHandler.GetProposalByID)
+	routes.handleFunc("POST", "/proposals/{id}/approve", auth.PermCatalogWrite, catalogHandler.ApproveProposal)
+	routes.handleFunc("POST", "/proposals/{id}/reject", auth.PermCatalogWrite, catalogHandler.RejectProposal)
+
+	routes.handleFunc("GET", "/reviews", auth.PermReviewsModerate, reviewHandler.GetReviews)
+	routes.handleFunc("GET", "/reviews/{id}", auth.PermReviewsModerate, reviewHandler.GetReviewByID)
+	routes.handleFunc("POST", "/reviews/{id}/approve", auth.PermReviewsModerate, reviewHandler.ApproveReview)
+	routes.handleFunc("POST", "/reviews/{id}/reject", auth.PermReviewsModerate, reviewHandler.RejectReview)
+
+	// Public reading lists are browsed like the catalogue; private ones stay with their owner
+	routes.handleFunc("GET", "/collections", auth.PermCatalogRead, collectionHandler.GetCollections)
+	routes.handleFunc("POST", "/collections", auth.PermCollectionsWrite, collectionHandler.AddCollection)
+	routes.handleFunc("GET", "/collections/shared/{token}", auth.PermPublic, collectionHandler.GetSharedCollection)
+	routes.handleFunc("GET", "/collections/{id}", auth.PermCatalogRead, collectionHandler.GetCollectionByID)
+	routes.handleFunc("PUT", "/collections/{id}", auth.PermCollectionsWrite, collectionHandler.UpdateCollection)
+	routes.handleFunc("DELETE", "/collections/{id}", auth.PermCollectionsWrite, collectionHandler.DeleteCollectionByID)
+	routes.handleFunc("POST", "/collections/{id}/entries", auth.PermCollectionsWrite, collectionHandler.AddEntry)
+	routes.handleFunc("PUT", "/collections/{id}/entries/{bookId}", auth.PermCollectionsWrite, collectionHandler.UpdateEntry)
+	routes.handleFunc("DELETE", "/collections/{id}/entries/{bookId}", auth.PermCollectionsWrite, collectionHandler.RemoveEntry)
+	routes.handleFunc("PUT", "/collections/{id}/order", auth.PermCollectionsWrite, collectionHandler.Reorder)
+	routes.handleFunc("POST", "/collections/{id}/share", auth.PermCollectionsWrite, collectionHandler.Share)
+	routes.handleFunc("DELETE", "/collections/{id}/share", auth.PermCollectionsWrite, collectionHandler.Unshare)
+
+	routes.handleFunc("GET", "/subjects", auth.PermCatalogRead, subjectHandler.GetSubjects)
+	routes.handleFunc("POST", "/subjects", auth.PermCatalogWrite, subjectHandler.AddSubject)
+	routes.handleFunc("GET", "/subjects/{id}", auth.PermCatalogRead, subjectHandler.GetSubjectByID)
+	routes.handleFunc("PUT", "/subjects/{id}", auth.PermCatalogWrite, subjectHandler.UpdateSubject)
+
+	routes.handleFunc("GET", "/branches", auth.PermBranchesRead, branchHandler.GetBranches)
+	routes.handleFunc("POST", "/branches", auth.PermBranchesWrite, branchHandler.AddBranch)
+	routes.handleFunc("GET", "/branches/{id}", auth.PermBranchesRead, branchHandler.GetBranchByID)
+	routes.handleFunc("GET", "/transfers", auth.PermBranchesRead, branchHandler.GetTransfers)
+	routes.handleFunc("POST", "/transfers", auth.PermBranchesWrite, branchHandler.RequestTransfer)
+	routes.handleFunc("GET", "/transfers/{id}", auth.PermBranchesRead, branchHandler.GetTransferByID)
+	routes.handleFunc("POST", "/transfers/{id}/ship", auth.PermBranchesWrite, branchHandler.ShipTransfer)
+	routes.handleFunc("POST", "/transfers/{id}/receive", auth.PermBranchesWrite, branchHandler.ReceiveTransfer)
+	routes.handleFunc("POST", "/transfers/{id}/cancel", auth.PermBranchesWrite, branchHandler.CancelTransfer)
+	routes.handleFunc("GET", "/stocktakes", auth.PermBranchesRead, stocktakeHandler.GetSessions)
+	routes.handleFunc("POST", "/stocktakes", auth.PermBranchesWrite, stocktakeHandler.StartSession)
+	routes.handleFunc("GET", "/stocktakes/{id}", auth.PermBranchesRead, stocktakeHandler.GetSessionByID)
+	routes.handleFunc("POST", "/stocktakes/{id}/scans", auth.PermBranchesWrite, stocktakeHandler.AddScans)
+	routes.handleFunc("GET", "/stocktakes/{id}/report", auth.PermBranchesRead, stocktakeHandler.GetReport)
+	routes.handleFunc("POST", "/stocktakes/{id}/close", auth.PermBranchesWrite, stocktakeHandler.CloseSession)
+	routes.handleFunc("POST", "/stocktakes/{id}/mark-lost", auth.PermBranchesWrite, stocktakeHandler.MarkLost)
+
+	routes.handleFunc("GET", "/patrons", auth.PermPatronsRead, patronHandler.GetPatrons)
+	routes.handleFunc("POST", "/patrons", auth.PermPatronsWrite, patronHandler.AddPatron)
+	routes.handleFunc("GET", "/patrons/{id}", auth.PermPatronsRead, patronHandler.GetPatronByID)
+	routes.handleFunc("GET", "/patrons/{id}/notifications", auth.PermPatronsRead, patronHandler.GetNotifications)
+	// Patrons read their own recommendations; staff read anyone's
+	routes.handleFunc("GET", "/patrons/{id}/recommendations", auth.PermCatalogRead, recommendationHandler.GetPatronRecommendations)
+
+	routes.handleFunc("GET", "/loans", auth.PermCirculationRead, circulationHandler.GetLoans)
+	routes.handleFunc("POST", "/loans", auth.PermCirculationWrite, circulationHandler.Checkout)
+	routes.handleFunc("GET", "/loans/{id}", auth.PermCirculationRead, circulationHandler.GetLoanByID)
+	routes.handleFunc("POST", "/loans/{id}/return", auth.PermCirculationWrite, circulationHandler.ReturnLoan)
+	routes.handleFunc("GET", "/assets/{id}", auth.PermCatalogRead, lendingHandler.GetAssetByID)
+	routes.handleFunc("PUT", "/assets/{id}", auth.PermCatalogWrite, lendingHandler.UpdateAsset)
+	routes.handleFunc("DELETE", "/assets/{id}", auth.PermCatalogDelete, lendingHandler.DeleteAsset)
+	routes.handleFunc("POST", "/assets/{id}/loans", auth.PermCirculationWrite, lendingHandler.Borrow)
+	routes.handleFunc("GET", "/digital-loans", auth.PermCirculationRead, lendingHandler.GetLoans)
+	routes.handleFunc("GET", "/digital-loans/{id}", auth.PermCirculationRead, lendingHandler.GetLoanByID)
+	routes.handleFunc("POST", "/digital-loans/{id}/return", auth.PermCirculationWrite, lendingHandler.ReturnLoan)
+	routes.handle("GET", "/downloads/{id}", auth.PermPublic, transfer(http.HandlerFunc(lendingHandler.Download)))
+	routes.handleFunc("GET", "/holds", auth.PermCirculationRead, circulationHandler.GetHolds)
+	routes.handleFunc("POST", "/holds", auth.PermCirculationWrite, circulationHandler.PlaceHold)
+	routes.handleFunc("GET", "/holds/{id}", auth.PermCirculationRead, circulationHandler.GetHoldByID)
+	routes.handleFunc("POST", "/holds/{id}/cancel", auth.PermCirculationWrite, circulationHandler.CancelHold)
+
+	routes.handleFunc("GET", "/admin/jobs", auth.PermJobsRead, jobsHandler.GetJobs)
+	routes.handleFunc("GET", "/admin/jobs/{name}", auth.PermJobsRead, jobsHandler.GetJob)
+	routes.handleFunc("POST", "/admin/jobs/{name}/run", auth.PermJobsRun, jobsHandler.RunJob)
 
-	r.HandleFunc("/books", bookHandler.GetBooks).Methods("GET")
-	r.HandleFunc("/books/{id}", bookHandler.GetBookByID).Methods("GET")
-	r.HandleFunc("/books", bookHandler.AddBook).Methods("POST")
-	r.HandleFunc("/books/{id}", bookHandler.DeleteBookByID).Methods("DELETE")
+	routes.handleFunc("GET", "/metrics", auth.PermMetricsRead, metricsHandler.GetMetrics)
+	routes.handleFunc("GET", "/healthz", auth.PermPublic, healthHandler.Liveness)
+	routes.handleFunc("GET", "/readyz", auth.PermPublic, healthHandler.Readiness)
 
-	return r
+	// Middleware goes on last, once the routes it checks its configuration against exist
+	r.Use(
+		middleware.RequestID,
+		middleware.Trace(tracer),
+		middleware.AccessLog(logger.With("component", "http")),
+		middleware.Metrics(requests),
+		newClientLimitMiddleware(cfg.RateLimit),
+		newAuthMiddleware(cfg.Auth, sessions, logger.With("component", "auth")),
+		newRateLimitMiddleware(cfg.RateLimit, r),
+		middleware.Authorize(policy, routes.permissionOf),
+	)
+	return &App{
+		Router:    r,
+		Scheduler: scheduler,
+		Logger:    logger,
+		Tracer:    tracer,
+		Health:    probes,
+		Storage:   blobs,
+	}
 }
\ No newline at end of file
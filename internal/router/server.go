@@ -0,0 +1,72 @@
+package router
+
+import (
+	"LibraryGo/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// flushTimeout bounds flushing spans at the end of shutdown, which runs even once the
+// shutdown deadline has passed
+const flushTimeout = 5 * time.Second
+
+// NewServer creates the HTTP server for the app, with the configured timeouts and
+// header limit. Errors of the server itself, such as TLS handshakes, go to the app's log.
+func (a *App) NewServer(cfg config.Config) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           a.Router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(a.Logger.With("component", "http").Handler(), slog.LevelWarn),
+	}
+}
+
+// Shutdown stops the app in order: readiness fails for drainDelay so load balancers
+// stop sending requests, the server stops listening and waits for the requests in
+// flight, background jobs are stopped, and finally spans are flushed. Once ctx is done
+// it closes the remaining connections and moves on, so every step gets its turn; the
+// errors of all steps are returned together.
+func (a *App) Shutdown(ctx context.Context, server *http.Server, drainDelay time.Duration) error {
+	a.Health.Drain()
+	a.Logger.Info("draining", "delay", drainDelay)
+	select {
+	case <-time.After(drainDelay):
+	case <-ctx.Done():
+	}
+
+	var errs []error
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		errs = append(errs, fmt.Errorf("requests did not finish: %w", err))
+	}
+	a.Logger.Info("server stopped")
+
+	stopped := make(chan struct{})
+	go func() {
+		a.Scheduler.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		a.Logger.Info("background jobs stopped")
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("background jobs did not stop: %w", ctx.Err()))
+	}
+
+	// Spans are flushed last, so the ones of the requests drained above are kept. The
+	// flush gets a deadline of its own, as ctx may be spent by now.
+	flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := a.Tracer.Shutdown(flushCtx); err != nil {
+		errs = append(errs, fmt.Errorf("flushing spans: %w", err))
+	}
+	return errors.Join(errs...)
+}
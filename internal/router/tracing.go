@@ -0,0 +1,19 @@
+package router
+
+import (
+	"LibraryGo/internal/config"
+	"LibraryGo/internal/tracing"
+	"log"
+	"log/slog"
+	"os"
+)
+
+// newTracer creates the tracer with the configured exporter; without one, trace context
+// is still propagated and logged but no spans are kept
+func newTracer(cfg config.TracingConfig, logger *slog.Logger) *tracing.Tracer {
+	exporter, err := tracing.NewExporter(cfg.Exporter, cfg.Endpoint, os.Stdout)
+	if err != nil {
+		log.Fatalf("tracing: %v", err)
+	}
+	return tracing.NewTracer(cfg.ServiceName, exporter, logger)
+}
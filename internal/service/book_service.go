@@ -1,43 +1,493 @@
 package service
 
 import (
+	"LibraryGo/internal/callnumber"
+	"LibraryGo/internal/isbn"
 	"LibraryGo/internal/model"
 	"LibraryGo/internal/repository"
+	"LibraryGo/internal/tracing"
+	"context"
 	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 )
 
+// MinSuggestPrefix is how many characters have to be typed before suggestions are offered
+const MinSuggestPrefix = 2
+
 // BookService provides business logic
 type BookService struct {
-	repo *repository.BookRepository
+	repo        *repository.BookRepository
+	copies      *repository.CopyRepository
+	branches    *BranchService
+	subjects    *SubjectService
+	reviews     *ReviewService
+	collections *CollectionService
+	covers      *CoverService
+	lending     *LendingService
+	logger      *slog.Logger
+
+	// mu serialises read-modify-write edits of a book's subjects and tags
+	mu sync.Mutex
 }
 
 // NewBookService initializes BookService
-func NewBookService(repo *repository.BookRepository) *BookService {
-	return &BookService{repo: repo}
+func NewBookService(repo *repository.BookRepository, copies *repository.CopyRepository, branches *BranchService,
+	subjects *SubjectService, reviews *ReviewService, collections *CollectionService, covers *CoverService,
+	lending *LendingService, logger *slog.Logger) *BookService {
+	return &BookService{repo: repo, copies: copies, branches: branches, subjects: subjects, reviews: reviews,
+		collections: collections, covers: covers, lending: lending, logger: logger}
 }
 
 // AddBook validates and adds a book
-func (s *BookService) AddBook(book model.Book) (model.Book, error) {
+func (s *BookService) AddBook(ctx context.Context, book model.Book) (model.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookService.AddBook")
+	defer span.End()
+
+	added, err := s.addBook(ctx, book)
+	if err != nil {
+		span.SetError(err)
+		s.logger.InfoContext(ctx, "book rejected", "title", book.Title, "error", err)
+		return model.Book{}, err
+	}
+	span.SetAttributes(tracing.Int("book.id", added.ID))
+	s.logger.InfoContext(ctx, "book added", "book_id", added.ID, "title", added.Title)
+	return added, nil
+}
+
+func (s *BookService) addBook(ctx context.Context, book model.Book) (model.Book, error) {
+	if book.Title == "" || book.Author == "" || book.PublishedYear <= 0 {
+		return model.Book{}, errors.New("invalid book data")
+	}
+	if book.CallNumber != "" {
+		parsed, err := callnumber.Parse(book.CallNumber)
+		if err != nil {
+			return model.Book{}, errors.New("invalid call number")
+		}
+		book.CallNumber = parsed.String()
+	}
+	if book.ISBN != "" {
+		normalized, err := isbn.Normalize(book.ISBN)
+		if err != nil {
+			return model.Book{}, err
+		}
+		book.ISBN = normalized
+	}
+	if err := s.subjects.Exists(book.SubjectIDs); err != nil {
+		return model.Book{}, err
+	}
+	book.Language = strings.ToLower(strings.TrimSpace(book.Language))
+	book.SubjectIDs = uniqueIDs(book.SubjectIDs)
+	book.Tags = normalizeTags(book.Tags)
+	book.Availability = nil
+	book.Rating = nil
+	book.Cover = nil
+
+	return s.repo.AddBook(ctx, book), nil
+}
+
+// GetBookByID retrieves a book by ID with its per-branch availability, rating and cover
+func (s *BookService) GetBookByID(ctx context.Context, id int) (model.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetBookByID", tracing.Int("book.id", id))
+	defer span.End()
+
+	book, err := s.repo.GetBookByID(ctx, id)
+	if err != nil {
+		span.SetError(err)
+		return model.Book{}, err
+	}
+	book.Availability = s.branches.Availability(book.ID)
+	book.Rating = s.reviews.Summary(book.ID)
+	book.Cover = s.covers.Cover(book.ID)
+	return book, nil
+}
+
+// DeleteBookByID deletes a book with its copies, reviews, cover and digital assets. Reading lists keep
+// the book as a removed entry.
+func (s *BookService) DeleteBookByID(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "BookService.DeleteBookByID", tracing.Int("book.id", id))
+	defer span.End()
+
+	book, err := s.repo.GetBookByID(ctx, id)
+	if err != nil {
+		span.SetError(err)
+		return err
+	}
+	if err := s.repo.DeleteBookByID(ctx, id); err != nil {
+		span.SetError(err)
+		return err
+	}
+	s.copies.DeleteCopiesOfBook(id)
+	s.reviews.DeleteReviewsOfBook(id)
+	s.covers.DeleteCoverOfBook(id)
+	s.lending.DeleteAssetsOfBook(id)
+	s.collections.BookDeleted(book)
+	s.logger.InfoContext(ctx, "book deleted", "book_id", id, "title", book.Title)
+	return nil
+}
+
+// GetBooks retrieves books matching the query with their per-branch availability, ratings and covers
+func (s *BookService) GetBooks(ctx context.Context, query model.BookQuery) ([]model.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetBooks")
+	defer span.End()
+
+	books, err := s.getBooks(ctx, query)
+	if err != nil {
+		span.SetError(err)
+		s.logger.DebugContext(ctx, "book search rejected", "error", err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.Int("books.count", len(books)))
+	return books, nil
+}
+
+func (s *BookService) getBooks(ctx context.Context, query model.BookQuery) ([]model.Book, error) {
+	books, err := s.repo.GetBooks(ctx, query.Author, query.StartYear, query.EndYear)
+	if err != nil {
+		return nil, err
+	}
+
+	var shelfRange *callnumber.Range
+	if query.CallRange != "" {
+		parsed, err := callnumber.ParseRange(query.CallRange)
+		if err != nil {
+			return nil, err
+		}
+		shelfRange = &parsed
+	}
+
+	subjectSets := make([]map[int]bool, 0, len(query.Subjects))
+	for _, id := range query.Subjects {
+		subjectSets = append(subjectSets, s.subjects.Descendants(id))
+	}
+	tags := normalizeTags(query.Tags)
+
+	decade := -1
+	if query.Decade != "" {
+		year, err := strconv.Atoi(query.Decade)
+		if err != nil {
+			return nil, errors.New("invalid decade format")
+		}
+		decade = decadeOf(year)
+	}
+
+	ratings := s.reviews.Summaries()
+
+	// Call numbers were validated when the books were added
+	callNumbers := make(map[int]callnumber.CallNumber)
+	filtered := books[:0]
+	for _, book := range books {
+		if query.BranchID != 0 && !s.branches.HasCopyAt(book.ID, query.BranchID) {
+			continue
+		}
+		if !hasSubjects(book, subjectSets) || !hasTags(book, tags) {
+			continue
+		}
+		if decade >= 0 && decadeOf(book.PublishedYear) != decade {
+			continue
+		}
+		if query.Language != "" && !strings.EqualFold(book.Language, query.Language) {
+			continue
+		}
+		if book.CallNumber != "" {
+			callNumbers[book.ID], _ = callnumber.Parse(book.CallNumber)
+		}
+		if shelfRange != nil {
+			parsed, ok := callNumbers[book.ID]
+			if !ok || !shelfRange.Contains(parsed) {
+				continue
+			}
+		}
+		book.Availability = s.branches.Availability(book.ID)
+		book.Rating = ratings[book.ID]
+		book.Cover = s.covers.Cover(book.ID)
+		filtered = append(filtered, book)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		if query.Sort == model.SortRating {
+			a, b := filtered[i].Rating, filtered[j].Rating
+			// Unrated books go last; among equal averages more reviews rank higher
+			if (a == nil) != (b == nil) {
+				return a != nil
+			}
+			if a != nil && a.Average != b.Average {
+				return a.Average > b.Average
+			}
+			if a != nil && a.Count != b.Count {
+				return a.Count > b.Count
+			}
+		}
+		if query.Sort == model.SortCallNumber {
+			a, hasA := callNumbers[filtered[i].ID]
+			b, hasB := callNumbers[filtered[j].ID]
+			// Books without a call number go after the shelved ones
+			if hasA != hasB {
+				return hasA
+			}
+			if c := callnumber.Compare(a, b); hasA && c != 0 {
+				return c < 0
+			}
+		}
+		return filtered[i].ID < filtered[j].ID
+	})
+	return filtered, nil
+}
+
+// Facets counts books per author, decade, language and subject.
+// A book counts towards a subject and every broader term above it, matching the subject filter.
+func (s *BookService) Facets(books []model.Book, query model.BookQuery) []model.Facet {
+	authors := make(map[string]int)
+	decades := make(map[string]int)
+	languages := make(map[string]int)
+	subjects := make(map[string]int)
+
+	for _, book := range books {
+		authors[book.Author]++
+		decades[strconv.Itoa(decadeOf(book.PublishedYear))]++
+		if book.Language != "" {
+			languages[book.Language]++
+		}
+		seen := make(map[int]bool)
+		for _, id := range book.SubjectIDs {
+			for _, term := range s.subjects.Ancestors(id) {
+				if !seen[term] {
+					seen[term] = true
+					subjects[strconv.Itoa(term)]++
+				}
+			}
+		}
+	}
+
+	selectedSubjects := make([]string, 0, len(query.Subjects))
+	for _, id := range query.Subjects {
+		selectedSubjects = append(selectedSubjects, strconv.Itoa(id))
+	}
+	var selectedDecade []string
+	if year, err := strconv.Atoi(query.Decade); err == nil {
+		selectedDecade = []string{strconv.Itoa(decadeOf(year))}
+	}
+
+	decadeFacet := facet(model.FacetDecade, decades, selectedDecade, func(value string) string { return value + "s" })
+	// Decades read better in time order than by count
+	sort.Slice(decadeFacet.Values, func(i, j int) bool {
+		a, _ := strconv.Atoi(decadeFacet.Values[i].Value)
+		b, _ := strconv.Atoi(decadeFacet.Values[j].Value)
+		return a < b
+	})
+
+	return []model.Facet{
+		facet(model.FacetAuthor, authors, []string{query.Author}, nil),
+		decadeFacet,
+		facet(model.FacetLanguage, languages, []string{strings.ToLower(query.Language)}, nil),
+		facet(model.FacetSubject, subjects, selectedSubjects, func(value string) string {
+			id, _ := strconv.Atoi(value)
+			if subject, err := s.subjects.GetSubjectByID(id); err == nil {
+				return subject.Name
+			}
+			return ""
+		}),
+	}
+}
+
+// Suggest completes a partial title or author for the search box.
+// Prefixes shorter than MinSuggestPrefix return nothing.
+func (s *BookService) Suggest(prefix, field string, limit int) []model.Suggestion {
+	if len([]rune(strings.TrimSpace(prefix))) < MinSuggestPrefix {
+		return []model.Suggestion{}
+	}
+	if limit <= 0 || limit > repository.MaxSuggestions {
+		limit = repository.MaxSuggestions
+	}
+	return s.repo.Suggest(field, prefix, limit)
+}
+
+// SetSubjects replaces the subjects assigned to a book
+func (s *BookService) SetSubjects(ctx context.Context, bookID int, subjectIDs []int) (model.Book, error) {
+	if err := s.subjects.Exists(subjectIDs); err != nil {
+		return model.Book{}, err
+	}
+	return s.edit(ctx, bookID, func(book *model.Book) {
+		book.SubjectIDs = uniqueIDs(subjectIDs)
+	})
+}
+
+// AddSubject assigns one more subject to a book
+func (s *BookService) AddSubject(ctx context.Context, bookID, subjectID int) (model.Book, error) {
+	if err := s.subjects.Exists([]int{subjectID}); err != nil {
+		return model.Book{}, err
+	}
+	return s.edit(ctx, bookID, func(book *model.Book) {
+		book.SubjectIDs = uniqueIDs(append(book.SubjectIDs, subjectID))
+	})
+}
+
+// RemoveSubject unassigns a subject from a book
+func (s *BookService) RemoveSubject(ctx context.Context, bookID, subjectID int) (model.Book, error) {
+	return s.edit(ctx, bookID, func(book *model.Book) {
+		var kept []int
+		for _, id := range book.SubjectIDs {
+			if id != subjectID {
+				kept = append(kept, id)
+			}
+		}
+		book.SubjectIDs = kept
+	})
+}
+
+// SetTags replaces the tags of a book
+func (s *BookService) SetTags(ctx context.Context, bookID int, tags []string) (model.Book, error) {
+	return s.edit(ctx, bookID, func(book *model.Book) {
+		book.Tags = normalizeTags(tags)
+	})
+}
+
+// AddTags adds tags to a book, keeping the ones it already has
+func (s *BookService) AddTags(ctx context.Context, bookID int, tags []string) (model.Book, error) {
+	return s.edit(ctx, bookID, func(book *model.Book) {
+		book.Tags = normalizeTags(append(book.Tags, tags...))
+	})
+}
+
+// RemoveTag removes a tag from a book
+func (s *BookService) RemoveTag(ctx context.Context, bookID int, tag string) (model.Book, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return s.edit(ctx, bookID, func(book *model.Book) {
+		var kept []string
+		for _, existing := range book.Tags {
+			if existing != tag {
+				kept = append(kept, existing)
+			}
+		}
+		book.Tags = kept
+	})
+}
+
+// edit applies a change to a stored book and returns it with its availability
+func (s *BookService) edit(ctx context.Context, bookID int, change func(book *model.Book)) (model.Book, error) {
+	return s.UpdateBook(ctx, bookID, func(book *model.Book) error {
+		change(book)
+		return nil
+	})
+}
+
+// UpdateBook applies a change to a stored book, unless the change returns an error, and returns
+// the book with its availability. The book is read and written under the same lock as subject
+// and tag edits, so the change sees the current state.
+func (s *BookService) UpdateBook(ctx context.Context, bookID int, change func(book *model.Book) error) (model.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	book, err := s.repo.GetBookByID(ctx, bookID)
+	if err != nil {
+		return model.Book{}, ErrBookNotFound
+	}
+	if err := change(&book); err != nil {
+		return model.Book{}, err
+	}
 	if book.Title == "" || book.Author == "" || book.PublishedYear <= 0 {
 		return model.Book{}, errors.New("invalid book data")
 	}
+	if err := s.repo.UpdateBook(ctx, book); err != nil {
+		return model.Book{}, ErrBookNotFound
+	}
+	s.logger.InfoContext(ctx, "book updated", "book_id", book.ID)
+	book.Availability = s.branches.Availability(book.ID)
+	book.Cover = s.covers.Cover(book.ID)
+	return book, nil
+}
 
-	return s.repo.AddBook(book), nil
+// facet builds a facet from value counts, most frequent first
+func facet(field string, counts map[string]int, selected []string, label func(string) string) model.Facet {
+	values := make([]model.FacetValue, 0, len(counts))
+	for value, count := range counts {
+		entry := model.FacetValue{Value: value, Count: count}
+		if label != nil {
+			entry.Label = label(value)
+		}
+		for _, chosen := range selected {
+			if chosen != "" && chosen == value {
+				entry.Selected = true
+			}
+		}
+		values = append(values, entry)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	return model.Facet{Field: field, Values: values}
 }
 
-// GetBookByID retrieves a book by ID
-func (s *BookService) GetBookByID(id int) (model.Book, error) {
-	return s.repo.GetBookByID(id)
+// decadeOf returns the first year of the decade a year falls in
+func decadeOf(year int) int {
+	return year - year%10
 }
 
-// DeleteBookByID deletes a book
-func (s *BookService) DeleteBookByID(id int) error {
-	return s.repo.DeleteBookByID(id)
+// hasSubjects reports whether a book falls under each of the subject sets
+func hasSubjects(book model.Book, subjectSets []map[int]bool) bool {
+	for _, set := range subjectSets {
+		matched := false
+		for _, id := range book.SubjectIDs {
+			if set[id] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
 }
 
-// GetBooksByAuthorAndYearRange retrieves books by author and published year range
-func (s *BookService) GetBooks(author, startYear, endYear string) ([]model.Book, error) {
-	return s.repo.GetBooks(author, startYear, endYear)
+// hasTags reports whether a book carries all of the tags
+func hasTags(book model.Book, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, existing := range book.Tags {
+			if existing == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
 }
 
+// uniqueIDs drops repeated IDs, keeping the first occurrence
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	var unique []int
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
 
+// normalizeTags trims and lower-cases tags and returns them sorted without repeats
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by BranchService
+var (
+	ErrCopyUnavailable    = errors.New("copy is not available for transfer")
+	ErrSameBranch         = errors.New("copy is already at the destination branch")
+	ErrTransferState      = errors.New("transfer cannot move to that state")
+	ErrCopyBarcodeMissing = errors.New("barcode is required")
+)
+
+// BranchService manages branches, the copies they hold and transfers between them
+type BranchService struct {
+	branches  *repository.BranchRepository
+	copies    *repository.CopyRepository
+	transfers *repository.TransferRepository
+	books     *repository.BookRepository
+	now       func() time.Time
+
+	// mu serialises changes to transfers. Copies are shared with circulation and
+	// stocktakes, so they are only changed with conditional updates.
+	mu sync.Mutex
+}
+
+// NewBranchService initializes BranchService
+func NewBranchService(branches *repository.BranchRepository, copies *repository.CopyRepository, transfers *repository.TransferRepository, books *repository.BookRepository) *BranchService {
+	return &BranchService{
+		branches:  branches,
+		copies:    copies,
+		transfers: transfers,
+		books:     books,
+		now:       time.Now,
+	}
+}
+
+// AddBranch validates and adds a branch
+func (s *BranchService) AddBranch(branch model.Branch) (model.Branch, error) {
+	branch.Code = strings.TrimSpace(branch.Code)
+	branch.Name = strings.TrimSpace(branch.Name)
+	if branch.Code == "" || branch.Name == "" {
+		return model.Branch{}, errors.New("branch code and name are required")
+	}
+	return s.branches.AddBranch(branch)
+}
+
+// GetBranchByID retrieves a branch by ID
+func (s *BranchService) GetBranchByID(id int) (model.Branch, error) {
+	return s.branches.GetBranchByID(id)
+}
+
+// GetAllBranches retrieves all branches
+func (s *BranchService) GetAllBranches() []model.Branch {
+	return s.branches.GetAllBranches()
+}
+
+// AddCopy registers a new copy of a book on a shelf of its home branch
+func (s *BranchService) AddCopy(bookID int, barcode string, homeBranchID int, shelf string) (model.Copy, error) {
+	barcode = strings.TrimSpace(barcode)
+	if barcode == "" {
+		return model.Copy{}, ErrCopyBarcodeMissing
+	}
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return model.Copy{}, ErrBookNotFound
+	}
+	if _, err := s.branches.GetBranchByID(homeBranchID); err != nil {
+		return model.Copy{}, err
+	}
+
+	return s.copies.AddCopy(model.Copy{
+		BookID:          bookID,
+		Barcode:         barcode,
+		HomeBranchID:    homeBranchID,
+		CurrentBranchID: homeBranchID,
+		Shelf:           strings.TrimSpace(shelf),
+		Status:          model.CopyStatusAvailable,
+	})
+}
+
+// GetCopies retrieves the copies of a book
+func (s *BranchService) GetCopies(bookID int) ([]model.Copy, error) {
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return nil, ErrBookNotFound
+	}
+	return s.copies.GetCopies(bookID, 0, ""), nil
+}
+
+// Availability counts the copies of a book per branch they are currently at
+func (s *BranchService) Availability(bookID int) []model.BranchAvailability {
+	byBranch := make(map[int]*model.BranchAvailability)
+	var order []int
+	for _, c := range s.copies.GetCopies(bookID, 0, "") {
+		if c.Status == model.CopyStatusLost {
+			continue
+		}
+		summary, ok := byBranch[c.CurrentBranchID]
+		if !ok {
+			summary = &model.BranchAvailability{BranchID: c.CurrentBranchID}
+			if branch, err := s.branches.GetBranchByID(c.CurrentBranchID); err == nil {
+				summary.BranchName = branch.Name
+			}
+			byBranch[c.CurrentBranchID] = summary
+			order = append(order, c.CurrentBranchID)
+		}
+
+		summary.Total++
+		switch c.Status {
+		case model.CopyStatusAvailable:
+			summary.Available++
+		case model.CopyStatusOnLoan:
+			summary.OnLoan++
+		case model.CopyStatusInTransit:
+			summary.InTransit++
+		}
+	}
+
+	availability := make([]model.BranchAvailability, 0, len(order))
+	for _, branchID := range order {
+		availability = append(availability, *byBranch[branchID])
+	}
+	return availability
+}
+
+// HasCopyAt reports whether any copy of a book is currently at the branch
+func (s *BranchService) HasCopyAt(bookID, branchID int) bool {
+	return len(s.copies.GetCopies(bookID, branchID, "")) > 0
+}
+
+// RequestTransfer asks for an available copy to be sent to another branch
+func (s *BranchService) RequestTransfer(copyID, toBranchID int) (model.Transfer, error) {
+	if _, err := s.branches.GetBranchByID(toBranchID); err != nil {
+		return model.Transfer{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.copies.GetCopyByID(copyID)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+	if item.Status != model.CopyStatusAvailable {
+		return model.Transfer{}, ErrCopyUnavailable
+	}
+	if item.CurrentBranchID == toBranchID {
+		return model.Transfer{}, ErrSameBranch
+	}
+	for _, transfer := range s.transfers.GetTransfers(copyID, 0, model.TransferStatusRequested) {
+		if transfer.ToBranchID == toBranchID {
+			return transfer, nil
+		}
+	}
+
+	return s.transfers.AddTransfer(model.Transfer{
+		CopyID:       copyID,
+		FromBranchID: item.CurrentBranchID,
+		ToBranchID:   toBranchID,
+		Status:       model.TransferStatusRequested,
+		RequestedAt:  s.now(),
+	}), nil
+}
+
+// ShipTransfer puts the copy in transit to the destination branch
+func (s *BranchService) ShipTransfer(id int) (model.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer, err := s.transferIn(id, model.TransferStatusRequested)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+
+	now := s.now()
+	transfer.Status = model.TransferStatusInTransit
+	transfer.ShippedAt = &now
+	err = s.moveCopy(transfer, model.CopyStatusAvailable, func(item *model.Copy) error {
+		if item.CurrentBranchID != transfer.FromBranchID {
+			return ErrCopyUnavailable
+		}
+		item.Status = model.CopyStatusInTransit
+		item.CurrentBranchID = transfer.ToBranchID
+		return nil
+	})
+	if errors.Is(err, repository.ErrCopyStatusChanged) {
+		return model.Transfer{}, ErrCopyUnavailable
+	}
+	return transfer, err
+}
+
+// ReceiveTransfer makes the copy available at the destination branch
+func (s *BranchService) ReceiveTransfer(id int) (model.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer, err := s.transferIn(id, model.TransferStatusInTransit)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+
+	now := s.now()
+	transfer.Status = model.TransferStatusReceived
+	transfer.ReceivedAt = &now
+	err = s.moveCopy(transfer, model.CopyStatusInTransit, func(item *model.Copy) error {
+		item.Status = model.CopyStatusAvailable
+		item.CurrentBranchID = transfer.ToBranchID
+		return nil
+	})
+	return transfer, err
+}
+
+// CancelTransfer withdraws a transfer; a copy already in transit is returned to its origin
+func (s *BranchService) CancelTransfer(id int) (model.Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer, err := s.transfers.GetTransferByID(id)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+	if transfer.Status != model.TransferStatusRequested && transfer.Status != model.TransferStatusInTransit {
+		return model.Transfer{}, ErrTransferState
+	}
+
+	wasInTransit := transfer.Status == model.TransferStatusInTransit
+	transfer.Status = model.TransferStatusCancelled
+	if !wasInTransit {
+		return transfer, s.transfers.UpdateTransfer(transfer)
+	}
+
+	err = s.moveCopy(transfer, model.CopyStatusInTransit, func(item *model.Copy) error {
+		item.Status = model.CopyStatusAvailable
+		item.CurrentBranchID = transfer.FromBranchID
+		return nil
+	})
+	return transfer, err
+}
+
+// GetTransferByID retrieves a transfer by ID
+func (s *BranchService) GetTransferByID(id int) (model.Transfer, error) {
+	return s.transfers.GetTransferByID(id)
+}
+
+// GetTransfers retrieves transfers filtered by branch and status
+func (s *BranchService) GetTransfers(branchID int, status string) []model.Transfer {
+	return s.transfers.GetTransfers(0, branchID, status)
+}
+
+// transferIn loads a transfer in the expected state; s.mu must be held
+func (s *BranchService) transferIn(id int, wantStatus string) (model.Transfer, error) {
+	transfer, err := s.transfers.GetTransferByID(id)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+	if transfer.Status != wantStatus {
+		return model.Transfer{}, ErrTransferState
+	}
+	return transfer, nil
+}
+
+// moveCopy changes the copy of a transfer if it is still in copyStatus, then stores the
+// transfer. A copy that has moved on, e.g. been checked out meanwhile, leaves the transfer
+// as it was and reports ErrCopyStatusChanged; s.mu must be held.
+func (s *BranchService) moveCopy(transfer model.Transfer, copyStatus string, change func(*model.Copy) error) error {
+	if _, err := s.copies.UpdateCopyIf(transfer.CopyID, copyStatus, change); err != nil {
+		return err
+	}
+	return s.transfers.UpdateTransfer(transfer)
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"LibraryGo/internal/epub"
+	"LibraryGo/internal/isbn"
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Book fields an import can propose changes to, by JSON name
+var proposalFields = []string{"title", "author", "publishedYear", "isbn", "language", "tags"}
+
+// Errors returned by CatalogService
+var (
+	ErrIncompleteMetadata = errors.New("EPUB metadata needs a title, a creator and a publication year to catalogue a new book")
+	ErrProposalResolved   = errors.New("proposal has already been approved or rejected")
+	ErrProposalStale      = errors.New("book has changed since the proposal was made; import the file again")
+	ErrUnknownField       = errors.New("proposal has no change to that field")
+	ErrFieldValue         = errors.New("proposed value does not fit the field")
+)
+
+// CatalogService catalogues e-books from the metadata of their files. A file for a new
+// title creates the book; a file for a known one proposes changes for staff to approve.
+type CatalogService struct {
+	books     *BookService
+	repo      *repository.BookRepository
+	proposals *repository.ProposalRepository
+	lending   *LendingService
+	now       func() time.Time
+
+	// mu serialises matching and creating books, and resolving proposals
+	mu sync.Mutex
+}
+
+// NewCatalogService initializes CatalogService
+func NewCatalogService(books *BookService, repo *repository.BookRepository, proposals *repository.ProposalRepository,
+	lending *LendingService) *CatalogService {
+	return &CatalogService{books: books, repo: repo, proposals: proposals, lending: lending, now: time.Now}
+}
+
+// SetClock replaces the clock, for tests
+func (s *CatalogService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// ImportEPUB reads the metadata of an EPUB and stores the file as a digital asset of its book.
+// The book is bookID if given, otherwise the one with the same ISBN, or else the same title
+// and author; when none matches, a book is created from the metadata.
+func (s *CatalogService) ImportEPUB(ctx context.Context, data []byte, fileName string, bookID int) (model.ImportResult, error) {
+	if len(data) > MaxAssetBytes {
+		return model.ImportResult{}, ErrAssetTooLarge
+	}
+	metadata, err := epub.ReadMetadata(data)
+	if err != nil {
+		return model.ImportResult{}, err
+	}
+	imported := fromMetadata(metadata)
+	result := model.ImportResult{Metadata: metadata}
+
+	s.mu.Lock()
+	book, found, err := s.match(ctx, imported, bookID)
+	if err != nil {
+		s.mu.Unlock()
+		return model.ImportResult{}, err
+	}
+	if !found {
+		if imported.Title == "" || imported.Author == "" || imported.PublishedYear <= 0 {
+			s.mu.Unlock()
+			return model.ImportResult{}, ErrIncompleteMetadata
+		}
+		book, err = s.books.AddBook(ctx, imported)
+		s.mu.Unlock()
+		if err != nil {
+			return model.ImportResult{}, err
+		}
+		result.Action = model.ImportCreated
+	} else {
+		s.mu.Unlock()
+		result.Action = model.ImportUnchanged
+		if changes := diff(book, imported); len(changes) > 0 {
+			proposal := s.proposals.AddProposal(model.MetadataProposal{
+				BookID:    book.ID,
+				Source:    fileName,
+				Changes:   changes,
+				Status:    model.ProposalStatusPending,
+				CreatedAt: s.now(),
+			})
+			result.Action = model.ImportProposed
+			result.Proposal = &proposal
+		}
+	}
+
+	asset, err := s.lending.AddAsset(book.ID, fileName, 0, bytes.NewReader(data))
+	if err != nil {
+		return model.ImportResult{}, err
+	}
+	result.Asset = &asset
+	result.Book, err = s.books.GetBookByID(ctx, book.ID)
+	if err != nil {
+		return model.ImportResult{}, ErrBookNotFound
+	}
+	return result, nil
+}
+
+// GetProposalByID retrieves a proposal by its ID
+func (s *CatalogService) GetProposalByID(id int) (model.MetadataProposal, error) {
+	return s.proposals.GetProposalByID(id)
+}
+
+// GetProposals retrieves proposals, optionally of one book or in one status
+func (s *CatalogService) GetProposals(bookID int, status string) []model.MetadataProposal {
+	return s.proposals.GetProposals(bookID, status)
+}
+
+// Approve applies the changes of a pending proposal to its book; fields picks some of them,
+// and none applies all. The book must still hold the values the proposal was made against.
+func (s *CatalogService) Approve(ctx context.Context, id int, fields []string) (model.MetadataProposal, model.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	proposal, err := s.pending(id)
+	if err != nil {
+		return model.MetadataProposal{}, model.Book{}, err
+	}
+	changes := proposal.Changes
+	if len(fields) > 0 {
+		changes = nil
+		for _, field := range fields {
+			change, ok := findChange(proposal.Changes, field)
+			if !ok {
+				return model.MetadataProposal{}, model.Book{}, ErrUnknownField
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	book, err := s.books.UpdateBook(ctx, proposal.BookID, func(book *model.Book) error {
+		for _, change := range changes {
+			if !reflect.DeepEqual(fieldValue(*book, change.Field), change.Current) {
+				return ErrProposalStale
+			}
+			if err := setField(book, change.Field, change.Proposed); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return model.MetadataProposal{}, model.Book{}, err
+	}
+
+	return s.resolve(proposal, model.ProposalStatusApproved), book, nil
+}
+
+// Reject closes a pending proposal without changing its book
+func (s *CatalogService) Reject(id int) (model.MetadataProposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	proposal, err := s.pending(id)
+	if err != nil {
+		return model.MetadataProposal{}, err
+	}
+	return s.resolve(proposal, model.ProposalStatusRejected), nil
+}
+
+// match finds the book a file belongs to
+func (s *CatalogService) match(ctx context.Context, imported model.Book, bookID int) (model.Book, bool, error) {
+	if bookID != 0 {
+		book, err := s.repo.GetBookByID(ctx, bookID)
+		if err != nil {
+			return model.Book{}, false, ErrBookNotFound
+		}
+		return book, true, nil
+	}
+
+	books := s.repo.GetAllBooks(ctx)
+	// Books catalogued by hand may carry the ISBN-10 of a title whose file has the ISBN-13
+	for _, book := range books {
+		if sameISBN(book.ISBN, imported.ISBN) {
+			return book, true, nil
+		}
+	}
+	for _, book := range books {
+		if imported.Title != "" && strings.EqualFold(book.Title, imported.Title) && strings.EqualFold(book.Author, imported.Author) {
+			return book, true, nil
+		}
+	}
+	return model.Book{}, false, nil
+}
+
+// pending retrieves a proposal that has not been resolved yet
+func (s *CatalogService) pending(id int) (model.MetadataProposal, error) {
+	proposal, err := s.proposals.GetProposalByID(id)
+	if err != nil {
+		return model.MetadataProposal{}, err
+	}
+	if proposal.Status != model.ProposalStatusPending {
+		return model.MetadataProposal{}, ErrProposalResolved
+	}
+	return proposal, nil
+}
+
+// resolve closes a proposal with the given status
+func (s *CatalogService) resolve(proposal model.MetadataProposal, status string) model.MetadataProposal {
+	now := s.now()
+	proposal.Status = status
+	proposal.ResolvedAt = &now
+	s.proposals.UpdateProposal(proposal)
+	return proposal
+}
+
+// fromMetadata maps e-book metadata onto a book
+func fromMetadata(metadata model.EbookMetadata) model.Book {
+	book := model.Book{
+		Title:    metadata.Title,
+		Author:   strings.Join(metadata.Creators, ", "),
+		Language: metadata.Language,
+		Tags:     normalizeTags(metadata.Subjects),
+	}
+	// Dates are W3CDTF, so "2021", "2021-03" and "2021-03-14" all start with the year
+	if len(metadata.Date) >= 4 {
+		if year, err := strconv.Atoi(metadata.Date[:4]); err == nil {
+			book.PublishedYear = year
+		}
+	}
+	for _, identifier := range metadata.Identifiers {
+		if identifier.Scheme != "ISBN" {
+			continue
+		}
+		if normalized, err := isbn.Normalize(identifier.Value); err == nil {
+			book.ISBN = normalized
+			break
+		}
+	}
+	return book
+}
+
+// diff lists the fields where the file has something the book lacks or has differently.
+// Values missing from the file are never proposed as removals, and tags are only added.
+func diff(book, imported model.Book) []model.FieldChange {
+	imported.Tags = normalizeTags(append(append([]string{}, book.Tags...), imported.Tags...))
+
+	var changes []model.FieldChange
+	for _, field := range proposalFields {
+		current, proposed := fieldValue(book, field), fieldValue(imported, field)
+		if reflect.ValueOf(proposed).IsZero() || reflect.DeepEqual(current, proposed) {
+			continue
+		}
+		if field == "isbn" && sameISBN(book.ISBN, imported.ISBN) {
+			continue
+		}
+		changes = append(changes, model.FieldChange{Field: field, Current: current, Proposed: proposed})
+	}
+	return changes
+}
+
+// sameISBN reports whether two ISBNs name the same book, whichever form each is in
+func sameISBN(a, b string) bool {
+	a13, err := isbn.ToISBN13(a)
+	if err != nil {
+		return false
+	}
+	b13, err := isbn.ToISBN13(b)
+	return err == nil && a13 == b13
+}
+
+// findChange looks up the change to a field
+func findChange(changes []model.FieldChange, field string) (model.FieldChange, bool) {
+	for _, change := range changes {
+		if change.Field == field {
+			return change, true
+		}
+	}
+	return model.FieldChange{}, false
+}
+
+// fieldValue reads a book field by its JSON name
+func fieldValue(book model.Book, field string) interface{} {
+	switch field {
+	case "title":
+		return book.Title
+	case "author":
+		return book.Author
+	case "publishedYear":
+		return book.PublishedYear
+	case "isbn":
+		return book.ISBN
+	case "language":
+		return book.Language
+	case "tags":
+		return book.Tags
+	}
+	return nil
+}
+
+// setField writes a book field by its JSON name. Values decoded from JSON are accepted
+// too: whole float64 numbers for the year and []interface{} of strings for tags.
+func setField(book *model.Book, field string, value interface{}) error {
+	ok := true
+	switch field {
+	case "title":
+		book.Title, ok = value.(string)
+	case "author":
+		book.Author, ok = value.(string)
+	case "publishedYear":
+		book.PublishedYear, ok = intValue(value)
+	case "isbn":
+		book.ISBN, ok = value.(string)
+	case "language":
+		book.Language, ok = value.(string)
+	case "tags":
+		book.Tags, ok = stringsValue(value)
+	default:
+		return ErrUnknownField
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrFieldValue, field)
+	}
+	return nil
+}
+
+// intValue reads an int, or a float64 holding a whole number
+func intValue(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// stringsValue reads a []string, or a []interface{} holding only strings
+func stringsValue(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	}
+	return nil, false
+}
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Default circulation periods
+const (
+	DefaultLoanPeriod   = 14 * 24 * time.Hour
+	DefaultPickupWindow = 7 * 24 * time.Hour
+)
+
+// Errors returned by CirculationService
+var (
+	ErrBookNotFound    = errors.New("book not found")
+	ErrBookOnLoan      = errors.New("book is already on loan")
+	ErrBookReserved    = errors.New("book is reserved for another patron")
+	ErrLoanReturned    = errors.New("loan has already been returned")
+	ErrHoldClosed      = errors.New("hold is no longer open")
+	ErrDuplicateHold   = errors.New("patron already has an open hold on this book")
+	ErrAlreadyBorrowed = errors.New("patron already has this book on loan")
+	ErrWrongBranch     = errors.New("no copy of the book is available at that branch")
+)
+
+// LoanObserver is told about every new loan, e.g. to learn borrowing patterns
+type LoanObserver interface {
+	LoanCreated(loan model.Loan)
+}
+
+// CirculationService provides loan and hold business logic
+type CirculationService struct {
+	loans   *repository.LoanRepository
+	holds   *repository.HoldRepository
+	books   *repository.BookRepository
+	patrons *repository.PatronRepository
+	copies  *repository.CopyRepository
+	now     func() time.Time
+
+	observers []LoanObserver
+
+	// mu serialises state transitions that span loans and holds
+	mu sync.Mutex
+
+	LoanPeriod   time.Duration
+	PickupWindow time.Duration
+}
+
+// NewCirculationService initializes CirculationService
+func NewCirculationService(loans *repository.LoanRepository, holds *repository.HoldRepository, books *repository.BookRepository,
+	patrons *repository.PatronRepository, copies *repository.CopyRepository) *CirculationService {
+	return &CirculationService{
+		loans:        loans,
+		holds:        holds,
+		books:        books,
+		patrons:      patrons,
+		copies:       copies,
+		now:          time.Now,
+		LoanPeriod:   DefaultLoanPeriod,
+		PickupWindow: DefaultPickupWindow,
+	}
+}
+
+// SetClock replaces the time source, for tests
+func (s *CirculationService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Observe registers an observer of new loans; it is called with s.mu held and must not call back
+func (s *CirculationService) Observe(observer LoanObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// Checkout lends a book to a patron.
+// When the book has copies, an available one is chosen, preferring branchID if it is set.
+func (s *CirculationService) Checkout(bookID, patronID, branchID int) (model.Loan, error) {
+	if _, err := s.patrons.GetPatronByID(patronID); err != nil {
+		return model.Loan{}, err
+	}
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return model.Loan{}, ErrBookNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	openLoans := s.openLoans(bookID)
+	for _, loan := range openLoans {
+		if loan.PatronID == patronID {
+			return model.Loan{}, ErrAlreadyBorrowed
+		}
+	}
+
+	// Ready holds of other patrons keep their set-aside copies off the shelf
+	var ownHold *model.Hold
+	readyHolds := s.holds.GetHolds(0, bookID, model.HoldStatusReady)
+	reserved := len(readyHolds)
+	for i := range readyHolds {
+		if readyHolds[i].PatronID == patronID {
+			ownHold = &readyHolds[i]
+			reserved--
+		}
+	}
+	units := s.availableUnits(bookID, openLoans)
+	if units-reserved <= 0 {
+		if units > 0 {
+			return model.Loan{}, ErrBookReserved
+		}
+		return model.Loan{}, ErrBookOnLoan
+	}
+
+	loan := model.Loan{
+		BookID:   bookID,
+		PatronID: patronID,
+		Status:   model.LoanStatusActive,
+	}
+	if copies := s.copies.GetCopies(bookID, 0, model.CopyStatusAvailable); len(copies) > 0 {
+		item, err := s.lendCopy(copies, branchID)
+		if err != nil {
+			return model.Loan{}, err
+		}
+		loan.CopyID = item.ID
+		loan.BranchID = item.CurrentBranchID
+	}
+
+	now := s.now()
+	if ownHold != nil {
+		ownHold.Status = model.HoldStatusFulfilled
+		ownHold.ClosedAt = &now
+		if err := s.holds.UpdateHold(*ownHold); err != nil {
+			return model.Loan{}, err
+		}
+	}
+
+	loan.CheckedOutAt = now
+	loan.DueDate = now.Add(s.LoanPeriod)
+	loan = s.loans.AddLoan(loan)
+	for _, observer := range s.observers {
+		observer.LoanCreated(loan)
+	}
+	return loan, nil
+}
+
+// Return checks a loaned book back in and sets it aside for the next hold in the queue.
+// A non-zero branchID records where the copy was returned.
+func (s *CirculationService) Return(loanID, branchID int) (model.Loan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loan, err := s.loans.GetLoanByID(loanID)
+	if err != nil {
+		return model.Loan{}, err
+	}
+	if !loan.IsOpen() {
+		return model.Loan{}, ErrLoanReturned
+	}
+
+	now := s.now()
+	loan.Status = model.LoanStatusReturned
+	loan.ReturnedAt = &now
+	if err := s.loans.UpdateLoan(loan); err != nil {
+		return model.Loan{}, err
+	}
+
+	if loan.CopyID != 0 {
+		// The copy is back on the shelf whatever was recorded while it was out
+		_, err := s.copies.UpdateCopyIf(loan.CopyID, "", func(item *model.Copy) error {
+			item.Status = model.CopyStatusAvailable
+			if branchID != 0 {
+				item.CurrentBranchID = branchID
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, repository.ErrCopyNotFound) {
+			return model.Loan{}, err
+		}
+	}
+
+	if err := s.promoteHolds(loan.BookID, now); err != nil {
+		return model.Loan{}, err
+	}
+	return loan, nil
+}
+
+// GetLoanByID retrieves a loan by ID
+func (s *CirculationService) GetLoanByID(id int) (model.Loan, error) {
+	return s.loans.GetLoanByID(id)
+}
+
+// GetLoans retrieves loans filtered by patron, book and status
+func (s *CirculationService) GetLoans(patronID, bookID int, status string) []model.Loan {
+	return s.loans.GetLoans(patronID, bookID, status)
+}
+
+// PlaceHold reserves a book for a patron; the hold is ready at once if the book is on the shelf
+func (s *CirculationService) PlaceHold(bookID, patronID int) (model.Hold, error) {
+	if _, err := s.patrons.GetPatronByID(patronID); err != nil {
+		return model.Hold{}, err
+	}
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return model.Hold{}, ErrBookNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	openLoans := s.openLoans(bookID)
+	for _, loan := range openLoans {
+		if loan.PatronID == patronID {
+			return model.Hold{}, ErrAlreadyBorrowed
+		}
+	}
+	for _, hold := range s.holds.GetHolds(patronID, bookID, "") {
+		if hold.IsOpen() {
+			return model.Hold{}, ErrDuplicateHold
+		}
+	}
+
+	hold := s.holds.AddHold(model.Hold{
+		BookID:   bookID,
+		PatronID: patronID,
+		Status:   model.HoldStatusPending,
+		PlacedAt: s.now(),
+	})
+
+	if err := s.promoteHolds(bookID, hold.PlacedAt); err != nil {
+		return model.Hold{}, err
+	}
+	return s.holds.GetHoldByID(hold.ID)
+}
+
+// CancelHold withdraws a hold and passes a set-aside book on to the next patron
+func (s *CirculationService) CancelHold(holdID int) (model.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hold, err := s.holds.GetHoldByID(holdID)
+	if err != nil {
+		return model.Hold{}, err
+	}
+	if !hold.IsOpen() {
+		return model.Hold{}, ErrHoldClosed
+	}
+
+	now := s.now()
+	wasReady := hold.Status == model.HoldStatusReady
+	hold.Status = model.HoldStatusCancelled
+	hold.ClosedAt = &now
+	if err := s.holds.UpdateHold(hold); err != nil {
+		return model.Hold{}, err
+	}
+
+	if wasReady {
+		if err := s.promoteHolds(hold.BookID, now); err != nil {
+			return model.Hold{}, err
+		}
+	}
+	return hold, nil
+}
+
+// GetHoldByID retrieves a hold by ID
+func (s *CirculationService) GetHoldByID(id int) (model.Hold, error) {
+	return s.holds.GetHoldByID(id)
+}
+
+// GetHolds retrieves holds filtered by patron, book and status
+func (s *CirculationService) GetHolds(patronID, bookID int, status string) []model.Hold {
+	return s.holds.GetHolds(patronID, bookID, status)
+}
+
+// MarkOverdueLoans flags active loans whose due date has passed and returns them
+func (s *CirculationService) MarkOverdueLoans(ctx context.Context) ([]model.Loan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var marked []model.Loan
+	for _, loan := range s.loans.GetLoans(0, 0, model.LoanStatusActive) {
+		if err := ctx.Err(); err != nil {
+			return marked, err
+		}
+		if !loan.DueDate.Before(now) {
+			continue
+		}
+		loan.Status = model.LoanStatusOverdue
+		if err := s.loans.UpdateLoan(loan); err != nil {
+			return marked, err
+		}
+		marked = append(marked, loan)
+	}
+	return marked, nil
+}
+
+// ExpireHolds closes ready holds whose pickup window has passed and returns them
+func (s *CirculationService) ExpireHolds(ctx context.Context) ([]model.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var expired []model.Hold
+	for _, hold := range s.holds.GetHolds(0, 0, model.HoldStatusReady) {
+		if err := ctx.Err(); err != nil {
+			return expired, err
+		}
+		if hold.ExpiresAt == nil || !hold.ExpiresAt.Before(now) {
+			continue
+		}
+		hold.Status = model.HoldStatusExpired
+		hold.ClosedAt = &now
+		if err := s.holds.UpdateHold(hold); err != nil {
+			return expired, err
+		}
+		expired = append(expired, hold)
+
+		if err := s.promoteHolds(hold.BookID, now); err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// Compact drops returned loans and closed holds older than the retention period
+func (s *CirculationService) Compact(ctx context.Context, retention time.Duration) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	cutoff := s.now().Add(-retention)
+	return s.loans.PurgeReturned(cutoff) + s.holds.PurgeClosed(cutoff), nil
+}
+
+// openLoans returns the loans of a book that have not been returned; s.mu must be held
+func (s *CirculationService) openLoans(bookID int) []model.Loan {
+	var open []model.Loan
+	for _, loan := range s.loans.GetLoans(0, bookID, "") {
+		if loan.IsOpen() {
+			open = append(open, loan)
+		}
+	}
+	return open
+}
+
+// availableUnits counts how many more loans of a book the shelf could serve, ignoring holds.
+// A book without registered copies is treated as a single copy. s.mu must be held.
+func (s *CirculationService) availableUnits(bookID int, openLoans []model.Loan) int {
+	if len(s.copies.GetCopies(bookID, 0, "")) == 0 {
+		return 1 - len(openLoans)
+	}
+	return len(s.copies.GetCopies(bookID, 0, model.CopyStatusAvailable))
+}
+
+// promoteHolds sets copies aside for the oldest pending holds while unreserved copies remain;
+// s.mu must be held
+func (s *CirculationService) promoteHolds(bookID int, now time.Time) error {
+	free := s.availableUnits(bookID, s.openLoans(bookID)) - len(s.holds.GetHolds(0, bookID, model.HoldStatusReady))
+	pending := s.holds.GetHolds(0, bookID, model.HoldStatusPending)
+
+	for i := 0; i < free && i < len(pending); i++ {
+		next := pending[i]
+		expires := now.Add(s.PickupWindow)
+		next.Status = model.HoldStatusReady
+		next.ReadyAt = &now
+		next.ExpiresAt = &expires
+		if err := s.holds.UpdateHold(next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lendCopy puts one of the available copies on loan, at branchID when it is set. Copies
+// that stopped being available since they were listed, e.g. shipped to another branch,
+// are passed over.
+func (s *CirculationService) lendCopy(available []model.Copy, branchID int) (model.Copy, error) {
+	for len(available) > 0 {
+		item, err := pickCopy(available, branchID)
+		if err != nil {
+			return model.Copy{}, err
+		}
+		lent, err := s.copies.UpdateCopyIf(item.ID, model.CopyStatusAvailable, func(item *model.Copy) error {
+			item.Status = model.CopyStatusOnLoan
+			return nil
+		})
+		if !errors.Is(err, repository.ErrCopyStatusChanged) {
+			return lent, err
+		}
+		for i := range available {
+			if available[i].ID == item.ID {
+				available = append(available[:i:i], available[i+1:]...)
+				break
+			}
+		}
+	}
+	return model.Copy{}, ErrBookOnLoan
+}
+
+// pickCopy chooses the copy to lend, requiring one at branchID when it is set
+func pickCopy(available []model.Copy, branchID int) (model.Copy, error) {
+	if branchID == 0 {
+		return available[0], nil
+	}
+	for _, item := range available {
+		if item.CurrentBranchID == branchID {
+			return item, nil
+		}
+	}
+	return model.Copy{}, ErrWrongBranch
+}
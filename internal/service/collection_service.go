@@ -0,0 +1,336 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxCollectionEntries limits how many books a reading list holds
+const MaxCollectionEntries = 500
+
+// Errors returned by CollectionService
+var (
+	ErrCollectionTitle   = errors.New("collection title is required")
+	ErrInvalidVisibility = errors.New("visibility must be private or public")
+	ErrCollectionFull    = errors.New("collection has too many entries")
+	ErrDuplicateEntry    = errors.New("book is already on the list")
+	ErrEntryNotFound     = errors.New("book is not on the list")
+	ErrInvalidPosition   = errors.New("position is outside the list")
+	ErrInvalidOrder      = errors.New("order must name every book on the list exactly once")
+	ErrNotListOwner      = errors.New("only the owner of the list or staff can change it")
+)
+
+// CollectionService manages reading lists curated by patrons. Lists are read and
+// changed on behalf of an actor: a patron acts on their own lists, staff on anyone's.
+type CollectionService struct {
+	collections *repository.CollectionRepository
+	books       *repository.BookRepository
+	patrons     *repository.PatronRepository
+	now         func() time.Time
+
+	// mu serialises edits so concurrent changes to a list are not lost
+	mu sync.Mutex
+}
+
+// NewCollectionService initializes CollectionService
+func NewCollectionService(collections *repository.CollectionRepository, books *repository.BookRepository, patrons *repository.PatronRepository) *CollectionService {
+	return &CollectionService{
+		collections: collections,
+		books:       books,
+		patrons:     patrons,
+		now:         time.Now,
+	}
+}
+
+// AddCollection creates a list, private unless stated otherwise, optionally with its first entries.
+// A patron's list is their own; staff may create one for any patron.
+func (s *CollectionService) AddCollection(collection model.Collection, actor model.Actor) (model.Collection, error) {
+	if !actor.Staff || collection.OwnerID == 0 {
+		collection.OwnerID = actor.PatronID
+	}
+	if !actor.Owns(collection.OwnerID) {
+		return model.Collection{}, ErrNotListOwner
+	}
+	collection.Title = strings.TrimSpace(collection.Title)
+	if collection.Title == "" {
+		return model.Collection{}, ErrCollectionTitle
+	}
+	if collection.Visibility == "" {
+		collection.Visibility = model.VisibilityPrivate
+	}
+	if !validVisibility(collection.Visibility) {
+		return model.Collection{}, ErrInvalidVisibility
+	}
+	if _, err := s.patrons.GetPatronByID(collection.OwnerID); err != nil {
+		return model.Collection{}, err
+	}
+
+	entries := collection.Entries
+	collection.Entries = []model.CollectionEntry{}
+	for _, entry := range entries {
+		if err := s.insert(&collection, entry, 0); err != nil {
+			return model.Collection{}, err
+		}
+	}
+
+	collection.ShareToken = ""
+	collection.CreatedAt = s.now()
+	collection.UpdatedAt = collection.CreatedAt
+	return s.resolve(s.collections.AddCollection(collection)), nil
+}
+
+// GetCollectionByID retrieves a list the actor may see: a public one, or one they own.
+// Private lists of other patrons are reported as not found.
+func (s *CollectionService) GetCollectionByID(id int, actor model.Actor) (model.Collection, error) {
+	collection, err := s.visible(id, actor)
+	if err != nil {
+		return model.Collection{}, err
+	}
+	return s.resolve(collection), nil
+}
+
+// GetSharedCollection retrieves a list through its share link, whatever its visibility
+func (s *CollectionService) GetSharedCollection(token string) (model.Collection, error) {
+	collection, err := s.collections.GetCollectionByShareToken(token)
+	if err != nil {
+		return model.Collection{}, err
+	}
+	return s.resolve(collection), nil
+}
+
+// GetCollections lists the public lists, optionally of one owner. The actor also
+// sees the private lists they own.
+func (s *CollectionService) GetCollections(ownerID int, actor model.Actor) []model.Collection {
+	collections := []model.Collection{}
+	for _, collection := range s.collections.GetCollections(ownerID, "") {
+		if collection.Visibility == model.VisibilityPublic || actor.Owns(collection.OwnerID) {
+			collections = append(collections, s.resolve(collection))
+		}
+	}
+	return collections
+}
+
+// UpdateCollection changes the title, description and visibility of a list
+func (s *CollectionService) UpdateCollection(id int, changes model.Collection, actor model.Actor) (model.Collection, error) {
+	changes.Title = strings.TrimSpace(changes.Title)
+	if changes.Title == "" {
+		return model.Collection{}, ErrCollectionTitle
+	}
+	if changes.Visibility != "" && !validVisibility(changes.Visibility) {
+		return model.Collection{}, ErrInvalidVisibility
+	}
+
+	return s.edit(id, actor, func(collection *model.Collection) error {
+		collection.Title = changes.Title
+		collection.Description = changes.Description
+		if changes.Visibility != "" {
+			collection.Visibility = changes.Visibility
+		}
+		return nil
+	})
+}
+
+// DeleteCollectionByID deletes a list
+func (s *CollectionService) DeleteCollectionByID(id int, actor model.Actor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.owned(id, actor); err != nil {
+		return err
+	}
+	return s.collections.DeleteCollectionByID(id)
+}
+
+// AddEntry puts a book on a list at a 1-based position, or at the end when position is 0
+func (s *CollectionService) AddEntry(id int, entry model.CollectionEntry, position int, actor model.Actor) (model.Collection, error) {
+	return s.edit(id, actor, func(collection *model.Collection) error {
+		return s.insert(collection, entry, position)
+	})
+}
+
+// UpdateEntry changes the note on a book on a list
+func (s *CollectionService) UpdateEntry(id, bookID int, note string, actor model.Actor) (model.Collection, error) {
+	return s.edit(id, actor, func(collection *model.Collection) error {
+		i := entryIndex(collection.Entries, bookID)
+		if i < 0 {
+			return ErrEntryNotFound
+		}
+		collection.Entries[i].Note = strings.TrimSpace(note)
+		return nil
+	})
+}
+
+// RemoveEntry takes a book off a list
+func (s *CollectionService) RemoveEntry(id, bookID int, actor model.Actor) (model.Collection, error) {
+	return s.edit(id, actor, func(collection *model.Collection) error {
+		i := entryIndex(collection.Entries, bookID)
+		if i < 0 {
+			return ErrEntryNotFound
+		}
+		collection.Entries = append(collection.Entries[:i], collection.Entries[i+1:]...)
+		return nil
+	})
+}
+
+// Reorder arranges a list in the given order of book IDs, which must name every entry once
+func (s *CollectionService) Reorder(id int, bookIDs []int, actor model.Actor) (model.Collection, error) {
+	return s.edit(id, actor, func(collection *model.Collection) error {
+		if len(bookIDs) != len(collection.Entries) {
+			return ErrInvalidOrder
+		}
+		ordered := make([]model.CollectionEntry, 0, len(bookIDs))
+		used := make(map[int]bool, len(bookIDs))
+		for _, bookID := range bookIDs {
+			i := entryIndex(collection.Entries, bookID)
+			if i < 0 || used[bookID] {
+				return ErrInvalidOrder
+			}
+			used[bookID] = true
+			ordered = append(ordered, collection.Entries[i])
+		}
+		collection.Entries = ordered
+		return nil
+	})
+}
+
+// Share creates a link token that lets anyone read the list, keeping the existing one
+func (s *CollectionService) Share(id int, actor model.Actor) (model.Collection, error) {
+	return s.edit(id, actor, func(collection *model.Collection) error {
+		if collection.ShareToken != "" {
+			return nil
+		}
+		token, err := shareToken()
+		if err != nil {
+			return err
+		}
+		collection.ShareToken = token
+		return nil
+	})
+}
+
+// Unshare revokes the share link of a list
+func (s *CollectionService) Unshare(id int, actor model.Actor) (model.Collection, error) {
+	return s.edit(id, actor, func(collection *model.Collection) error {
+		collection.ShareToken = ""
+		return nil
+	})
+}
+
+// BookDeleted keeps the entries of a deleted book on every list, marked as removed
+func (s *CollectionService) BookDeleted(book model.Book) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.collections.MarkBookRemoved(book.ID, book.Title)
+}
+
+// edit applies a change to a stored list the actor owns and saves it
+func (s *CollectionService) edit(id int, actor model.Actor, change func(*model.Collection) error) (model.Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, err := s.owned(id, actor)
+	if err != nil {
+		return model.Collection{}, err
+	}
+	if err := change(&collection); err != nil {
+		return model.Collection{}, err
+	}
+	collection.UpdatedAt = s.now()
+	if err := s.collections.UpdateCollection(collection); err != nil {
+		return model.Collection{}, err
+	}
+	return s.resolve(collection), nil
+}
+
+// visible retrieves a list the actor may read
+func (s *CollectionService) visible(id int, actor model.Actor) (model.Collection, error) {
+	collection, err := s.collections.GetCollectionByID(id)
+	if err != nil {
+		return model.Collection{}, err
+	}
+	if collection.Visibility != model.VisibilityPublic && !actor.Owns(collection.OwnerID) {
+		return model.Collection{}, repository.ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+// owned retrieves a list the actor may change. Lists the actor cannot even see are
+// reported as not found, so their existence is not given away.
+func (s *CollectionService) owned(id int, actor model.Actor) (model.Collection, error) {
+	collection, err := s.visible(id, actor)
+	if err != nil {
+		return model.Collection{}, err
+	}
+	if !actor.Owns(collection.OwnerID) {
+		return model.Collection{}, ErrNotListOwner
+	}
+	return collection, nil
+}
+
+// insert validates an entry and adds it to a list
+func (s *CollectionService) insert(collection *model.Collection, entry model.CollectionEntry, position int) error {
+	if _, err := s.books.GetBookByID(context.Background(), entry.BookID); err != nil {
+		return ErrBookNotFound
+	}
+	if entryIndex(collection.Entries, entry.BookID) >= 0 {
+		return ErrDuplicateEntry
+	}
+	if len(collection.Entries) >= MaxCollectionEntries {
+		return ErrCollectionFull
+	}
+	if position < 0 || position > len(collection.Entries)+1 {
+		return ErrInvalidPosition
+	}
+	if position == 0 {
+		position = len(collection.Entries) + 1
+	}
+
+	entry = model.CollectionEntry{BookID: entry.BookID, Note: strings.TrimSpace(entry.Note)}
+	collection.Entries = append(collection.Entries, model.CollectionEntry{})
+	copy(collection.Entries[position:], collection.Entries[position-1:])
+	collection.Entries[position-1] = entry
+	return nil
+}
+
+// resolve fills in the books of a list's entries
+func (s *CollectionService) resolve(collection model.Collection) model.Collection {
+	for i, entry := range collection.Entries {
+		if entry.Removed {
+			continue
+		}
+		if book, err := s.books.GetBookByID(context.Background(), entry.BookID); err == nil {
+			collection.Entries[i].Book = &book
+		}
+	}
+	return collection
+}
+
+func entryIndex(entries []model.CollectionEntry, bookID int) int {
+	for i, entry := range entries {
+		if entry.BookID == bookID {
+			return i
+		}
+	}
+	return -1
+}
+
+func validVisibility(visibility string) bool {
+	return visibility == model.VisibilityPrivate || visibility == model.VisibilityPublic
+}
+
+// shareToken generates an unguessable token for share links
+func shareToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
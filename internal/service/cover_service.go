@@ -0,0 +1,246 @@
+package service
+
+import (
+	"LibraryGo/internal/blob"
+	"LibraryGo/internal/imaging"
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cover upload limits
+const (
+	MaxCoverBytes     = 5 << 20    // 5 MiB
+	MaxCoverDimension = 8000       // Pixels per side, to refuse decompression bombs
+	MaxCoverPixels    = 40_000_000 // Pixels in all, as decoding and scaling hold up to 4 bytes of each twice
+	MaxCoverDecodes   = 2          // Uploads decoded at once, each holding up to 8 bytes per pixel
+)
+
+// CoverWidths are the widths of the generated thumbnails by size name
+var CoverWidths = map[string]int{
+	model.CoverSizeSmall:  120,
+	model.CoverSizeMedium: 300,
+	model.CoverSizeLarge:  600,
+}
+
+// coverTypes are the accepted image formats by sniffed content type
+var coverTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Errors returned by CoverService
+var (
+	ErrCoverTooLarge    = errors.New("cover image exceeds 5 MiB")
+	ErrUnsupportedImage = errors.New("cover must be a JPEG, PNG or GIF image")
+	ErrCoverDimensions  = errors.New("cover image is too large in pixels")
+	ErrUnknownCoverSize = errors.New("unknown cover size")
+)
+
+// CoverService stores book cover images and their thumbnails in blob storage
+type CoverService struct {
+	covers *repository.CoverRepository
+	books  *repository.BookRepository
+	store  blob.Store
+	now    func() time.Time
+
+	// decoding holds a slot per upload being decoded and scaled, bounding their memory
+	decoding chan struct{}
+	// mu keeps an upload and its thumbnails from interleaving with another upload
+	mu sync.Mutex
+}
+
+// NewCoverService initializes CoverService
+func NewCoverService(covers *repository.CoverRepository, books *repository.BookRepository, store blob.Store) *CoverService {
+	return &CoverService{
+		covers:   covers,
+		books:    books,
+		store:    store,
+		now:      time.Now,
+		decoding: make(chan struct{}, MaxCoverDecodes),
+	}
+}
+
+// Upload replaces the cover of a book. The format is sniffed from the data,
+// whatever the upload claimed, and thumbnails are generated in every size.
+func (s *CoverService) Upload(bookID int, data []byte) (model.Cover, error) {
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return model.Cover{}, ErrBookNotFound
+	}
+	if len(data) > MaxCoverBytes {
+		return model.Cover{}, ErrCoverTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	if !coverTypes[contentType] {
+		return model.Cover{}, ErrUnsupportedImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return model.Cover{}, ErrUnsupportedImage
+	}
+	if config.Width > MaxCoverDimension || config.Height > MaxCoverDimension || config.Width*config.Height > MaxCoverPixels {
+		return model.Cover{}, ErrCoverDimensions
+	}
+
+	s.decoding <- struct{}{}
+	defer func() { <-s.decoding }()
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return model.Cover{}, ErrUnsupportedImage
+	}
+
+	// Converted for scaling once, rather than once per size
+	src := imaging.RGBA(img)
+	thumbnails := make(map[string][]byte, len(CoverWidths))
+	for size, width := range CoverWidths {
+		encoded, err := encodeThumbnail(imaging.Thumbnail(src, width), contentType)
+		if err != nil {
+			return model.Cover{}, err
+		}
+		thumbnails[size] = encoded
+	}
+
+	sum := sha256.Sum256(data)
+	cover := model.Cover{
+		BookID:      bookID,
+		ContentType: contentType,
+		Size:        len(data),
+		Width:       config.Width,
+		Height:      config.Height,
+		ETag:        hex.EncodeToString(sum[:8]),
+		UpdatedAt:   s.now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.Put(coverKey(bookID, model.CoverSizeOriginal), data); err != nil {
+		return model.Cover{}, err
+	}
+	for size, encoded := range thumbnails {
+		if err := s.store.Put(coverKey(bookID, size), encoded); err != nil {
+			return model.Cover{}, err
+		}
+	}
+	s.covers.SaveCover(cover)
+	return withCoverURLs(cover), nil
+}
+
+// Cover returns the cover of a book with its URLs, or nil when it has none
+func (s *CoverService) Cover(bookID int) *model.Cover {
+	cover, err := s.covers.GetCover(bookID)
+	if err != nil {
+		return nil
+	}
+	cover = withCoverURLs(cover)
+	return &cover
+}
+
+// Image returns a book's cover in one size with its content type
+func (s *CoverService) Image(bookID int, size string) (model.Cover, []byte, string, error) {
+	if size == "" {
+		size = model.CoverSizeOriginal
+	}
+	if _, known := CoverWidths[size]; !known && size != model.CoverSizeOriginal {
+		return model.Cover{}, nil, "", ErrUnknownCoverSize
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cover, err := s.covers.GetCover(bookID)
+	if err != nil {
+		return model.Cover{}, nil, "", err
+	}
+	data, err := s.store.Get(coverKey(bookID, size))
+	if errors.Is(err, blob.ErrNotFound) {
+		return model.Cover{}, nil, "", repository.ErrCoverNotFound
+	}
+	if err != nil {
+		return model.Cover{}, nil, "", err
+	}
+
+	contentType := cover.ContentType
+	if size != model.CoverSizeOriginal {
+		contentType = thumbnailType(cover.ContentType)
+	}
+	return withCoverURLs(cover), data, contentType, nil
+}
+
+// DeleteCover removes the cover of a book and its thumbnails
+func (s *CoverService) DeleteCover(bookID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.covers.DeleteCover(bookID); err != nil {
+		return err
+	}
+	for _, size := range coverSizes() {
+		if err := s.store.Delete(coverKey(bookID, size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteCoverOfBook removes the cover of a deleted book, if it had one
+func (s *CoverService) DeleteCoverOfBook(bookID int) {
+	// The book is gone either way; a blob left behind only wastes space
+	_ = s.DeleteCover(bookID)
+}
+
+// withCoverURLs fills in the image URLs. The ETag in them changes with the
+// image, so clients may cache a URL for as long as they like.
+func withCoverURLs(cover model.Cover) model.Cover {
+	base := fmt.Sprintf("/books/%d/cover", cover.BookID)
+	cover.URLs = map[string]string{model.CoverSizeOriginal: base + "?v=" + cover.ETag}
+	for size := range CoverWidths {
+		cover.URLs[size] = base + "?size=" + size + "&v=" + cover.ETag
+	}
+	return cover
+}
+
+func coverSizes() []string {
+	sizes := []string{model.CoverSizeOriginal}
+	for size := range CoverWidths {
+		sizes = append(sizes, size)
+	}
+	return sizes
+}
+
+func coverKey(bookID int, size string) string {
+	return fmt.Sprintf("covers/%d/%s", bookID, size)
+}
+
+// thumbnailType is PNG for PNG originals, to keep transparency, and JPEG otherwise
+func thumbnailType(contentType string) string {
+	if contentType == "image/png" {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+func encodeThumbnail(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if thumbnailType(contentType) == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	return buf.Bytes(), err
+}
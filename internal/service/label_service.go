@@ -0,0 +1,145 @@
+package service
+
+import (
+	"LibraryGo/internal/barcode"
+	"LibraryGo/internal/labels"
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+)
+
+// Barcode image defaults, in pixels
+const (
+	DefaultBarcodeModule = 3
+	MaxBarcodeModule     = 20
+	DefaultBarHeight     = 80
+	MaxLabelsPerSheet    = 2000 // Labels per request, over all pages
+)
+
+// Errors returned by LabelService
+var (
+	ErrUnknownStock       = errors.New("unknown label stock")
+	ErrUnknownSymbology   = errors.New("symbology must be code128 or qr")
+	ErrUnknownImageFormat = errors.New("format must be png or svg")
+	ErrInvalidModule      = errors.New("scale must be between 1 and 20")
+	ErrNoLabels           = errors.New("no copies selected for labels")
+	ErrTooManyLabels      = errors.New("too many labels for one request")
+	ErrInvalidSkip        = errors.New("skip must be less than the labels on a sheet")
+)
+
+// LabelService renders barcodes and label sheets for copies and books
+type LabelService struct {
+	copies *repository.CopyRepository
+	books  *repository.BookRepository
+}
+
+// NewLabelService initializes LabelService
+func NewLabelService(copies *repository.CopyRepository, books *repository.BookRepository) *LabelService {
+	return &LabelService{copies: copies, books: books}
+}
+
+// CopyBarcode renders the barcode of a copy; see Barcode for the options
+func (s *LabelService) CopyBarcode(copyID int, symbology, format string, module int) ([]byte, string, error) {
+	item, err := s.copies.GetCopyByID(copyID)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.Barcode(item.Barcode, symbology, format, module)
+}
+
+// BookBarcode renders the ID of a book as a barcode; see Barcode for the options
+func (s *LabelService) BookBarcode(bookID int, symbology, format string, module int) ([]byte, string, error) {
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return nil, "", ErrBookNotFound
+	}
+	return s.Barcode(strconv.Itoa(bookID), symbology, format, module)
+}
+
+// Barcode renders data as a Code 128 or QR symbol in PNG or SVG with module pixels per module,
+// and returns it with its content type. Zero values pick Code 128, PNG and the default module.
+func (s *LabelService) Barcode(data, symbology, format string, module int) ([]byte, string, error) {
+	if symbology == "" {
+		symbology = barcode.Code128
+	}
+	if symbology != barcode.Code128 && symbology != barcode.QR {
+		return nil, "", ErrUnknownSymbology
+	}
+	if module == 0 {
+		module = DefaultBarcodeModule
+	}
+	if module < 1 || module > MaxBarcodeModule {
+		return nil, "", ErrInvalidModule
+	}
+
+	symbol, err := barcode.Encode(symbology, data)
+	if err != nil {
+		return nil, "", err
+	}
+	barHeight := DefaultBarHeight * module / DefaultBarcodeModule
+	switch format {
+	case "", model.LabelFormatPNG:
+		image, err := symbol.PNG(module, barHeight)
+		return image, "image/png", err
+	case model.LabelFormatSVG:
+		return symbol.SVG(module, barHeight), "image/svg+xml", nil
+	}
+	return nil, "", ErrUnknownImageFormat
+}
+
+// Stocks lists the label stocks sheets can be printed on
+func (s *LabelService) Stocks() []model.LabelStock {
+	return labels.Stocks()
+}
+
+// Sheet renders a PDF of labels for the requested copies, followed by every copy of the
+// requested books, each printed once in the order asked for
+func (s *LabelService) Sheet(req model.LabelSheetRequest) ([]byte, error) {
+	if req.Stock == "" {
+		req.Stock = labels.DefaultStock
+	}
+	stock, ok := labels.Stock(req.Stock)
+	if !ok {
+		return nil, ErrUnknownStock
+	}
+	if req.Skip < 0 || req.Skip >= stock.Columns*stock.Rows {
+		return nil, ErrInvalidSkip
+	}
+
+	var items []model.Copy
+	for _, id := range req.CopyIDs {
+		item, err := s.copies.GetCopyByID(id)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	for _, bookID := range req.BookIDs {
+		if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+			return nil, ErrBookNotFound
+		}
+		items = append(items, s.copies.GetCopies(bookID, 0, "")...)
+	}
+
+	seen := make(map[int]bool)
+	var sheet []model.Label
+	for _, item := range items {
+		if seen[item.ID] {
+			continue
+		}
+		seen[item.ID] = true
+		book, err := s.books.GetBookByID(context.Background(), item.BookID)
+		if err != nil {
+			return nil, ErrBookNotFound
+		}
+		sheet = append(sheet, model.Label{CopyID: item.ID, Title: book.Title, CallNumber: book.CallNumber, Barcode: item.Barcode})
+	}
+	if len(sheet) == 0 {
+		return nil, ErrNoLabels
+	}
+	if len(sheet) > MaxLabelsPerSheet {
+		return nil, ErrTooManyLabels
+	}
+	return labels.Sheet(stock, sheet, req.Skip)
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"LibraryGo/internal/blob"
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Digital lending defaults
+const (
+	MaxAssetBytes       = 100 << 20 // 100 MiB
+	DefaultDownloadTTL  = time.Hour
+	DefaultAssetLicense = 1
+)
+
+// Errors returned by LendingService
+var (
+	ErrAssetTooLarge       = errors.New("file exceeds 100 MiB")
+	ErrUnsupportedAsset    = errors.New("file must be an EPUB or PDF")
+	ErrInvalidLicenses     = errors.New("licenses must be at least 1")
+	ErrNoLicense           = errors.New("every license of this title is on loan")
+	ErrDigitalLoanClosed   = errors.New("digital loan is no longer active")
+	ErrDownloadLinkInvalid = errors.New("download link signature is invalid")
+	ErrDownloadLinkExpired = errors.New("download link has expired")
+)
+
+// epubSignature is how every EPUB starts: a zip entry named mimetype, stored uncompressed
+var epubSignature = []byte("mimetypeapplication/epub+zip")
+
+// LendingService lends digital assets. Each title has a number of licenses that can
+// be on loan at once; patrons download through signed links that expire within the
+// hour and never outlive the loan.
+type LendingService struct {
+	assets  *repository.AssetRepository
+	loans   *repository.DigitalLoanRepository
+	books   *repository.BookRepository
+	patrons *repository.PatronRepository
+	store   blob.Store
+	key     []byte
+	now     func() time.Time
+
+	// mu serialises checkouts so a title is never lent beyond its licenses
+	mu sync.Mutex
+
+	LoanPeriod  time.Duration
+	DownloadTTL time.Duration
+}
+
+// NewLendingService initializes LendingService. Download links are signed with key;
+// without one a random key is used and links do not survive a restart.
+func NewLendingService(assets *repository.AssetRepository, loans *repository.DigitalLoanRepository, books *repository.BookRepository,
+	patrons *repository.PatronRepository, store blob.Store, key []byte) *LendingService {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &LendingService{
+		assets:      assets,
+		loans:       loans,
+		books:       books,
+		patrons:     patrons,
+		store:       store,
+		key:         key,
+		now:         time.Now,
+		LoanPeriod:  DefaultLoanPeriod,
+		DownloadTTL: DefaultDownloadTTL,
+	}
+}
+
+// SetClock replaces the time source, for tests
+func (s *LendingService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// AddAsset stores a file of a book, streaming it to the store. The format is recognised
+// from the data itself.
+func (s *LendingService) AddAsset(bookID int, fileName string, licenses int, data io.Reader) (model.DigitalAsset, error) {
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return model.DigitalAsset{}, ErrBookNotFound
+	}
+	if licenses == 0 {
+		licenses = DefaultAssetLicense
+	}
+	if licenses < 1 {
+		return model.DigitalAsset{}, ErrInvalidLicenses
+	}
+	file := bufio.NewReader(data)
+	head, err := file.Peek(assetHeadBytes)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return model.DigitalAsset{}, err
+	}
+	format, contentType := sniffAsset(head)
+	if format == "" {
+		return model.DigitalAsset{}, ErrUnsupportedAsset
+	}
+
+	fileName = path.Base(strings.ReplaceAll(strings.TrimSpace(fileName), "\\", "/"))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		fileName = "book"
+	}
+	if !strings.HasSuffix(strings.ToLower(fileName), "."+format) {
+		fileName += "." + format
+	}
+
+	asset := s.assets.AddAsset(model.DigitalAsset{
+		BookID:      bookID,
+		Format:      format,
+		FileName:    fileName,
+		ContentType: contentType,
+		Licenses:    licenses,
+		CreatedAt:   s.now(),
+	})
+	size, err := s.storeAsset(asset.ID, file)
+	if err != nil {
+		s.assets.DeleteAssetByID(asset.ID)
+		return model.DigitalAsset{}, err
+	}
+	asset.Size = int(size)
+	if err := s.assets.UpdateAsset(asset); err != nil {
+		return model.DigitalAsset{}, err
+	}
+	asset.Available = licenses
+	return asset, nil
+}
+
+// storeAsset copies the file of an asset to the store and returns its size. Nothing is
+// kept when the copy fails or the file exceeds MaxAssetBytes.
+func (s *LendingService) storeAsset(id int, file io.Reader) (int64, error) {
+	w, err := s.store.Create(assetKey(id))
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(w, io.LimitReader(file, MaxAssetBytes+1))
+	if err == nil && size > MaxAssetBytes {
+		err = ErrAssetTooLarge
+	}
+	if err != nil {
+		w.Abort()
+		return 0, err
+	}
+	return size, w.Close()
+}
+
+// GetAssetByID retrieves an asset with its free licenses
+func (s *LendingService) GetAssetByID(id int) (model.DigitalAsset, error) {
+	asset, err := s.assets.GetAssetByID(id)
+	if err != nil {
+		return model.DigitalAsset{}, err
+	}
+	return s.withAvailability(asset), nil
+}
+
+// GetAssets retrieves the assets of a book with their free licenses
+func (s *LendingService) GetAssets(bookID int) ([]model.DigitalAsset, error) {
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return nil, ErrBookNotFound
+	}
+	assets := s.assets.GetAssets(bookID)
+	for i := range assets {
+		assets[i] = s.withAvailability(assets[i])
+	}
+	return assets, nil
+}
+
+// SetLicenses changes how many copies of an asset can be lent at once. Loans beyond
+// a lowered limit run their course.
+func (s *LendingService) SetLicenses(id, licenses int) (model.DigitalAsset, error) {
+	if licenses < 1 {
+		return model.DigitalAsset{}, ErrInvalidLicenses
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	asset, err := s.assets.GetAssetByID(id)
+	if err != nil {
+		return model.DigitalAsset{}, err
+	}
+	asset.Licenses = licenses
+	if err := s.assets.UpdateAsset(asset); err != nil {
+		return model.DigitalAsset{}, err
+	}
+	return s.withAvailability(asset), nil
+}
+
+// DeleteAsset removes an asset and its file, ending its active loans
+func (s *LendingService) DeleteAsset(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.assets.DeleteAssetByID(id); err != nil {
+		return err
+	}
+	now := s.now()
+	for _, loan := range s.loans.GetLoans(id, 0, model.DigitalLoanStatusActive) {
+		loan.Status = model.DigitalLoanStatusReturned
+		loan.ClosedAt = &now
+		if err := s.loans.UpdateLoan(loan); err != nil {
+			return err
+		}
+	}
+	return s.store.Delete(assetKey(id))
+}
+
+// DeleteAssetsOfBook removes the assets of a deleted book
+func (s *LendingService) DeleteAssetsOfBook(bookID int) {
+	for _, asset := range s.assets.GetAssets(bookID) {
+		// The book is gone either way; a file left behind only wastes space
+		_ = s.DeleteAsset(asset.ID)
+	}
+}
+
+// Borrow lends an asset to a patron if a license is free and returns the loan with a download link
+func (s *LendingService) Borrow(assetID, patronID int) (model.DigitalLoan, error) {
+	if _, err := s.patrons.GetPatronByID(patronID); err != nil {
+		return model.DigitalLoan{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	asset, err := s.assets.GetAssetByID(assetID)
+	if err != nil {
+		return model.DigitalLoan{}, err
+	}
+	now := s.now()
+	active := s.activeLoans(assetID, now)
+	for _, loan := range active {
+		if loan.PatronID == patronID {
+			return model.DigitalLoan{}, ErrAlreadyBorrowed
+		}
+	}
+	if len(active) >= asset.Licenses {
+		return model.DigitalLoan{}, ErrNoLicense
+	}
+
+	loan := s.loans.AddLoan(model.DigitalLoan{
+		AssetID:      assetID,
+		BookID:       asset.BookID,
+		PatronID:     patronID,
+		Status:       model.DigitalLoanStatusActive,
+		CheckedOutAt: now,
+		DueDate:      now.Add(s.LoanPeriod),
+	})
+	return s.withLink(loan, now), nil
+}
+
+// GetLoanByID retrieves a digital loan, with a fresh download link while it is active
+func (s *LendingService) GetLoanByID(id int) (model.DigitalLoan, error) {
+	loan, err := s.loans.GetLoanByID(id)
+	if err != nil {
+		return model.DigitalLoan{}, err
+	}
+	return s.withLink(loan, s.now()), nil
+}
+
+// GetLoans retrieves digital loans; zero or empty arguments match everything
+func (s *LendingService) GetLoans(assetID, patronID int, status string) []model.DigitalLoan {
+	return s.loans.GetLoans(assetID, patronID, status)
+}
+
+// Return gives a digital loan back early, freeing its license
+func (s *LendingService) Return(id int) (model.DigitalLoan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loan, err := s.loans.GetLoanByID(id)
+	if err != nil {
+		return model.DigitalLoan{}, err
+	}
+	now := s.now()
+	if !loanActive(loan, now) {
+		return model.DigitalLoan{}, ErrDigitalLoanClosed
+	}
+	loan.Status = model.DigitalLoanStatusReturned
+	loan.ClosedAt = &now
+	return loan, s.loans.UpdateLoan(loan)
+}
+
+// ExpireLoans closes the active digital loans whose period has ended and returns them.
+// Their licenses are already free from the due date on; this brings the records in line.
+func (s *LendingService) ExpireLoans(ctx context.Context) ([]model.DigitalLoan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var expired []model.DigitalLoan
+	for _, loan := range s.loans.GetLoans(0, 0, model.DigitalLoanStatusActive) {
+		if err := ctx.Err(); err != nil {
+			return expired, err
+		}
+		if now.Before(loan.DueDate) {
+			continue
+		}
+		closedAt := loan.DueDate
+		loan.Status = model.DigitalLoanStatusExpired
+		loan.ClosedAt = &closedAt
+		if err := s.loans.UpdateLoan(loan); err != nil {
+			return expired, err
+		}
+		expired = append(expired, loan)
+	}
+	return expired, nil
+}
+
+// Download checks a signed link and opens the file it grants; the caller closes it
+func (s *LendingService) Download(loanID int, expires, signature string) (model.DigitalAsset, io.ReadCloser, error) {
+	loan, err := s.loans.GetLoanByID(loanID)
+	if err != nil {
+		return model.DigitalAsset{}, nil, err
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(loan, expiresAt))) {
+		return model.DigitalAsset{}, nil, ErrDownloadLinkInvalid
+	}
+	now := s.now()
+	if !loanActive(loan, now) {
+		return model.DigitalAsset{}, nil, ErrDigitalLoanClosed
+	}
+	if now.Unix() >= expiresAt {
+		return model.DigitalAsset{}, nil, ErrDownloadLinkExpired
+	}
+
+	asset, err := s.assets.GetAssetByID(loan.AssetID)
+	if err != nil {
+		return model.DigitalAsset{}, nil, ErrDigitalLoanClosed
+	}
+	file, err := s.store.Open(assetKey(asset.ID))
+	if err != nil {
+		return model.DigitalAsset{}, nil, err
+	}
+	return asset, file, nil
+}
+
+// activeLoans returns the loans of an asset holding a license
+func (s *LendingService) activeLoans(assetID int, now time.Time) []model.DigitalLoan {
+	var active []model.DigitalLoan
+	for _, loan := range s.loans.GetLoans(assetID, 0, model.DigitalLoanStatusActive) {
+		if loanActive(loan, now) {
+			active = append(active, loan)
+		}
+	}
+	return active
+}
+
+func (s *LendingService) withAvailability(asset model.DigitalAsset) model.DigitalAsset {
+	asset.Available = asset.Licenses - len(s.activeLoans(asset.ID, s.now()))
+	if asset.Available < 0 {
+		asset.Available = 0
+	}
+	return asset
+}
+
+// withLink adds a signed download link to an active loan, valid for DownloadTTL but never past the due date
+func (s *LendingService) withLink(loan model.DigitalLoan, now time.Time) model.DigitalLoan {
+	if !loanActive(loan, now) {
+		return loan
+	}
+	expiresAt := now.Add(s.DownloadTTL)
+	if loan.DueDate.Before(expiresAt) {
+		expiresAt = loan.DueDate
+	}
+	expiresAt = expiresAt.Truncate(time.Second)
+
+	loan.LinkExpiresAt = &expiresAt
+	loan.DownloadURL = fmt.Sprintf("/downloads/%d?expires=%d&signature=%s", loan.ID, expiresAt.Unix(), s.sign(loan, expiresAt.Unix()))
+	return loan
+}
+
+// sign binds a link to the loan, its patron and its expiry
+func (s *LendingService) sign(loan model.DigitalLoan, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%d:%d:%d:%d", loan.ID, loan.AssetID, loan.PatronID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// loanActive reports whether a loan still holds its license; it lapses at the due date
+// even before the expiry job has run
+func loanActive(loan model.DigitalLoan, now time.Time) bool {
+	return loan.Status == model.DigitalLoanStatusActive && now.Before(loan.DueDate)
+}
+
+// assetHeadBytes is how much of a file sniffAsset needs to see
+var assetHeadBytes = 30 + len(epubSignature)
+
+// sniffAsset recognises EPUB and PDF files by their leading bytes
+func sniffAsset(data []byte) (string, string) {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return model.AssetFormatPDF, "application/pdf"
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) && len(data) >= 30+len(epubSignature) &&
+		bytes.Equal(data[30:30+len(epubSignature)], epubSignature):
+		return model.AssetFormatEPUB, "application/epub+zip"
+	}
+	return "", ""
+}
+
+func assetKey(id int) string {
+	return fmt.Sprintf("assets/%d", id)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"LibraryGo/internal/auth"
+	"LibraryGo/internal/model"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginTTL is how long a browser has to come back from the identity provider
+const LoginTTL = 10 * time.Minute
+
+// MaxPendingLogins caps the logins waiting for the provider. More are refused until some
+// complete or expire, rather than dropping logins other browsers are in the middle of.
+const MaxPendingLogins = 1000
+
+// DefaultReturnPath is where a browser lands after logging in when it asked for nowhere
+const DefaultReturnPath = "/auth/me"
+
+// Errors returned by LoginService
+var (
+	ErrLoginStateInvalid = errors.New("login state is unknown or has expired; start the login again")
+	ErrUnsafeReturnPath  = errors.New("return path must be a path on this server")
+	ErrNoRole            = errors.New("the identity provider gave this user no role in the library")
+	ErrTooManyLogins     = errors.New("too many logins are in progress; try again in a minute")
+	ErrNoSession         = errors.New("no session to log out of")
+)
+
+// pendingLogin is what is remembered between sending a browser to the provider and
+// its return
+type pendingLogin struct {
+	verifier  string
+	nonce     string
+	returnTo  string
+	expiresAt time.Time
+}
+
+// LoginService logs staff in through an OpenID Connect provider and keeps their
+// browser sessions
+type LoginService struct {
+	provider     *auth.OIDCClient
+	sessions     *auth.SessionStore
+	defaultRoles []string
+	now          func() time.Time
+
+	// mu guards pending so each state is redeemed at most once. It holds at most
+	// MaxPendingLogins entries.
+	mu      sync.Mutex
+	pending map[string]pendingLogin
+}
+
+// NewLoginService initializes LoginService. Users whose ID token carries no roles
+// claim get defaultRoles; with none, their login is refused.
+func NewLoginService(provider *auth.OIDCClient, sessions *auth.SessionStore, defaultRoles []string) *LoginService {
+	return &LoginService{
+		provider:     provider,
+		sessions:     sessions,
+		defaultRoles: defaultRoles,
+		now:          time.Now,
+		pending:      make(map[string]pendingLogin),
+	}
+}
+
+// SetClock replaces the time source, for tests
+func (s *LoginService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Begin starts a login and returns the provider URL to send the browser to, and the
+// state the browser must present again on its return. returnTo is the local path the
+// browser goes to once logged in.
+func (s *LoginService) Begin(ctx context.Context, returnTo string) (string, string, error) {
+	if returnTo == "" {
+		returnTo = DefaultReturnPath
+	}
+	// Only local paths, so the login cannot be used to redirect to another site
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.Contains(returnTo, "\\") {
+		return "", "", ErrUnsafeReturnPath
+	}
+
+	state, err := auth.RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := auth.RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := auth.NewPKCEVerifier()
+	if err != nil {
+		return "", "", err
+	}
+	url, err := s.provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, login := range s.pending {
+		if now.After(login.expiresAt) {
+			delete(s.pending, key)
+		}
+	}
+	if len(s.pending) >= MaxPendingLogins {
+		return "", "", ErrTooManyLogins
+	}
+	s.pending[state] = pendingLogin{verifier: verifier, nonce: nonce, returnTo: returnTo, expiresAt: now.Add(LoginTTL)}
+	return url, state, nil
+}
+
+// Complete redeems the code the provider sent the browser back with and starts a
+// session. browserState is the state the browser kept from Begin; it must match the
+// state the provider returned, so a login started elsewhere cannot be completed in
+// this browser. It returns the session and the path the login asked to return to.
+func (s *LoginService) Complete(ctx context.Context, state, browserState, code string) (auth.Session, string, error) {
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return auth.Session{}, "", ErrLoginStateInvalid
+	}
+	s.mu.Lock()
+	login, ok := s.pending[state]
+	delete(s.pending, state)
+	s.mu.Unlock()
+	if !ok || s.now().After(login.expiresAt) {
+		return auth.Session{}, "", ErrLoginStateInvalid
+	}
+
+	claims, err := s.provider.Exchange(ctx, code, login.verifier, login.nonce)
+	if err != nil {
+		return auth.Session{}, "", err
+	}
+	roles := claims.Roles
+	if len(roles) == 0 {
+		roles = s.defaultRoles
+	}
+	if len(roles) == 0 {
+		return auth.Session{}, "", ErrNoRole
+	}
+	session, err := s.sessions.Create(model.Principal{ID: claims.Subject, Name: claims.Name, Kind: model.PrincipalSession, Roles: roles,
+		PatronID: claims.PatronID})
+	if err != nil {
+		return auth.Session{}, "", err
+	}
+	return session, login.returnTo, nil
+}
+
+// Logout ends a session
+func (s *LoginService) Logout(sessionID string) error {
+	if _, ok := s.sessions.Get(sessionID); !ok {
+		return ErrNoSession
+	}
+	s.sessions.Delete(sessionID)
+	return nil
+}
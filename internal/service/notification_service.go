@@ -0,0 +1,214 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/notify"
+	"LibraryGo/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultDueSoonWindow is how long before the due date a reminder is sent
+const DefaultDueSoonWindow = 2 * 24 * time.Hour
+
+// ErrChannelNotConfigured is recorded when a patron's channel is not available
+var ErrChannelNotConfigured = errors.New("notification channel is not configured")
+
+// NotificationService renders and delivers patron notifications and keeps the delivery log
+type NotificationService struct {
+	patrons        *repository.PatronRepository
+	books          *repository.BookRepository
+	circulation    *CirculationService
+	log            *repository.NotificationRepository
+	templates      *notify.Templates
+	channels       map[string]notify.Channel
+	defaultChannel string
+	retry          notify.RetryPolicy
+	now            func() time.Time
+
+	DueSoonWindow time.Duration
+}
+
+// NewNotificationService initializes NotificationService with the available delivery channels
+func NewNotificationService(patrons *repository.PatronRepository, books *repository.BookRepository, circulation *CirculationService,
+	log *repository.NotificationRepository, templates *notify.Templates, retry notify.RetryPolicy, defaultChannel string, channels ...notify.Channel) *NotificationService {
+	byName := make(map[string]notify.Channel, len(channels))
+	for _, channel := range channels {
+		byName[channel.Name()] = channel
+	}
+
+	return &NotificationService{
+		patrons:        patrons,
+		books:          books,
+		circulation:    circulation,
+		log:            log,
+		templates:      templates,
+		channels:       byName,
+		defaultChannel: defaultChannel,
+		retry:          retry,
+		now:            time.Now,
+		DueSoonWindow:  DefaultDueSoonWindow,
+	}
+}
+
+// SetClock replaces the time source, for tests
+func (s *NotificationService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Notify renders a message for a patron, delivers it and records the outcome in the delivery log
+func (s *NotificationService) Notify(ctx context.Context, patronID int, kind, reference string, data notify.TemplateData) (model.Notification, error) {
+	patron, err := s.patrons.GetPatronByID(patronID)
+	if err != nil {
+		return model.Notification{}, err
+	}
+	data.PatronName = patron.Name
+
+	subject, body, err := s.templates.Render(kind, patron.Locale, data)
+	if err != nil {
+		return model.Notification{}, err
+	}
+
+	channelName := patron.PreferredChannel
+	if channelName == "" {
+		channelName = s.defaultChannel
+	}
+
+	entry := model.Notification{
+		PatronID:  patron.ID,
+		Kind:      kind,
+		Reference: reference,
+		Channel:   channelName,
+		Subject:   subject,
+		Body:      body,
+		CreatedAt: s.now(),
+	}
+
+	var deliveryErr error
+	channel, ok := s.channels[channelName]
+	if !ok {
+		deliveryErr = notify.Permanent(ErrChannelNotConfigured)
+	} else {
+		entry.Attempts, deliveryErr = s.retry.Deliver(ctx, channel, notify.Message{
+			PatronID:   patron.ID,
+			Name:       patron.Name,
+			Email:      patron.Email,
+			WebhookURL: patron.WebhookURL,
+			Kind:       kind,
+			Reference:  reference,
+			Subject:    subject,
+			Body:       body,
+		})
+	}
+
+	switch {
+	case deliveryErr == nil:
+		sentAt := s.now()
+		entry.Status = model.DeliveryStatusSent
+		entry.SentAt = &sentAt
+	case notify.IsPermanent(deliveryErr):
+		entry.Status = model.DeliveryStatusUndeliverable
+		entry.Error = deliveryErr.Error()
+	default:
+		entry.Status = model.DeliveryStatusFailed
+		entry.Error = deliveryErr.Error()
+	}
+
+	return s.log.AddNotification(entry), deliveryErr
+}
+
+// GetDeliveries retrieves the delivery log of a patron
+func (s *NotificationService) GetDeliveries(patronID int, kind, status string) ([]model.Notification, error) {
+	if _, err := s.patrons.GetPatronByID(patronID); err != nil {
+		return nil, err
+	}
+	return s.log.GetNotifications(patronID, kind, status), nil
+}
+
+// SendDueReminders notifies patrons whose loans fall due within DueSoonWindow
+func (s *NotificationService) SendDueReminders(ctx context.Context) (int, error) {
+	now := s.now()
+	var pending []model.Loan
+	for _, loan := range s.circulation.GetLoans(0, 0, model.LoanStatusActive) {
+		if loan.DueDate.After(now) && loan.DueDate.Sub(now) <= s.DueSoonWindow {
+			pending = append(pending, loan)
+		}
+	}
+	return s.notifyLoans(ctx, pending, model.NotificationDueSoon)
+}
+
+// SendOverdueNotices notifies patrons about each overdue loan once
+func (s *NotificationService) SendOverdueNotices(ctx context.Context) (int, error) {
+	return s.notifyLoans(ctx, s.circulation.GetLoans(0, 0, model.LoanStatusOverdue), model.NotificationOverdue)
+}
+
+// SendHoldReadyNotices tells patrons that a held book is waiting for pickup
+func (s *NotificationService) SendHoldReadyNotices(ctx context.Context) (int, error) {
+	sent, failed := 0, 0
+	for _, hold := range s.circulation.GetHolds(0, 0, model.HoldStatusReady) {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		reference := fmt.Sprintf("hold:%d", hold.ID)
+		if s.log.IsSettled(hold.PatronID, model.NotificationHoldReady, reference) {
+			continue
+		}
+
+		data := s.bookData(hold.BookID)
+		if hold.ExpiresAt != nil {
+			data.ExpiresAt = *hold.ExpiresAt
+		}
+		_, err := s.Notify(ctx, hold.PatronID, model.NotificationHoldReady, reference, data)
+		sent, failed = tally(err, sent, failed)
+	}
+	return sent, deliveryFailures(failed)
+}
+
+// notifyLoans sends one notification of the given kind per loan unless it was already settled
+func (s *NotificationService) notifyLoans(ctx context.Context, loans []model.Loan, kind string) (int, error) {
+	sent, failed := 0, 0
+	for _, loan := range loans {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		reference := fmt.Sprintf("loan:%d", loan.ID)
+		if s.log.IsSettled(loan.PatronID, kind, reference) {
+			continue
+		}
+
+		data := s.bookData(loan.BookID)
+		data.DueDate = loan.DueDate
+		_, err := s.Notify(ctx, loan.PatronID, kind, reference, data)
+		sent, failed = tally(err, sent, failed)
+	}
+	return sent, deliveryFailures(failed)
+}
+
+// bookData fills in the book fields of the template data
+func (s *NotificationService) bookData(bookID int) notify.TemplateData {
+	book, err := s.books.GetBookByID(context.Background(), bookID)
+	if err != nil {
+		return notify.TemplateData{BookTitle: fmt.Sprintf("book #%d", bookID)}
+	}
+	return notify.TemplateData{BookTitle: book.Title, BookAuthor: book.Author}
+}
+
+// tally counts a delivery outcome; undeliverable messages are logged but do not fail the run
+func tally(err error, sent, failed int) (int, int) {
+	switch {
+	case err == nil:
+		sent++
+	case !notify.IsPermanent(err):
+		failed++
+	}
+	return sent, failed
+}
+
+func deliveryFailures(failed int) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d notifications could not be delivered", failed)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PatronService provides patron business logic
+type PatronService struct {
+	repo     *repository.PatronRepository
+	channels []string // Notification channels patrons may prefer, sorted
+}
+
+// NewPatronService initializes PatronService with the notification channels that are configured
+func NewPatronService(repo *repository.PatronRepository, channels ...string) *PatronService {
+	channels = append([]string(nil), channels...)
+	sort.Strings(channels)
+	return &PatronService{repo: repo, channels: channels}
+}
+
+// AddPatron validates and adds a patron
+func (s *PatronService) AddPatron(patron model.Patron) (model.Patron, error) {
+	patron.Name = strings.TrimSpace(patron.Name)
+	if patron.Name == "" {
+		return model.Patron{}, errors.New("patron name is required")
+	}
+	if patron.Email != "" {
+		// Only the address is kept, as "Ada <ada@example.org>" is no recipient for SMTP
+		addr, err := mail.ParseAddress(patron.Email)
+		if err != nil {
+			return model.Patron{}, errors.New("invalid email address")
+		}
+		patron.Email = addr.Address
+	}
+	if patron.WebhookURL != "" {
+		u, err := url.Parse(patron.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return model.Patron{}, errors.New("invalid webhook URL")
+		}
+	}
+	if patron.PreferredChannel != "" && !s.hasChannel(patron.PreferredChannel) {
+		return model.Patron{}, fmt.Errorf("preferred channel must be one of %s", strings.Join(s.channels, ", "))
+	}
+	if patron.Locale == "" {
+		patron.Locale = "en"
+	}
+
+	return s.repo.AddPatron(patron), nil
+}
+
+// hasChannel reports whether a notification channel is configured
+func (s *PatronService) hasChannel(name string) bool {
+	i := sort.SearchStrings(s.channels, name)
+	return i < len(s.channels) && s.channels[i] == name
+}
+
+// GetPatronByID retrieves a patron by ID
+func (s *PatronService) GetPatronByID(id int) (model.Patron, error) {
+	return s.repo.GetPatronByID(id)
+}
+
+// GetAllPatrons retrieves all patrons
+func (s *PatronService) GetAllPatrons() []model.Patron {
+	return s.repo.GetAllPatrons()
+}
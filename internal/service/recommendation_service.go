@@ -0,0 +1,264 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Recommendation list sizes
+const (
+	DefaultRecommendations = 10
+	MaxRecommendations     = 50
+)
+
+// Errors returned by RecommendationService
+var ErrNotOwnRecommendations = errors.New("patrons can only see their own recommendations")
+
+// RecommendationService suggests books with item-to-item collaborative filtering over
+// loan history: two books are similar when the same patrons borrowed both. When the
+// history says too little it falls back to the same author or subjects, then to the
+// most borrowed books.
+type RecommendationService struct {
+	books   *repository.BookRepository
+	patrons *repository.PatronRepository
+
+	// Who borrowed what, kept from loan events so it survives loan compaction
+	borrowers map[int]map[int]bool // Book ID to patron IDs
+	history   map[int]map[int]bool // Patron ID to book IDs
+	mu        sync.Mutex
+}
+
+// NewRecommendationService initializes RecommendationService from the loans recorded so far.
+// Register it with CirculationService.Observe to learn from new loans.
+func NewRecommendationService(books *repository.BookRepository, patrons *repository.PatronRepository, loans *repository.LoanRepository) *RecommendationService {
+	s := &RecommendationService{
+		books:     books,
+		patrons:   patrons,
+		borrowers: make(map[int]map[int]bool),
+		history:   make(map[int]map[int]bool),
+	}
+	for _, loan := range loans.GetLoans(0, 0, "") {
+		s.LoanCreated(loan)
+	}
+	return s
+}
+
+// LoanCreated records that a patron borrowed a book
+func (s *RecommendationService) LoanCreated(loan model.Loan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.borrowers[loan.BookID] == nil {
+		s.borrowers[loan.BookID] = make(map[int]bool)
+	}
+	s.borrowers[loan.BookID][loan.PatronID] = true
+	if s.history[loan.PatronID] == nil {
+		s.history[loan.PatronID] = make(map[int]bool)
+	}
+	s.history[loan.PatronID][loan.BookID] = true
+}
+
+// Related recommends books to read after the given one
+func (s *RecommendationService) Related(bookID, limit int) ([]model.Recommendation, error) {
+	book, err := s.books.GetBookByID(context.Background(), bookID)
+	if err != nil {
+		return nil, ErrBookNotFound
+	}
+	limit = recommendationLimit(limit)
+
+	s.mu.Lock()
+	scores := s.similar(bookID)
+	s.mu.Unlock()
+
+	exclude := map[int]bool{bookID: true}
+	recommendations := s.ranked(scores, exclude, limit)
+	recommendations = append(recommendations, s.alike([]model.Book{book}, exclude, limit-len(recommendations))...)
+	return recommendations, nil
+}
+
+// ForPatron recommends books a patron has not borrowed yet, based on what they did borrow.
+// Patrons see only their own recommendations; staff see anyone's.
+func (s *RecommendationService) ForPatron(patronID, limit int, actor model.Actor) ([]model.Recommendation, error) {
+	if !actor.Owns(patronID) {
+		return nil, ErrNotOwnRecommendations
+	}
+	if _, err := s.patrons.GetPatronByID(patronID); err != nil {
+		return nil, err
+	}
+	limit = recommendationLimit(limit)
+
+	s.mu.Lock()
+	exclude := make(map[int]bool)
+	scores := make(map[int]float64)
+	for borrowed := range s.history[patronID] {
+		exclude[borrowed] = true
+	}
+	for borrowed := range s.history[patronID] {
+		for other, score := range s.similar(borrowed) {
+			if !exclude[other] {
+				scores[other] += score
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	var seeds []model.Book
+	for _, book := range s.sortedBooks() {
+		if exclude[book.ID] {
+			seeds = append(seeds, book)
+		}
+	}
+
+	recommendations := s.ranked(scores, exclude, limit)
+	recommendations = append(recommendations, s.alike(seeds, exclude, limit-len(recommendations))...)
+	recommendations = append(recommendations, s.popular(exclude, limit-len(recommendations))...)
+	return recommendations, nil
+}
+
+// similar scores the books borrowed by patrons who borrowed bookID by cosine
+// similarity of their borrower sets; s.mu must be held
+func (s *RecommendationService) similar(bookID int) map[int]float64 {
+	together := make(map[int]int)
+	for patronID := range s.borrowers[bookID] {
+		for other := range s.history[patronID] {
+			if other != bookID {
+				together[other]++
+			}
+		}
+	}
+
+	scores := make(map[int]float64, len(together))
+	for other, count := range together {
+		scores[other] = float64(count) / math.Sqrt(float64(len(s.borrowers[bookID])*len(s.borrowers[other])))
+	}
+	return scores
+}
+
+// ranked turns similarity scores into recommendations, best first, skipping
+// excluded and deleted books; chosen books are added to exclude
+func (s *RecommendationService) ranked(scores map[int]float64, exclude map[int]bool, limit int) []model.Recommendation {
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	recommendations := []model.Recommendation{}
+	for _, id := range ids {
+		if len(recommendations) >= limit {
+			break
+		}
+		if exclude[id] {
+			continue
+		}
+		book, err := s.books.GetBookByID(context.Background(), id)
+		if err != nil {
+			continue
+		}
+		exclude[id] = true
+		recommendations = append(recommendations, model.Recommendation{
+			Book:   book,
+			Score:  math.Round(scores[id]*1000) / 1000,
+			Reason: model.ReasonBorrowedTogether,
+		})
+	}
+	return recommendations
+}
+
+// alike recommends books by the same authors as the seeds, then books sharing their subjects
+func (s *RecommendationService) alike(seeds []model.Book, exclude map[int]bool, limit int) []model.Recommendation {
+	if limit <= 0 || len(seeds) == 0 {
+		return nil
+	}
+	authors := make(map[string]bool)
+	subjects := make(map[int]bool)
+	for _, seed := range seeds {
+		authors[seed.Author] = true
+		for _, id := range seed.SubjectIDs {
+			subjects[id] = true
+		}
+	}
+
+	var byAuthor, bySubject []model.Recommendation
+	for _, book := range s.sortedBooks() {
+		if exclude[book.ID] {
+			continue
+		}
+		if authors[book.Author] {
+			byAuthor = append(byAuthor, model.Recommendation{Book: book, Reason: model.ReasonSameAuthor})
+			continue
+		}
+		shared := 0
+		for _, id := range book.SubjectIDs {
+			if subjects[id] {
+				shared++
+			}
+		}
+		if shared > 0 {
+			bySubject = append(bySubject, model.Recommendation{Book: book, Score: float64(shared), Reason: model.ReasonSameSubject})
+		}
+	}
+	// Books sharing more subjects come first
+	sort.SliceStable(bySubject, func(i, j int) bool {
+		return bySubject[i].Score > bySubject[j].Score
+	})
+
+	recommendations := append(byAuthor, bySubject...)
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	for i := range recommendations {
+		exclude[recommendations[i].Book.ID] = true
+		recommendations[i].Score = 0
+	}
+	return recommendations
+}
+
+// popular recommends the most borrowed books
+func (s *RecommendationService) popular(exclude map[int]bool, limit int) []model.Recommendation {
+	if limit <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	counts := make(map[int]float64, len(s.borrowers))
+	for bookID, patrons := range s.borrowers {
+		counts[bookID] = float64(len(patrons))
+	}
+	s.mu.Unlock()
+
+	recommendations := s.ranked(counts, exclude, limit)
+	for i := range recommendations {
+		recommendations[i].Reason = model.ReasonPopular
+	}
+	return recommendations
+}
+
+// sortedBooks lists the catalog in ID order
+func (s *RecommendationService) sortedBooks() []model.Book {
+	books := s.books.GetAllBooks(context.Background())
+	sort.Slice(books, func(i, j int) bool {
+		return books[i].ID < books[j].ID
+	})
+	return books
+}
+
+func recommendationLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultRecommendations
+	}
+	if limit > MaxRecommendations {
+		return MaxRecommendations
+	}
+	return limit
+}
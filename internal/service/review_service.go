@@ -0,0 +1,166 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxReviewLength limits the text of a review, in characters
+const MaxReviewLength = 5000
+
+// Errors returned by ReviewService
+var (
+	ErrInvalidRating     = errors.New("rating must be between 1 and 5")
+	ErrReviewTooLong     = errors.New("review text is too long")
+	ErrDuplicateReview   = errors.New("patron has already reviewed this book")
+	ErrInvalidModeration = errors.New("reviews can only be approved or rejected")
+	ErrNotReviewer       = errors.New("only patrons, or staff on their behalf, can review books")
+	ErrModeratorsOnly    = errors.New("only moderators can list reviews that are not approved")
+)
+
+// ReviewService manages patron reviews, their moderation and book ratings
+type ReviewService struct {
+	reviews *repository.ReviewRepository
+	books   *repository.BookRepository
+	patrons *repository.PatronRepository
+	now     func() time.Time
+
+	// mu serialises submissions so a patron cannot post two reviews of a book at once
+	mu sync.Mutex
+}
+
+// NewReviewService initializes ReviewService
+func NewReviewService(reviews *repository.ReviewRepository, books *repository.BookRepository, patrons *repository.PatronRepository) *ReviewService {
+	return &ReviewService{
+		reviews: reviews,
+		books:   books,
+		patrons: patrons,
+		now:     time.Now,
+	}
+}
+
+// AddReview submits a review for moderation. A patron reviews as themselves; staff
+// may enter a review for the patron it names and are recorded as having done so.
+// A patron has one review per book; a rejected review may be replaced by a new one.
+func (s *ReviewService) AddReview(review model.Review, actor model.Actor) (model.Review, error) {
+	review.EnteredBy = ""
+	switch {
+	case actor.Staff && review.PatronID != 0 && review.PatronID != actor.PatronID:
+		review.EnteredBy = actor.ID
+	case actor.PatronID != 0:
+		review.PatronID = actor.PatronID
+	default:
+		return model.Review{}, ErrNotReviewer
+	}
+	review.Text = strings.TrimSpace(review.Text)
+	if review.Rating < 1 || review.Rating > 5 {
+		return model.Review{}, ErrInvalidRating
+	}
+	if len([]rune(review.Text)) > MaxReviewLength {
+		return model.Review{}, ErrReviewTooLong
+	}
+	if _, err := s.books.GetBookByID(context.Background(), review.BookID); err != nil {
+		return model.Review{}, ErrBookNotFound
+	}
+	if _, err := s.patrons.GetPatronByID(review.PatronID); err != nil {
+		return model.Review{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.reviews.GetReviews(review.BookID, review.PatronID, "") {
+		if existing.Status != model.ReviewStatusRejected {
+			return model.Review{}, ErrDuplicateReview
+		}
+	}
+
+	review.Status = model.ReviewStatusPending
+	review.CreatedAt = s.now()
+	review.ModeratedAt = nil
+	return s.reviews.AddReview(review), nil
+}
+
+// GetReviewByID retrieves a review by ID
+func (s *ReviewService) GetReviewByID(id int) (model.Review, error) {
+	return s.reviews.GetReviewByID(id)
+}
+
+// GetBookReviews retrieves the reviews of a book with the given status, or of every
+// status when it is empty. Only moderators see reviews that are not approved.
+func (s *ReviewService) GetBookReviews(bookID int, status string, actor model.Actor) ([]model.Review, error) {
+	if status != model.ReviewStatusApproved && !actor.Staff {
+		return nil, ErrModeratorsOnly
+	}
+	if _, err := s.books.GetBookByID(context.Background(), bookID); err != nil {
+		return nil, ErrBookNotFound
+	}
+	return s.reviews.GetReviews(bookID, 0, status), nil
+}
+
+// GetReviews retrieves reviews across books, e.g. the pending moderation queue
+func (s *ReviewService) GetReviews(status string) []model.Review {
+	return s.reviews.GetReviews(0, 0, status)
+}
+
+// Moderate approves or rejects a review
+func (s *ReviewService) Moderate(id int, status string) (model.Review, error) {
+	if status != model.ReviewStatusApproved && status != model.ReviewStatusRejected {
+		return model.Review{}, ErrInvalidModeration
+	}
+	review, err := s.reviews.GetReviewByID(id)
+	if err != nil {
+		return model.Review{}, err
+	}
+
+	now := s.now()
+	review.Status = status
+	review.ModeratedAt = &now
+	return review, s.reviews.UpdateReview(review)
+}
+
+// Summary aggregates the approved reviews of a book; nil when it has none
+func (s *ReviewService) Summary(bookID int) *model.RatingSummary {
+	return summarize(s.reviews.GetReviews(bookID, 0, model.ReviewStatusApproved))
+}
+
+// Summaries aggregates the approved reviews of every rated book
+func (s *ReviewService) Summaries() map[int]*model.RatingSummary {
+	byBook := make(map[int][]model.Review)
+	for _, review := range s.reviews.GetReviews(0, 0, model.ReviewStatusApproved) {
+		byBook[review.BookID] = append(byBook[review.BookID], review)
+	}
+
+	summaries := make(map[int]*model.RatingSummary, len(byBook))
+	for bookID, reviews := range byBook {
+		summaries[bookID] = summarize(reviews)
+	}
+	return summaries
+}
+
+// DeleteReviewsOfBook removes the reviews of a deleted book
+func (s *ReviewService) DeleteReviewsOfBook(bookID int) {
+	s.reviews.DeleteReviewsOfBook(bookID)
+}
+
+// summarize averages review ratings to two decimals
+func summarize(reviews []model.Review) *model.RatingSummary {
+	if len(reviews) == 0 {
+		return nil
+	}
+	total := 0
+	for _, review := range reviews {
+		total += review.Rating
+	}
+	average := float64(total) / float64(len(reviews))
+	return &model.RatingSummary{
+		Average: math.Round(average*100) / 100,
+		Count:   len(reviews),
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by StocktakeService
+var (
+	ErrStocktakeClosed = errors.New("stocktake session is closed")
+	ErrNotMissing      = errors.New("copy is not reported missing by this stocktake")
+	ErrEmptyScan       = errors.New("shelf and at least one barcode are required")
+)
+
+// StocktakeService audits branch shelves against the catalog
+type StocktakeService struct {
+	stocktakes *repository.StocktakeRepository
+	copies     *repository.CopyRepository
+	branches   *repository.BranchRepository
+	books      *repository.BookRepository
+	now        func() time.Time
+
+	// mu serialises scans with closing a session, so the final report covers every
+	// accepted scan, and with marking its missing copies lost
+	mu sync.Mutex
+}
+
+// NewStocktakeService initializes StocktakeService
+func NewStocktakeService(stocktakes *repository.StocktakeRepository, copies *repository.CopyRepository,
+	branches *repository.BranchRepository, books *repository.BookRepository) *StocktakeService {
+	return &StocktakeService{
+		stocktakes: stocktakes,
+		copies:     copies,
+		branches:   branches,
+		books:      books,
+		now:        time.Now,
+	}
+}
+
+// StartSession opens a stocktake of a branch, optionally limited to some shelves
+func (s *StocktakeService) StartSession(branchID int, shelves []string) (model.StocktakeSession, error) {
+	if _, err := s.branches.GetBranchByID(branchID); err != nil {
+		return model.StocktakeSession{}, err
+	}
+
+	var scope []string
+	for _, shelf := range shelves {
+		if shelf = strings.TrimSpace(shelf); shelf != "" {
+			scope = append(scope, shelf)
+		}
+	}
+
+	return s.stocktakes.AddSession(model.StocktakeSession{
+		BranchID:  branchID,
+		Shelves:   scope,
+		Status:    model.StocktakeStatusOpen,
+		StartedAt: s.now(),
+	}), nil
+}
+
+// GetSessionByID retrieves a session by ID
+func (s *StocktakeService) GetSessionByID(id int) (model.StocktakeSession, error) {
+	return s.stocktakes.GetSessionByID(id)
+}
+
+// GetSessions retrieves sessions filtered by branch and status
+func (s *StocktakeService) GetSessions(branchID int, status string) []model.StocktakeSession {
+	return s.stocktakes.GetSessions(branchID, status)
+}
+
+// AddScans records the barcodes read on one shelf
+func (s *StocktakeService) AddScans(sessionID int, shelf string, barcodes []string) (model.StocktakeSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.openSession(sessionID)
+	if err != nil {
+		return model.StocktakeSession{}, err
+	}
+
+	shelf = strings.TrimSpace(shelf)
+	now := s.now()
+	scans := make([]model.StocktakeScan, 0, len(barcodes))
+	for _, barcode := range barcodes {
+		if barcode = strings.TrimSpace(barcode); barcode != "" {
+			scans = append(scans, model.StocktakeScan{Shelf: shelf, Barcode: barcode, ScannedAt: now})
+		}
+	}
+	if shelf == "" || len(scans) == 0 {
+		return model.StocktakeSession{}, ErrEmptyScan
+	}
+
+	if err := s.stocktakes.AddScans(session.ID, scans); err != nil {
+		return model.StocktakeSession{}, err
+	}
+	return s.stocktakes.GetSessionByID(session.ID)
+}
+
+// Report compares the scans of a session with where the catalog expects each copy to be.
+// A closed session keeps the report it was closed with, whatever happens to its copies later.
+func (s *StocktakeService) Report(sessionID int) (model.StocktakeReport, error) {
+	session, err := s.stocktakes.GetSessionByID(sessionID)
+	if err != nil {
+		return model.StocktakeReport{}, err
+	}
+	if report, final := s.stocktakes.GetReport(session.ID); final {
+		return report, nil
+	}
+	return s.compare(session), nil
+}
+
+// compare builds the report of a session from its scans and the current copies
+func (s *StocktakeService) compare(session model.StocktakeSession) model.StocktakeReport {
+	report := model.StocktakeReport{
+		SessionID:  session.ID,
+		BranchID:   session.BranchID,
+		Missing:    []model.StocktakeItem{},
+		Unexpected: []model.StocktakeItem{},
+		Misplaced:  []model.StocktakeItem{},
+	}
+
+	inScope := func(shelf string) bool {
+		if len(session.Shelves) == 0 {
+			return true
+		}
+		for _, scoped := range session.Shelves {
+			if strings.EqualFold(scoped, shelf) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// A barcode scanned twice counts once, at the shelf where it was last seen
+	scannedAt := make(map[string]string)
+	var order []string
+	for _, scan := range s.stocktakes.GetScans(session.ID) {
+		if _, seen := scannedAt[scan.Barcode]; !seen {
+			order = append(order, scan.Barcode)
+		}
+		scannedAt[scan.Barcode] = scan.Shelf
+	}
+	report.Scanned = len(order)
+
+	for _, barcode := range order {
+		shelf := scannedAt[barcode]
+		item, err := s.copies.GetCopyByBarcode(barcode)
+		if err != nil {
+			report.Unexpected = append(report.Unexpected, model.StocktakeItem{
+				Barcode:      barcode,
+				Reason:       model.StocktakeReasonUnknown,
+				ScannedShelf: shelf,
+			})
+			continue
+		}
+
+		entry := s.item(item)
+		entry.ScannedShelf = shelf
+		switch {
+		case item.CurrentBranchID != session.BranchID:
+			entry.Reason = model.StocktakeReasonOtherBranch
+			report.Unexpected = append(report.Unexpected, entry)
+		case item.Status != model.CopyStatusAvailable:
+			entry.Reason = model.StocktakeReasonNotOnShelf
+			report.Unexpected = append(report.Unexpected, entry)
+		case item.Shelf != "" && !strings.EqualFold(item.Shelf, shelf):
+			entry.Reason = model.StocktakeReasonWrongShelf
+			report.Misplaced = append(report.Misplaced, entry)
+		default:
+			report.Found++
+		}
+	}
+
+	for _, item := range s.copies.GetCopies(0, session.BranchID, model.CopyStatusAvailable) {
+		if !inScope(item.Shelf) {
+			continue
+		}
+		report.Expected++
+		if _, scanned := scannedAt[item.Barcode]; scanned {
+			continue
+		}
+		entry := s.item(item)
+		entry.Reason = model.StocktakeReasonNotScanned
+		report.Missing = append(report.Missing, entry)
+	}
+
+	return report
+}
+
+// CloseSession ends a session so no more scans are accepted, and freezes and returns its
+// final report
+func (s *StocktakeService) CloseSession(sessionID int) (model.StocktakeReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.openSession(sessionID)
+	if err != nil {
+		return model.StocktakeReport{}, err
+	}
+
+	now := s.now()
+	report := s.compare(session)
+	report.ClosedAt = &now
+	if err := s.stocktakes.SaveReport(report); err != nil {
+		return model.StocktakeReport{}, err
+	}
+	session.Status = model.StocktakeStatusClosed
+	session.ClosedAt = &now
+	if err := s.stocktakes.UpdateSession(session); err != nil {
+		return model.StocktakeReport{}, err
+	}
+	return report, nil
+}
+
+// MarkMissingLost flags copies reported missing as lost.
+// With no copy IDs every missing copy is marked. Each copy is checked again as it is
+// marked: one that has been lent or moved since the report is left alone and
+// not returned.
+func (s *StocktakeService) MarkMissingLost(sessionID int, copyIDs []int) ([]model.Copy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, err := s.Report(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make(map[int]model.StocktakeItem, len(report.Missing))
+	for _, item := range report.Missing {
+		missing[item.CopyID] = item
+	}
+	if len(copyIDs) == 0 {
+		for _, item := range report.Missing {
+			copyIDs = append(copyIDs, item.CopyID)
+		}
+	}
+	for _, id := range copyIDs {
+		if _, ok := missing[id]; !ok {
+			return nil, ErrNotMissing
+		}
+	}
+
+	marked := make([]model.Copy, 0, len(copyIDs))
+	for _, id := range copyIDs {
+		// Missing copies were available at the branch; anything else means the copy turned up
+		item, err := s.copies.UpdateCopyIf(id, model.CopyStatusAvailable, func(item *model.Copy) error {
+			if item.CurrentBranchID != report.BranchID {
+				return repository.ErrCopyStatusChanged
+			}
+			item.Status = model.CopyStatusLost
+			return nil
+		})
+		switch {
+		case errors.Is(err, repository.ErrCopyStatusChanged), errors.Is(err, repository.ErrCopyNotFound):
+			continue
+		case err != nil:
+			return marked, err
+		}
+		marked = append(marked, item)
+	}
+	return marked, nil
+}
+
+// openSession loads a session that still accepts scans
+func (s *StocktakeService) openSession(id int) (model.StocktakeSession, error) {
+	session, err := s.stocktakes.GetSessionByID(id)
+	if err != nil {
+		return model.StocktakeSession{}, err
+	}
+	if session.Status != model.StocktakeStatusOpen {
+		return model.StocktakeSession{}, ErrStocktakeClosed
+	}
+	return session, nil
+}
+
+// item describes a copy for a report entry
+func (s *StocktakeService) item(item model.Copy) model.StocktakeItem {
+	entry := model.StocktakeItem{
+		Barcode:          item.Barcode,
+		CopyID:           item.ID,
+		BookID:           item.BookID,
+		ExpectedBranchID: item.CurrentBranchID,
+		ExpectedShelf:    item.Shelf,
+		CopyStatus:       item.Status,
+	}
+	if book, err := s.books.GetBookByID(context.Background(), item.BookID); err == nil {
+		entry.Title = book.Title
+	}
+	return entry
+}
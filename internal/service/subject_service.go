@@ -0,0 +1,175 @@
+package service
+
+import (
+	"LibraryGo/internal/model"
+	"LibraryGo/internal/repository"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Errors returned by SubjectService
+var (
+	ErrSubjectInvalid   = errors.New("subject name is required and kind must be topic or genre")
+	ErrSubjectDuplicate = errors.New("a subject with that name already exists under the same broader term")
+	ErrSubjectCycle     = errors.New("a subject cannot be narrower than itself")
+	ErrUnknownSubject   = errors.New("referenced subject does not exist")
+)
+
+// SubjectService maintains the hierarchical subject and genre taxonomy
+type SubjectService struct {
+	repo *repository.SubjectRepository
+
+	// mu serialises changes so duplicate and cycle checks see a stable tree
+	mu sync.Mutex
+}
+
+// NewSubjectService initializes SubjectService
+func NewSubjectService(repo *repository.SubjectRepository) *SubjectService {
+	return &SubjectService{repo: repo}
+}
+
+// AddSubject validates and adds a subject under its broader term
+func (s *SubjectService) AddSubject(subject model.Subject) (model.Subject, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subject, err := s.validate(subject)
+	if err != nil {
+		return model.Subject{}, err
+	}
+	return s.repo.AddSubject(subject), nil
+}
+
+// UpdateSubject renames, re-kinds or moves a subject within the tree
+func (s *SubjectService) UpdateSubject(subject model.Subject) (model.Subject, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.repo.GetSubjectByID(subject.ID); err != nil {
+		return model.Subject{}, err
+	}
+	subject, err := s.validate(subject)
+	if err != nil {
+		return model.Subject{}, err
+	}
+	for id := subject.BroaderID; id != 0; {
+		if id == subject.ID {
+			return model.Subject{}, ErrSubjectCycle
+		}
+		broader, err := s.repo.GetSubjectByID(id)
+		if err != nil {
+			break
+		}
+		id = broader.BroaderID
+	}
+
+	if err := s.repo.UpdateSubject(subject); err != nil {
+		return model.Subject{}, err
+	}
+	return s.GetSubjectByID(subject.ID)
+}
+
+// GetSubjectByID retrieves a subject with its narrower terms
+func (s *SubjectService) GetSubjectByID(id int) (model.Subject, error) {
+	subject, err := s.repo.GetSubjectByID(id)
+	if err != nil {
+		return model.Subject{}, err
+	}
+	subject.NarrowerIDs = s.narrower(s.repo.GetAllSubjects())[id]
+	return subject, nil
+}
+
+// GetSubjects retrieves subjects with their narrower terms, optionally of one kind
+func (s *SubjectService) GetSubjects(kind string) []model.Subject {
+	all := s.repo.GetAllSubjects()
+	narrower := s.narrower(all)
+
+	subjects := make([]model.Subject, 0, len(all))
+	for _, subject := range all {
+		if kind != "" && subject.Kind != kind {
+			continue
+		}
+		subject.NarrowerIDs = narrower[subject.ID]
+		subjects = append(subjects, subject)
+	}
+	return subjects
+}
+
+// Descendants returns a subject together with every term below it
+func (s *SubjectService) Descendants(id int) map[int]bool {
+	narrower := s.narrower(s.repo.GetAllSubjects())
+
+	found := map[int]bool{id: true}
+	queue := []int{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range narrower[current] {
+			if !found[child] {
+				found[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return found
+}
+
+// Ancestors returns a subject followed by its broader terms up to the top of the tree
+func (s *SubjectService) Ancestors(id int) []int {
+	var chain []int
+	seen := make(map[int]bool)
+	for id != 0 && !seen[id] {
+		subject, err := s.repo.GetSubjectByID(id)
+		if err != nil {
+			break
+		}
+		seen[id] = true
+		chain = append(chain, id)
+		id = subject.BroaderID
+	}
+	return chain
+}
+
+// Exists returns ErrUnknownSubject if any of the IDs is not a subject
+func (s *SubjectService) Exists(ids []int) error {
+	for _, id := range ids {
+		if _, err := s.repo.GetSubjectByID(id); err != nil {
+			return ErrUnknownSubject
+		}
+	}
+	return nil
+}
+
+// validate normalises a subject and checks its broader term and name; s.mu must be held
+func (s *SubjectService) validate(subject model.Subject) (model.Subject, error) {
+	subject.Name = strings.TrimSpace(subject.Name)
+	if subject.Kind == "" {
+		subject.Kind = model.SubjectKindTopic
+	}
+	if subject.Name == "" || (subject.Kind != model.SubjectKindTopic && subject.Kind != model.SubjectKindGenre) {
+		return model.Subject{}, ErrSubjectInvalid
+	}
+	if subject.BroaderID != 0 {
+		if _, err := s.repo.GetSubjectByID(subject.BroaderID); err != nil {
+			return model.Subject{}, ErrUnknownSubject
+		}
+	}
+	for _, existing := range s.repo.GetAllSubjects() {
+		if existing.ID != subject.ID && existing.BroaderID == subject.BroaderID && strings.EqualFold(existing.Name, subject.Name) {
+			return model.Subject{}, ErrSubjectDuplicate
+		}
+	}
+	return subject, nil
+}
+
+// narrower maps each subject ID to the IDs of the terms directly below it
+func (s *SubjectService) narrower(subjects []model.Subject) map[int][]int {
+	children := make(map[int][]int)
+	for _, subject := range subjects {
+		if subject.BroaderID != 0 {
+			children[subject.BroaderID] = append(children[subject.BroaderID], subject.ID)
+		}
+	}
+	return children
+}
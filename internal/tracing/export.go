@@ -0,0 +1,397 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Batching of finished spans
+const (
+	queueSize     = 2048
+	maxBatch      = 512
+	flushInterval = 5 * time.Second
+
+	// failureLogInterval spaces out the warnings of failed exports, so a collector
+	// that is down does not flood the log
+	failureLogInterval = time.Minute
+)
+
+// SpanData is a finished span as handed to an exporter
+type SpanData struct {
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	Parent     SpanID // Zero for the root of a trace
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Failed     bool
+	Message    string // Error of a failed span
+}
+
+// Exporter sends batches of finished spans of a service somewhere
+type Exporter interface {
+	Export(ctx context.Context, service string, spans []SpanData) error
+}
+
+// batcher queues finished spans and exports them in the background, in batches of up to
+// maxBatch or every flushInterval, so ending a span never waits on the network
+type batcher struct {
+	service  string
+	exporter Exporter
+	logger   *slog.Logger
+	queue    chan SpanData
+	done     chan struct{}
+
+	// Failed exports not yet warned about, and when the last warning was; only run
+	// touches these
+	failures int
+	warned   time.Time
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int // Spans lost to a full queue or a failed export
+}
+
+func newBatcher(service string, exporter Exporter, logger *slog.Logger) *batcher {
+	b := &batcher{service: service, exporter: exporter, logger: logger, queue: make(chan SpanData, queueSize), done: make(chan struct{})}
+	if exporter == nil {
+		close(b.done)
+		b.closed = true
+		return b
+	}
+	go b.run()
+	return b
+}
+
+// add queues a span, dropping it when the queue is full or the batcher has shut down
+func (b *batcher) add(span SpanData) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	select {
+	case b.queue <- span:
+	default:
+		b.dropped++
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []SpanData
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// Spans are diagnostics: a collector that is down must not hold up the server
+		ctx, cancel := context.WithTimeout(context.Background(), flushInterval)
+		if err := b.exporter.Export(ctx, b.service, batch); err != nil {
+			b.exportFailed(err, len(batch))
+		}
+		cancel()
+		batch = nil
+	}
+	for {
+		select {
+		case span, ok := <-b.queue:
+			if !ok {
+				flush()
+				b.warn(nil)
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= maxBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// exportFailed counts the spans of a failed export as dropped and warns about it, at
+// most once per failureLogInterval
+func (b *batcher) exportFailed(err error, spans int) {
+	b.mu.Lock()
+	b.dropped += spans
+	b.mu.Unlock()
+	b.failures++
+	if time.Since(b.warned) >= failureLogInterval {
+		b.warn(err)
+	}
+}
+
+// warn logs the failed exports not yet warned about, with the error of the last one
+// when there is one
+func (b *batcher) warn(err error) {
+	if b.failures == 0 {
+		return
+	}
+	attributes := []interface{}{"failures", b.failures, "dropped_total", b.droppedSpans()}
+	if err != nil {
+		attributes = append(attributes, "error", err)
+	}
+	b.logger.Warn("exporting spans failed", attributes...)
+	b.failures, b.warned = 0, time.Now()
+}
+
+// droppedSpans returns the number of spans lost so far
+func (b *batcher) droppedSpans() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// shutdown stops taking spans and waits until the queued ones are exported
+func (b *batcher) shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OTLPExporter posts spans to an OpenTelemetry collector as OTLP/HTTP JSON
+type OTLPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOTLPExporter creates an exporter for a collector's traces endpoint, such as
+// http://localhost:4318/v1/traces
+func NewOTLPExporter(endpoint string, timeout time.Duration) *OTLPExporter {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &OTLPExporter{endpoint: endpoint, client: &http.Client{Timeout: timeout}}
+}
+
+// Export posts one request for the batch
+func (e *OTLPExporter) Export(ctx context.Context, service string, spans []SpanData) error {
+	payload, err := json.Marshal(encode(service, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// WriterExporter writes each batch as a line of OTLP JSON, e.g. to stdout
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter creates an exporter writing to w
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// Export writes the batch
+func (e *WriterExporter) Export(ctx context.Context, service string, spans []SpanData) error {
+	line, err := json.Marshal(encode(service, spans))
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(line, '\n'))
+	return err
+}
+
+// ExportRequest is the body of an OTLP/HTTP JSON trace export
+type ExportRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+// ResourceSpans are the spans of one service
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+// Resource describes the service that recorded the spans
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+// ScopeSpans are the spans of one instrumentation library
+type ScopeSpans struct {
+	Scope Scope      `json:"scope"`
+	Spans []OTLPSpan `json:"spans"`
+}
+
+// Scope names the instrumentation library
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// OTLPSpan is a span in OTLP JSON, where IDs are hex and times are nanoseconds as strings
+type OTLPSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              SpanKind   `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Status            Status     `json:"status"`
+}
+
+// Status codes of a span, as in OTLP
+const (
+	StatusUnset = 0
+	StatusError = 2
+)
+
+// Status says whether a span failed
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// KeyValue is an attribute in OTLP JSON
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue holds exactly one of its fields; 64-bit integers are strings in OTLP JSON
+type AnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// Value returns the value held, for tests and debugging
+func (v AnyValue) Value() interface{} {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.IntValue != nil:
+		n, _ := strconv.ParseInt(*v.IntValue, 10, 64)
+		return n
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	}
+	return nil
+}
+
+// scopeName identifies this package as the instrumentation library
+const scopeName = "LibraryGo/internal/tracing"
+
+func encode(service string, spans []SpanData) ExportRequest {
+	encoded := make([]OTLPSpan, 0, len(spans))
+	for _, span := range spans {
+		otlp := OTLPSpan{
+			TraceID:           span.Context.TraceID.String(),
+			SpanID:            span.Context.SpanID.String(),
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        keyValues(span.Attributes),
+		}
+		if span.Parent.IsValid() {
+			otlp.ParentSpanID = span.Parent.String()
+		}
+		if span.Failed {
+			otlp.Status = Status{Code: StatusError, Message: span.Message}
+		}
+		encoded = append(encoded, otlp)
+	}
+	return ExportRequest{ResourceSpans: []ResourceSpans{{
+		Resource:   Resource{Attributes: keyValues([]Attribute{String("service.name", service)})},
+		ScopeSpans: []ScopeSpans{{Scope: Scope{Name: scopeName}, Spans: encoded}},
+	}}}
+}
+
+func keyValues(attributes []Attribute) []KeyValue {
+	var values []KeyValue
+	for _, attribute := range attributes {
+		var value AnyValue
+		switch v := attribute.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			value.DoubleValue = &v
+		case bool:
+			value.BoolValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		values = append(values, KeyValue{Key: attribute.Key, Value: value})
+	}
+	return values
+}
+
+// Exporter names accepted by NewExporter
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// NewExporter creates the exporter named by kind: "none" or "" for none, "otlp" for a
+// collector at endpoint, or "stdout" to write to stdout
+func NewExporter(kind, endpoint string, stdout io.Writer) (Exporter, error) {
+	switch strings.ToLower(kind) {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterOTLP:
+		if endpoint == "" {
+			return nil, errors.New("the otlp exporter needs an endpoint")
+		}
+		return NewOTLPExporter(endpoint, 0), nil
+	case ExporterStdout:
+		return NewWriterExporter(stdout), nil
+	}
+	return nil, fmt.Errorf("exporter %q: must be none, otlp or stdout", kind)
+}
@@ -0,0 +1,274 @@
+// Package tracing records spans of the work done for each request and exports them
+// in the OpenTelemetry protocol, so traces can be followed across the server and the
+// services calling it. Trace context travels in W3C traceparent headers.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the W3C header carrying the trace context between services
+const TraceparentHeader = "traceparent"
+
+// TraceID identifies a trace, shared by all of its spans
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID is set; all zeros is invalid
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// IsValid reports whether the ID is set; all zeros is invalid
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext is the part of a span that is passed on to other services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the span context as a version 00 traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ErrInvalidTraceparent is returned for headers that are not a usable traceparent
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent reads a traceparent header. Later versions are accepted as long as
+// they start with the fields of version 00, as the W3C recommendation asks.
+func ParseTraceparent(header string) (SpanContext, error) {
+	header = strings.TrimSpace(header)
+	parts := strings.Split(header, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	var version, flags [1]byte
+	var sc SpanContext
+	for _, field := range []struct {
+		dst []byte
+		src string
+	}{{version[:], parts[0]}, {sc.TraceID[:], parts[1]}, {sc.SpanID[:], parts[2]}, {flags[:], parts[3]}} {
+		// Upper case hex is not allowed
+		if strings.ToLower(field.src) != field.src {
+			return SpanContext{}, ErrInvalidTraceparent
+		}
+		if _, err := hex.Decode(field.dst, []byte(field.src)); err != nil {
+			return SpanContext{}, ErrInvalidTraceparent
+		}
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// SpanKind says what side of a call a span is on
+type SpanKind int
+
+// Span kinds, numbered as in OTLP
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// Attribute is a key and a string, integer, float or boolean value
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String creates a string attribute
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int creates an integer attribute
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Bool creates a boolean attribute
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Span is an operation being traced. All methods can be called on a nil span, which is
+// what Start returns outside a trace, so callers never have to check.
+type Span struct {
+	tracer  *Tracer
+	name    string
+	kind    SpanKind
+	context SpanContext
+	parent  SpanID
+	start   time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes []Attribute
+	failed     bool
+	message    string
+	ended      bool
+}
+
+// SpanContext returns the IDs of the span
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes = append(s.attributes, attributes...)
+}
+
+// SetError marks the span as failed with err; a nil err leaves it alone
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.message = err.Error()
+}
+
+// End finishes the span and hands it to the exporter if the trace is sampled.
+// Only the first call has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = s.tracer.now()
+	s.mu.Unlock()
+	if s.context.Sampled {
+		s.tracer.batch.add(s.data())
+	}
+}
+
+// data copies the finished span for export
+func (s *Span) data() SpanData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SpanData{
+		Name:       s.name,
+		Kind:       s.kind,
+		Context:    s.context,
+		Parent:     s.parent,
+		Start:      s.start,
+		End:        s.end,
+		Attributes: append([]Attribute(nil), s.attributes...),
+		Failed:     s.failed,
+		Message:    s.message,
+	}
+}
+
+// Tracer starts spans and exports the sampled ones once they end
+type Tracer struct {
+	service string
+	batch   *batcher
+	now     func() time.Time
+}
+
+// NewTracer creates a tracer for a service. A nil exporter still propagates trace
+// context but keeps no spans. Failed exports are logged to logger.
+func NewTracer(service string, exporter Exporter, logger *slog.Logger) *Tracer {
+	return &Tracer{service: service, batch: newBatcher(service, exporter, logger), now: time.Now}
+}
+
+// Dropped returns the number of finished spans lost because the queue was full or
+// their export failed
+func (t *Tracer) Dropped() int {
+	return t.batch.droppedSpans()
+}
+
+// Shutdown exports the spans still queued and stops the exporter; spans ending
+// afterwards are dropped
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.batch.shutdown(ctx)
+}
+
+// Start begins a span as a child of the span or remote parent in ctx, or as the root of
+// a new trace, and returns a context carrying it
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attributes ...Attribute) (context.Context, *Span) {
+	span := &Span{tracer: t, name: name, kind: kind, start: t.now(), attributes: attributes}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.context.TraceID = parent.context.TraceID
+		span.context.Sampled = parent.context.Sampled
+		span.parent = parent.context.SpanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		span.context.TraceID = remote.TraceID
+		span.context.Sampled = remote.Sampled
+		span.parent = remote.SpanID
+	} else {
+		rand.Read(span.context.TraceID[:])
+		span.context.Sampled = true
+	}
+	rand.Read(span.context.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Start begins an internal span as a child of the span in ctx, with the same tracer.
+// Outside a trace it returns ctx and a nil span.
+func Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, KindInternal, attributes...)
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// SpanFromContext returns the span of ctx, or nil outside a trace
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// WithRemoteParent returns a copy of ctx whose next span continues the trace of a
+// span in another service
+func WithRemoteParent(ctx context.Context, parent SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, parent)
+}
+
+// Inject sets the traceparent header for a call made within the span of ctx
+func Inject(ctx context.Context, header interface{ Set(key, value string) }) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set(TraceparentHeader, span.context.Traceparent())
+	}
+}
@@ -2,11 +2,10 @@ package utils
 
 import (
     "LibraryGo/internal/model"
+    "LibraryGo/internal/requestid"
     "encoding/json"
     "net/http"
     "time"
-
-    "github.com/google/uuid"
 )
 
 // ResponseBuilder helps construct API responses
@@ -18,7 +17,7 @@ type ResponseBuilder struct {
 func NewResponse() *ResponseBuilder {
     return &ResponseBuilder{
         response: model.APIResponse{
-            RequestID: uuid.New().String(),
+            RequestID: requestid.New(),
             Timestamp: time.Now(),
         },
     }
@@ -61,9 +60,13 @@ func (rb *ResponseBuilder) WithMeta(meta *model.MetaData) *ResponseBuilder {
     return rb
 }
 
-// Send writes the response to http.ResponseWriter
+// Send writes the response to http.ResponseWriter, under the request's ID when
+// the RequestID middleware has set one
 func (rb *ResponseBuilder) Send(w http.ResponseWriter, statusCode int) error {
     rb.WithStatus(statusCode) // Set the status code in the response
+    if id := w.Header().Get(requestid.Header); id != "" {
+        rb.response.RequestID = id
+    }
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(statusCode)
     return json.NewEncoder(w).Encode(rb.response)
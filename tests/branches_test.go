@@ -0,0 +1,186 @@
+package handler
+
+import (
+    "bytes"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+    "github.com/gorilla/mux"
+)
+
+// doJSON sends a request with an optional JSON body and decodes the API response
+func doJSON(t *testing.T, r *mux.Router, method, url string, body interface{}) (int, model.APIResponse) {
+    var payload bytes.Buffer
+    if body != nil {
+        json.NewEncoder(&payload).Encode(body)
+    }
+    req, _ := http.NewRequest(method, url, &payload)
+    req.Header.Set("Content-Type", "application/json")
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+
+    var response model.APIResponse
+    if w.Code != http.StatusNoContent {
+        if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+            t.Fatalf("Failed to decode response of %s %s: %v", method, url, err)
+        }
+    }
+    return w.Code, response
+}
+
+// decodeData converts the generic response data into a typed value
+func decodeData(t *testing.T, response model.APIResponse, out interface{}) {
+    raw, _ := json.Marshal(response.Data)
+    if err := json.Unmarshal(raw, out); err != nil {
+        t.Fatalf("Failed to decode response data: %v", err)
+    }
+}
+
+func TestBranchesAndTransfers(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+
+    var central, east model.Branch
+    _, response := doJSON(t, r, "POST", "/branches", model.Branch{Code: "CEN", Name: "Central"})
+    decodeData(t, response, &central)
+    _, response = doJSON(t, r, "POST", "/branches", model.Branch{Code: "EST", Name: "East"})
+    decodeData(t, response, &east)
+
+    if code, _ := doJSON(t, r, "POST", "/branches", model.Branch{Code: "cen", Name: "Duplicate"}); code != http.StatusBadRequest {
+        t.Errorf("Expected duplicate branch code to be rejected but got %d", code)
+    }
+
+    var first model.Copy
+    code, response := doJSON(t, r, "POST", "/books/1/copies", map[string]interface{}{"barcode": "B-001", "homeBranchId": central.ID})
+    if code != http.StatusCreated {
+        t.Fatalf("Expected status code %d but got %d", http.StatusCreated, code)
+    }
+    decodeData(t, response, &first)
+    doJSON(t, r, "POST", "/books/1/copies", map[string]interface{}{"barcode": "B-002", "homeBranchId": east.ID})
+    if code, _ := doJSON(t, r, "POST", "/books/2/copies", map[string]interface{}{"barcode": "B-001", "homeBranchId": east.ID}); code != http.StatusConflict {
+        t.Errorf("Expected duplicate barcode to conflict but got %d", code)
+    }
+
+    _, response = doJSON(t, r, "GET", "/books?branch=2", nil)
+    var atEast []model.Book
+    decodeData(t, response, &atEast)
+    if len(atEast) != 1 || atEast[0].ID != 1 || len(atEast[0].Availability) != 2 {
+        t.Fatalf("Expected book 1 with availability at two branches but got %+v", atEast)
+    }
+
+    var transfer model.Transfer
+    code, response = doJSON(t, r, "POST", "/transfers", map[string]interface{}{"copyId": first.ID, "toBranchId": east.ID})
+    if code != http.StatusCreated {
+        t.Fatalf("Expected status code %d but got %d", http.StatusCreated, code)
+    }
+    decodeData(t, response, &transfer)
+
+    if code, _ := doJSON(t, r, "POST", "/transfers/1/receive", nil); code != http.StatusConflict {
+        t.Errorf("Expected receiving an unshipped transfer to conflict but got %d", code)
+    }
+    doJSON(t, r, "POST", "/transfers/1/ship", nil)
+
+    var book model.Book
+    _, response = doJSON(t, r, "GET", "/books/1", nil)
+    decodeData(t, response, &book)
+    if len(book.Availability) != 1 || book.Availability[0].InTransit != 1 || book.Availability[0].Available != 1 {
+        t.Errorf("Expected one copy available and one in transit at East but got %+v", book.Availability)
+    }
+
+    code, response = doJSON(t, r, "POST", "/transfers/1/receive", nil)
+    decodeData(t, response, &transfer)
+    if code != http.StatusOK || transfer.Status != model.TransferStatusReceived {
+        t.Errorf("Expected received transfer but got %d %+v", code, transfer)
+    }
+
+    _, response = doJSON(t, r, "GET", "/books?branch=1", nil)
+    if response.Meta.Count != 0 {
+        t.Errorf("Expected no books at Central after the transfer but got %d", response.Meta.Count)
+    }
+}
+
+func TestCheckoutWithCopies(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+
+    doJSON(t, r, "POST", "/branches", model.Branch{Code: "CEN", Name: "Central"})
+    doJSON(t, r, "POST", "/books/1/copies", map[string]interface{}{"barcode": "B-001", "homeBranchId": 1})
+    doJSON(t, r, "POST", "/books/1/copies", map[string]interface{}{"barcode": "B-002", "homeBranchId": 1})
+    for _, name := range []string{"Ann", "Ben", "Cid"} {
+        doJSON(t, r, "POST", "/patrons", model.Patron{Name: name})
+    }
+
+    // Two copies serve two patrons; the third one has to wait
+    for patronID := 1; patronID <= 2; patronID++ {
+        code, response := doJSON(t, r, "POST", "/loans", map[string]int{"bookId": 1, "patronId": patronID})
+        var loan model.Loan
+        decodeData(t, response, &loan)
+        if code != http.StatusCreated || loan.CopyID == 0 {
+            t.Fatalf("Expected loan with a copy for patron %d but got %d %+v", patronID, code, loan)
+        }
+    }
+    if code, _ := doJSON(t, r, "POST", "/loans", map[string]int{"bookId": 1, "patronId": 3}); code != http.StatusConflict {
+        t.Errorf("Expected checkout without free copies to conflict but got %d", code)
+    }
+
+    var hold model.Hold
+    _, response := doJSON(t, r, "POST", "/holds", map[string]int{"bookId": 1, "patronId": 3})
+    decodeData(t, response, &hold)
+    if hold.Status != model.HoldStatusPending {
+        t.Fatalf("Expected pending hold but got %s", hold.Status)
+    }
+
+    doJSON(t, r, "POST", "/loans/1/return", nil)
+    _, response = doJSON(t, r, "GET", "/holds/1", nil)
+    decodeData(t, response, &hold)
+    if hold.Status != model.HoldStatusReady {
+        t.Errorf("Expected hold to be ready after a copy came back but got %s", hold.Status)
+    }
+}
+
+func TestCheckoutRacesTransfer(t *testing.T) {
+    for round := 0; round < 20; round++ {
+        r := router.SetupRouter()
+        setupTestBooks(t, r)
+        doJSON(t, r, "POST", "/branches", model.Branch{Code: "CEN", Name: "Central"})
+        doJSON(t, r, "POST", "/branches", model.Branch{Code: "EST", Name: "East"})
+        doJSON(t, r, "POST", "/books/1/copies", map[string]interface{}{"barcode": "B-001", "homeBranchId": 1})
+        doJSON(t, r, "POST", "/patrons", model.Patron{Name: "Ann"})
+        doJSON(t, r, "POST", "/transfers", map[string]int{"copyId": 1, "toBranchId": 2})
+
+        // Lending the copy and shipping it away both start from an available copy;
+        // only one of them may win
+        requests := []*http.Request{
+            httptest.NewRequest("POST", "/loans", bytes.NewBufferString(`{"bookId":1,"patronId":1}`)),
+            httptest.NewRequest("POST", "/transfers/1/ship", nil),
+        }
+        codes := make([]int, len(requests))
+        var wg sync.WaitGroup
+        for i, req := range requests {
+            wg.Add(1)
+            go func(i int, req *http.Request) {
+                defer wg.Done()
+                w := httptest.NewRecorder()
+                r.ServeHTTP(w, req)
+                codes[i] = w.Code
+            }(i, req)
+        }
+        wg.Wait()
+
+        var copies []model.Copy
+        _, response := doJSON(t, r, "GET", "/books/1/copies", nil)
+        decodeData(t, response, &copies)
+        lent, shipped := codes[0] == http.StatusCreated, codes[1] == http.StatusOK
+        switch {
+        case lent == shipped:
+            t.Fatalf("Expected exactly one of checkout and shipping to succeed but got %v", codes)
+        case lent && copies[0].Status != model.CopyStatusOnLoan,
+            shipped && copies[0].Status != model.CopyStatusInTransit:
+            t.Fatalf("Expected the copy to reflect the winner of %v but got %+v", codes, copies[0])
+        }
+    }
+}
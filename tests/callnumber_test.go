@@ -0,0 +1,82 @@
+package handler
+
+import (
+    "net/http"
+    "testing"
+    "LibraryGo/internal/callnumber"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+func TestCallNumberShelfOrder(t *testing.T) {
+    // Each list is in shelf order, which plain string sorting gets wrong
+    shelves := [][]string{
+        {"005.133 S54", "020 A12", "500 B1", "510.2 C3", "510.24 C3", "599.9 D4", "823.914 R69 2003", "823.914 R69h"},
+        {"QA9 .F6", "QA76 .A1", "QA76.73.G63 D66 2016", "QA76.9 .B48", "QA76.9 .B5", "QB1 .A2", "Z1 .B3"},
+        {"QA76.73.G63 v.2", "QA76.73.G63 v.10"},
+    }
+    for _, shelf := range shelves {
+        for i := 1; i < len(shelf); i++ {
+            a, err := callnumber.Parse(shelf[i-1])
+            if err != nil {
+                t.Fatalf("Failed to parse %q: %v", shelf[i-1], err)
+            }
+            b, err := callnumber.Parse(shelf[i])
+            if err != nil {
+                t.Fatalf("Failed to parse %q: %v", shelf[i], err)
+            }
+            if !callnumber.Less(a, b) {
+                t.Errorf("Expected %q to shelve before %q", shelf[i-1], shelf[i])
+            }
+        }
+    }
+
+    for _, invalid := range []string{"", "12.5", "ABCD12", "QA76x"} {
+        if _, err := callnumber.Parse(invalid); err == nil {
+            t.Errorf("Expected %q to be rejected", invalid)
+        }
+    }
+}
+
+func TestBooksByCallNumber(t *testing.T) {
+    r := router.SetupRouter()
+    for _, callNumber := range []string{"599.9 D4", "60 X", "510 C3", "QA76.9 .B5", "", "500 B1", "600 E5"} {
+        book := model.Book{Title: "Book", Author: "Author", PublishedYear: 2020, CallNumber: callNumber}
+        code, _ := doJSON(t, r, "POST", "/books", book)
+        if callNumber == "60 X" {
+            if code != http.StatusBadRequest {
+                t.Errorf("Expected invalid call number to be rejected but got %d", code)
+            }
+            continue
+        }
+        if code != http.StatusCreated {
+            t.Fatalf("Failed to add book with call number %q: %d", callNumber, code)
+        }
+    }
+
+    var books []model.Book
+    _, response := doJSON(t, r, "GET", "/books?sort=callNumber", nil)
+    decodeData(t, response, &books)
+    want := []string{"500 B1", "510 C3", "599.9 D4", "600 E5", "QA76.9 .B5", ""}
+    if len(books) != len(want) {
+        t.Fatalf("Expected %d books but got %d", len(want), len(books))
+    }
+    for i, book := range books {
+        if book.CallNumber != want[i] {
+            t.Errorf("Expected %q at position %d but got %q", want[i], i, book.CallNumber)
+        }
+    }
+
+    _, response = doJSON(t, r, "GET", "/books?sort=callNumber&callNumberRange=500%E2%80%93599", nil)
+    decodeData(t, response, &books)
+    if len(books) != 3 || books[0].CallNumber != "500 B1" || books[2].CallNumber != "599.9 D4" {
+        t.Errorf("Expected the three books in 500-599 but got %+v", books)
+    }
+
+    if code, _ := doJSON(t, r, "GET", "/books?sort=color", nil); code != http.StatusBadRequest {
+        t.Errorf("Expected unknown sort to be rejected but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "GET", "/books?callNumberRange=599-500", nil); code != http.StatusBadRequest {
+        t.Errorf("Expected reversed range to be rejected but got %d", code)
+    }
+}
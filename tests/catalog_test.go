@@ -0,0 +1,169 @@
+package handler
+
+import (
+    "archive/zip"
+    "bytes"
+    "encoding/json"
+    "net/http"
+    "strings"
+    "testing"
+    "LibraryGo/internal/epub"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+// opfTemplate is an EPUB 3 package document; TITLE is replaced per test
+const opfTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="uid">urn:uuid:2f1c6a0e-8d7b-4c5e-9a3f-1b2c3d4e5f60</dc:identifier>
+    <dc:identifier id="isbn">978-0-306-40615-7</dc:identifier>
+    <meta refines="#isbn" property="identifier-type" scheme="onix:codelist5">15</meta>
+    <dc:title id="t1">TITLE</dc:title>
+    <meta refines="#t1" property="title-type">main</meta>
+    <dc:title id="t2">A Subtitle</dc:title>
+    <meta refines="#t2" property="title-type">subtitle</meta>
+    <dc:creator id="c1">Ada Writer</dc:creator>
+    <meta refines="#c1" property="role" scheme="marc:relators">aut</meta>
+    <dc:creator id="c2">Ian Illustrator</dc:creator>
+    <meta refines="#c2" property="role" scheme="marc:relators">ill</meta>
+    <dc:date>2019-05-01</dc:date>
+    <dc:language>EN</dc:language>
+    <dc:subject>Science Fiction</dc:subject>
+  </metadata>
+</package>`
+
+// testPackagedEPUB builds an EPUB whose package document carries the given title
+func testPackagedEPUB(t *testing.T, title string) []byte {
+    return packagedEPUB(t, title, "OEBPS/content.opf")
+}
+
+// packagedEPUB builds an EPUB whose container names the package document by fullPath
+func packagedEPUB(t *testing.T, title, fullPath string) []byte {
+    var buf bytes.Buffer
+    archive := zip.NewWriter(&buf)
+    mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+    if err != nil {
+        t.Fatalf("Failed to build EPUB: %v", err)
+    }
+    mimetype.Write([]byte("application/epub+zip"))
+    container, _ := archive.Create("META-INF/container.xml")
+    container.Write([]byte(`<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="` + fullPath + `" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`))
+    opf, _ := archive.Create("OEBPS/content.opf")
+    opf.Write([]byte(strings.Replace(opfTemplate, "TITLE", title, 1)))
+    archive.Close()
+    return buf.Bytes()
+}
+
+func TestReadEPUBMetadata(t *testing.T) {
+    metadata, err := epub.ReadMetadata(testPackagedEPUB(t, "The Long Orbit"))
+    if err != nil {
+        t.Fatalf("Failed to read metadata: %v", err)
+    }
+    if metadata.Title != "The Long Orbit" || len(metadata.Creators) != 1 || metadata.Creators[0] != "Ada Writer" {
+        t.Errorf("Expected the main title and the author only but got %+v", metadata)
+    }
+    if metadata.Language != "en" || metadata.Date != "2019-05-01" || len(metadata.Identifiers) != 2 {
+        t.Errorf("Expected language, date and both identifiers but got %+v", metadata)
+    }
+    // Some packagers write the path from the archive root with a leading slash
+    if metadata, err := epub.ReadMetadata(packagedEPUB(t, "The Long Orbit", "/OEBPS/content.opf")); err != nil || metadata.Title != "The Long Orbit" {
+        t.Errorf("Expected a rooted package path to be found but got %+v (%v)", metadata, err)
+    }
+    if _, err := epub.ReadMetadata(testEPUB(t)); err != epub.ErrNoPackage {
+        t.Errorf("Expected an EPUB without container to be rejected but got %v", err)
+    }
+}
+
+func TestImportEPUB(t *testing.T) {
+    r := router.SetupRouter()
+
+    // A new title is catalogued from its metadata
+    w := upload(r, "POST", "/books/import?fileName=orbit.epub", "application/epub+zip", testPackagedEPUB(t, "The Long Orbit"))
+    var response model.APIResponse
+    json.NewDecoder(w.Body).Decode(&response)
+    var created model.ImportResult
+    decodeData(t, response, &created)
+    if w.Code != http.StatusCreated || created.Action != model.ImportCreated || created.Asset == nil {
+        t.Fatalf("Expected the book to be created but got %d %s", w.Code, w.Body)
+    }
+    book := created.Book
+    if book.Title != "The Long Orbit" || book.Author != "Ada Writer" || book.PublishedYear != 2019 ||
+        book.ISBN != "9780306406157" || book.Language != "en" || len(book.Tags) != 1 {
+        t.Errorf("Expected the book to carry the file's metadata but got %+v", book)
+    }
+
+    // The same file again changes nothing but adds another copy of the file
+    w = upload(r, "POST", "/books/import", "application/epub+zip", testPackagedEPUB(t, "The Long Orbit"))
+    if w.Code != http.StatusOK {
+        t.Errorf("Expected an unchanged import but got %d %s", w.Code, w.Body)
+    }
+
+    // A corrected title matched by ISBN is proposed, not applied
+    w = upload(r, "POST", "/books/import", "application/epub+zip", testPackagedEPUB(t, "The Longest Orbit"))
+    response = model.APIResponse{}
+    json.NewDecoder(w.Body).Decode(&response)
+    var proposed model.ImportResult
+    decodeData(t, response, &proposed)
+    if w.Code != http.StatusAccepted || proposed.Proposal == nil || len(proposed.Proposal.Changes) != 1 ||
+        proposed.Proposal.Changes[0].Field != "title" || proposed.Book.Title != "The Long Orbit" {
+        t.Fatalf("Expected a title change awaiting approval but got %d %s", w.Code, w.Body)
+    }
+
+    code, response := doJSON(t, r, "POST", "/proposals/1/approve", nil)
+    var approved model.Book
+    decodeData(t, response, &approved)
+    if code != http.StatusOK || approved.Title != "The Longest Orbit" {
+        t.Fatalf("Expected the title to be updated but got %d %+v", code, approved)
+    }
+    if code, _ := doJSON(t, r, "POST", "/proposals/1/approve", nil); code != http.StatusConflict {
+        t.Errorf("Expected a resolved proposal to stay resolved but got %d", code)
+    }
+
+    // Proposals made against old values go stale
+    upload(r, "POST", "/books/import", "application/epub+zip", testPackagedEPUB(t, "Orbit"))
+    upload(r, "POST", "/books/import?bookId=1", "application/epub+zip", testPackagedEPUB(t, "Orbits"))
+    doJSON(t, r, "POST", "/proposals/2/approve", map[string][]string{"fields": {"title"}})
+    if code, _ := doJSON(t, r, "POST", "/proposals/3/approve", nil); code != http.StatusConflict {
+        t.Errorf("Expected a stale proposal to be refused but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "POST", "/proposals/3/reject", nil); code != http.StatusOK {
+        t.Errorf("Expected a stale proposal to be rejected but got %d", code)
+    }
+
+    var pending []model.MetadataProposal
+    _, response = doJSON(t, r, "GET", "/proposals?status=pending", nil)
+    decodeData(t, response, &pending)
+    if len(pending) != 0 {
+        t.Errorf("Expected no pending proposals but got %+v", pending)
+    }
+    if w := upload(r, "POST", "/books/import", "application/pdf", []byte("%PDF-1.7")); w.Code != http.StatusUnsupportedMediaType {
+        t.Errorf("Expected a PDF to be refused but got %d", w.Code)
+    }
+    if code, _ := doJSON(t, r, "POST", "/books", model.Book{Title: "T", Author: "A", PublishedYear: 2000, ISBN: "978-0-306-40615-8"}); code != http.StatusBadRequest {
+        t.Errorf("Expected a bad ISBN check digit to be refused but got %d", code)
+    }
+}
+
+func TestImportMatchesISBN10(t *testing.T) {
+    r := router.SetupRouter()
+
+    // The book was catalogued by hand with the ISBN-10 of the title the file carries as ISBN-13
+    doJSON(t, r, "POST", "/books", model.Book{Title: "Long Orbit", Author: "Ada Writer", PublishedYear: 2019, ISBN: "0-306-40615-2"})
+    w := upload(r, "POST", "/books/import", "application/epub+zip", testPackagedEPUB(t, "The Long Orbit"))
+    var response model.APIResponse
+    json.NewDecoder(w.Body).Decode(&response)
+    var result model.ImportResult
+    decodeData(t, response, &result)
+    if w.Code != http.StatusAccepted || result.Book.ID != 1 || result.Proposal == nil {
+        t.Fatalf("Expected the file to match the book by ISBN but got %d %s", w.Code, w.Body)
+    }
+    for _, change := range result.Proposal.Changes {
+        if change.Field == "isbn" {
+            t.Errorf("Expected no change between two forms of the same ISBN but got %+v", change)
+        }
+    }
+}
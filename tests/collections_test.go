@@ -0,0 +1,218 @@
+package handler
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+// entryBookIDs lists the book IDs of a collection in order
+func entryBookIDs(collection model.Collection) []int {
+    ids := []int{}
+    for _, entry := range collection.Entries {
+        ids = append(ids, entry.BookID)
+    }
+    return ids
+}
+
+func TestCollections(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+    for _, name := range []string{"Ms Reed", "Ben"} {
+        doJSON(t, r, "POST", "/patrons", model.Patron{Name: name})
+    }
+
+    newList := model.Collection{
+        Title:   "Summer reading 2026",
+        OwnerID: 1,
+        Entries: []model.CollectionEntry{{BookID: 1, Note: "Start here"}, {BookID: 2}},
+    }
+    code, response := doJSON(t, r, "POST", "/collections", newList)
+    var list model.Collection
+    decodeData(t, response, &list)
+    if code != http.StatusCreated || list.Visibility != model.VisibilityPrivate || list.Entries[0].Book == nil {
+        t.Fatalf("Expected a private list with resolved books but got %d %+v", code, list)
+    }
+
+    // Entries can be inserted at a position and reordered
+    var updated model.Collection
+    _, response = doJSON(t, r, "POST", "/collections/1/entries", map[string]interface{}{"bookId": 3, "position": 1})
+    decodeData(t, response, &updated)
+    if ids := entryBookIDs(updated); !equalInts(ids, []int{3, 1, 2}) {
+        t.Errorf("Expected book 3 first but got %v", ids)
+    }
+    if code, _ := doJSON(t, r, "POST", "/collections/1/entries", map[string]int{"bookId": 3}); code != http.StatusConflict {
+        t.Errorf("Expected a duplicate entry to conflict but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "PUT", "/collections/1/order", map[string][]int{"bookIds": {1, 2}}); code != http.StatusBadRequest {
+        t.Errorf("Expected an incomplete order to be rejected but got %d", code)
+    }
+    var reordered model.Collection
+    _, response = doJSON(t, r, "PUT", "/collections/1/order", map[string][]int{"bookIds": {2, 1, 3}})
+    decodeData(t, response, &reordered)
+    if ids := entryBookIDs(reordered); !equalInts(ids, []int{2, 1, 3}) || reordered.Entries[1].Note != "Start here" {
+        t.Errorf("Expected order 2, 1, 3 keeping notes but got %v %+v", ids, reordered.Entries)
+    }
+
+    // A share link opens a private list; revoking it closes the link again
+    var shared model.Collection
+    _, response = doJSON(t, r, "POST", "/collections/1/share", nil)
+    decodeData(t, response, &shared)
+    if shared.ShareToken == "" {
+        t.Fatal("Expected a share token")
+    }
+    if code, _ := doJSON(t, r, "GET", "/collections/shared/"+shared.ShareToken, nil); code != http.StatusOK {
+        t.Errorf("Expected the share link to open the list but got %d", code)
+    }
+    doJSON(t, r, "DELETE", "/collections/1/share", nil)
+    if code, _ := doJSON(t, r, "GET", "/collections/shared/"+shared.ShareToken, nil); code != http.StatusNotFound {
+        t.Errorf("Expected a revoked link to be gone but got %d", code)
+    }
+
+    doJSON(t, r, "PUT", "/collections/1", model.Collection{Title: "Summer reading 2026", Visibility: model.VisibilityPublic})
+    _, response = doJSON(t, r, "GET", "/collections?ownerId=1", nil)
+    if response.Meta.Total != 1 {
+        t.Errorf("Expected the list to be public but got %d lists", response.Meta.Total)
+    }
+
+    // Deleting a book keeps its place on the list, marked as removed
+    doJSON(t, r, "DELETE", "/books/1", nil)
+    var afterDelete model.Collection
+    _, response = doJSON(t, r, "GET", "/collections/1", nil)
+    decodeData(t, response, &afterDelete)
+    entry := afterDelete.Entries[1]
+    if entry.BookID != 1 || !entry.Removed || entry.Title != "Test Book 1" || entry.Book != nil {
+        t.Errorf("Expected a removed entry for the deleted book but got %+v", entry)
+    }
+    if code, _ := doJSON(t, r, "POST", "/collections/1/entries", map[string]int{"bookId": 1}); code != http.StatusNotFound {
+        t.Errorf("Expected adding a deleted book to fail but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "DELETE", "/collections/1/entries/1", nil); code != http.StatusOK {
+        t.Errorf("Expected the removed entry to be deletable but got %d", code)
+    }
+}
+
+// tokenRequest sends a JSON request with a bearer token, or none when token is empty
+func tokenRequest(r *mux.Router, method, url, token string, body interface{}) (int, model.APIResponse) {
+    var payload bytes.Buffer
+    if body != nil {
+        json.NewEncoder(&payload).Encode(body)
+    }
+    req, _ := http.NewRequest(method, url, &payload)
+    req.Header.Set("Content-Type", "application/json")
+    if token != "" {
+        req.Header.Set("Authorization", "Bearer "+token)
+    }
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    var response model.APIResponse
+    json.NewDecoder(w.Body).Decode(&response)
+    return w.Code, response
+}
+
+// memberToken signs an HS256 token for a role, naming the patron when patronID is set
+func memberToken(t *testing.T, secret, subject string, patronID int, role string) string {
+    claims := map[string]interface{}{"sub": subject, "roles": []string{role}, "exp": time.Now().Add(time.Hour).Unix()}
+    if patronID != 0 {
+        claims["patron_id"] = patronID
+    }
+    return signJWT(t, map[string]interface{}{"alg": "HS256"}, claims, func(signed []byte) []byte {
+        mac := hmac.New(sha256.New, []byte(secret))
+        mac.Write(signed)
+        return mac.Sum(nil)
+    })
+}
+
+func TestCollectionOwnership(t *testing.T) {
+    secret := "a shared secret of at least 32 bytes!"
+    cfg := config.Default()
+    cfg.Auth.JWTSecret = secret
+    cfg.RateLimit.Disabled = true
+    r := router.NewApp(cfg).Router
+
+    staff := memberToken(t, secret, "staff-1", 0, "staff")
+    reed := memberToken(t, secret, "reed", 1, "patron")
+    ben := memberToken(t, secret, "ben", 2, "patron")
+
+    tokenRequest(r, "POST", "/books", staff, model.Book{Title: "Test Book 1", Author: "Test Author 1", PublishedYear: 2024})
+    for _, name := range []string{"Ms Reed", "Ben"} {
+        tokenRequest(r, "POST", "/patrons", staff, model.Patron{Name: name})
+    }
+
+    // A patron's list is their own, whatever owner the body names
+    var list model.Collection
+    code, response := tokenRequest(r, "POST", "/collections", reed, model.Collection{Title: "Summer reading", OwnerID: 2})
+    decodeData(t, response, &list)
+    if code != http.StatusCreated || list.OwnerID != 1 {
+        t.Fatalf("Expected the list to belong to the caller but got %d %+v", code, list)
+    }
+
+    // Private lists are hidden from everyone but their owner and staff
+    if code, _ := tokenRequest(r, "GET", "/collections/1", ben, nil); code != http.StatusNotFound {
+        t.Errorf("Expected another patron not to see a private list but got %d", code)
+    }
+    if code, _ := tokenRequest(r, "GET", "/collections/1", reed, nil); code != http.StatusOK {
+        t.Errorf("Expected the owner to see their list but got %d", code)
+    }
+    if code, _ := tokenRequest(r, "GET", "/collections/1", staff, nil); code != http.StatusOK {
+        t.Errorf("Expected staff to see the list but got %d", code)
+    }
+    if code, _ := tokenRequest(r, "GET", "/collections/1", "", nil); code != http.StatusNotFound {
+        t.Errorf("Expected anonymous callers not to see a private list but got %d", code)
+    }
+    if _, response := tokenRequest(r, "GET", "/collections", ben, nil); response.Meta == nil || response.Meta.Total != 0 {
+        t.Errorf("Expected another patron to see no lists but got %+v", response.Meta)
+    }
+    if _, response := tokenRequest(r, "GET", "/collections", reed, nil); response.Meta == nil || response.Meta.Total != 1 {
+        t.Errorf("Expected the owner to see their private list but got %+v", response.Meta)
+    }
+    if code, _ := tokenRequest(r, "POST", "/collections/1/entries", ben, map[string]int{"bookId": 1}); code != http.StatusNotFound {
+        t.Errorf("Expected another patron not to change a private list but got %d", code)
+    }
+
+    // Once public, others can read the list but still not change it
+    tokenRequest(r, "PUT", "/collections/1", reed, model.Collection{Title: "Summer reading", Visibility: model.VisibilityPublic})
+    if code, _ := tokenRequest(r, "GET", "/collections/1", ben, nil); code != http.StatusOK {
+        t.Errorf("Expected a public list to be readable but got %d", code)
+    }
+    if code, _ := tokenRequest(r, "GET", "/collections/1", "", nil); code != http.StatusOK {
+        t.Errorf("Expected anonymous callers to read a public list but got %d", code)
+    }
+    if _, response := tokenRequest(r, "GET", "/collections", "", nil); response.Meta == nil || response.Meta.Total != 1 {
+        t.Errorf("Expected anonymous callers to browse public lists but got %+v", response.Meta)
+    }
+    changes := []struct {
+        method, url string
+        body        interface{}
+    }{
+        {"PUT", "/collections/1", model.Collection{Title: "Mine now"}},
+        {"DELETE", "/collections/1", nil},
+        {"POST", "/collections/1/entries", map[string]int{"bookId": 1}},
+        {"PUT", "/collections/1/order", map[string][]int{"bookIds": {}}},
+        {"POST", "/collections/1/share", nil},
+        {"DELETE", "/collections/1/share", nil},
+    }
+    for _, change := range changes {
+        code, response := tokenRequest(r, change.method, change.url, ben, change.body)
+        if code != http.StatusForbidden || response.Error == nil || response.Error.Code != "FORBIDDEN" {
+            t.Errorf("Expected %s %s by another patron to be forbidden but got %d", change.method, change.url, code)
+        }
+    }
+
+    // The owner and staff can change it
+    if code, _ := tokenRequest(r, "POST", "/collections/1/entries", reed, map[string]int{"bookId": 1}); code != http.StatusOK {
+        t.Errorf("Expected the owner to add an entry but got %d", code)
+    }
+    if code, _ := tokenRequest(r, "DELETE", "/collections/1/entries/1", staff, nil); code != http.StatusOK {
+        t.Errorf("Expected staff to remove an entry but got %d", code)
+    }
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+    "bytes"
+    "encoding/binary"
+    "encoding/json"
+    "hash/crc32"
+    "image"
+    "image/color"
+    "image/jpeg"
+    "image/png"
+    "mime/multipart"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+// testCover draws a cover of the given size
+func testCover(width, height int) image.Image {
+    img := image.NewRGBA(image.Rect(0, 0, width, height))
+    for y := 0; y < height; y++ {
+        for x := 0; x < width; x++ {
+            img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+        }
+    }
+    return img
+}
+
+// pngHeader is the start of a PNG declaring the given size, which is all a size check reads
+func pngHeader(width, height int) []byte {
+    chunk := append([]byte("IHDR"), make([]byte, 13)...)
+    binary.BigEndian.PutUint32(chunk[4:], uint32(width))
+    binary.BigEndian.PutUint32(chunk[8:], uint32(height))
+    chunk[12], chunk[13] = 8, 6 // 8-bit RGBA
+    header := append([]byte("\x89PNG\r\n\x1a\n"), 0, 0, 0, 13)
+    header = append(header, chunk...)
+    return binary.BigEndian.AppendUint32(header, crc32.ChecksumIEEE(chunk))
+}
+
+// upload sends a raw file body
+func upload(r *mux.Router, method, url, contentType string, body []byte) *httptest.ResponseRecorder {
+    req, _ := http.NewRequest(method, url, bytes.NewReader(body))
+    req.Header.Set("Content-Type", contentType)
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    return w
+}
+
+func TestCoverUpload(t *testing.T) {
+    cfg := config.Default()
+    cfg.Storage.Dir = t.TempDir()
+    cfg.Auth.Disabled = true
+    r := router.NewApp(cfg).Router
+    setupTestBooks(t, r)
+
+    var original bytes.Buffer
+    png.Encode(&original, testCover(400, 600))
+
+    // The declared type is ignored in favour of the sniffed one
+    w := upload(r, "PUT", "/books/1/cover", "application/octet-stream", original.Bytes())
+    var response model.APIResponse
+    json.NewDecoder(w.Body).Decode(&response)
+    var cover model.Cover
+    decodeData(t, response, &cover)
+    if w.Code != http.StatusOK || cover.ContentType != "image/png" || cover.Width != 400 || cover.ETag == "" {
+        t.Fatalf("Expected a 400px PNG cover but got %d %+v", w.Code, cover)
+    }
+    if _, err := os.Stat(filepath.Join(cfg.Storage.Dir, "covers", "1", "small")); err != nil {
+        t.Errorf("Expected the thumbnail on disk: %v", err)
+    }
+
+    var book model.Book
+    _, response = doJSON(t, r, "GET", "/books/1", nil)
+    decodeData(t, response, &book)
+    if book.Cover == nil || book.Cover.URLs[model.CoverSizeSmall] == "" {
+        t.Fatalf("Expected cover URLs on the book but got %+v", book.Cover)
+    }
+
+    // Thumbnails keep the aspect ratio
+    req, _ := http.NewRequest("GET", book.Cover.URLs[model.CoverSizeSmall], nil)
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    thumbnail, format, err := image.Decode(w.Body)
+    if err != nil || format != "png" || thumbnail.Bounds().Dx() != 120 || thumbnail.Bounds().Dy() != 180 {
+        t.Fatalf("Expected a 120x180 PNG thumbnail but got %s %v %v", format, thumbnail, err)
+    }
+    if w.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+        t.Errorf("Expected a versioned URL to be cacheable for good but got %q", w.Header().Get("Cache-Control"))
+    }
+
+    // Clients revalidate with the ETag
+    req, _ = http.NewRequest("GET", "/books/1/cover?size=small", nil)
+    req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusNotModified {
+        t.Errorf("Expected 304 for a matching ETag but got %d", w.Code)
+    }
+
+    // Multipart uploads of JPEG images
+    var jpg, form bytes.Buffer
+    jpeg.Encode(&jpg, testCover(100, 150), nil)
+    writer := multipart.NewWriter(&form)
+    part, _ := writer.CreateFormFile("file", "cover.jpg")
+    part.Write(jpg.Bytes())
+    writer.Close()
+    if w := upload(r, "PUT", "/books/2/cover", writer.FormDataContentType(), form.Bytes()); w.Code != http.StatusOK {
+        t.Errorf("Expected multipart upload to succeed but got %d %s", w.Code, w.Body)
+    }
+
+    if w := upload(r, "PUT", "/books/3/cover", "image/png", []byte("not an image")); w.Code != http.StatusUnsupportedMediaType {
+        t.Errorf("Expected text to be rejected but got %d", w.Code)
+    }
+    huge := append(append([]byte{}, original.Bytes()...), make([]byte, 6<<20)...)
+    if w := upload(r, "PUT", "/books/3/cover", "image/png", huge); w.Code != http.StatusRequestEntityTooLarge {
+        t.Errorf("Expected an oversized upload to be rejected but got %d", w.Code)
+    }
+    // Within the limit per side but not in all
+    if w := upload(r, "PUT", "/books/3/cover", "image/png", pngHeader(7000, 7000)); w.Code != http.StatusBadRequest {
+        t.Errorf("Expected a 49 megapixel cover to be rejected but got %d %s", w.Code, w.Body)
+    }
+    if code, _ := doJSON(t, r, "GET", "/books/1/cover?size=huge", nil); code != http.StatusBadRequest {
+        t.Errorf("Expected unknown size to be rejected but got %d", code)
+    }
+
+    // Deleting the book removes its cover
+    doJSON(t, r, "DELETE", "/books/1", nil)
+    if _, err := os.Stat(filepath.Join(cfg.Storage.Dir, "covers", "1", "original")); !os.IsNotExist(err) {
+        t.Errorf("Expected the cover files to be removed but got %v", err)
+    }
+}
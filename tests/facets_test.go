@@ -0,0 +1,89 @@
+package handler
+
+import (
+    "net/http"
+    "testing"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+// facetValue finds the count of one value of a facet
+func facetValue(meta *model.MetaData, field, value string) (model.FacetValue, bool) {
+    if meta == nil {
+        return model.FacetValue{}, false
+    }
+    for _, facet := range meta.Facets {
+        if facet.Field != field {
+            continue
+        }
+        for _, entry := range facet.Values {
+            if entry.Value == value {
+                return entry, true
+            }
+        }
+    }
+    return model.FacetValue{}, false
+}
+
+func TestFacetCounts(t *testing.T) {
+    r := router.SetupRouter()
+
+    var fiction, fantasy model.Subject
+    _, response := doJSON(t, r, "POST", "/subjects", model.Subject{Name: "Fiction", Kind: model.SubjectKindGenre})
+    decodeData(t, response, &fiction)
+    _, response = doJSON(t, r, "POST", "/subjects", model.Subject{Name: "Fantasy", Kind: model.SubjectKindGenre, BroaderID: fiction.ID})
+    decodeData(t, response, &fantasy)
+
+    books := []model.Book{
+        {Title: "A", Author: "Le Guin", PublishedYear: 1968, Language: "en", SubjectIDs: []int{fantasy.ID}},
+        {Title: "B", Author: "Le Guin", PublishedYear: 1969, Language: "EN", SubjectIDs: []int{fiction.ID}},
+        {Title: "C", Author: "Oz", PublishedYear: 1992, Language: "he"},
+        {Title: "D", Author: "Le Guin", PublishedYear: 1990, Language: "en", SubjectIDs: []int{fantasy.ID}},
+    }
+    for _, book := range books {
+        if code, _ := doJSON(t, r, "POST", "/books", book); code != http.StatusCreated {
+            t.Fatalf("Failed to add book %s: %d", book.Title, code)
+        }
+    }
+
+    // Counts cover every match even though only one book is on the page
+    code, response := doJSON(t, r, "GET", "/books?perPage=1&page=2", nil)
+    if code != http.StatusOK || response.Meta.Count != 1 || response.Meta.Total != 4 || response.Meta.TotalPages != 4 {
+        t.Fatalf("Expected page 2 of 4 but got %d %+v", code, response.Meta)
+    }
+    expected := []struct {
+        field, value string
+        count        int
+    }{
+        {model.FacetAuthor, "Le Guin", 3},
+        {model.FacetDecade, "1960", 2},
+        {model.FacetDecade, "1990", 2},
+        {model.FacetLanguage, "en", 3},
+        {model.FacetSubject, "1", 3}, // Fiction includes the Fantasy books
+        {model.FacetSubject, "2", 2},
+    }
+    for _, want := range expected {
+        entry, found := facetValue(response.Meta, want.field, want.value)
+        if !found || entry.Count != want.count {
+            t.Errorf("Expected %s=%s to count %d but got %+v", want.field, want.value, want.count, entry)
+        }
+    }
+
+    // Selecting facet values narrows the results and marks them as selected
+    _, response = doJSON(t, r, "GET", "/books?decade=1990&language=en", nil)
+    if response.Meta.Total != 1 {
+        t.Errorf("Expected one English book from the 1990s but got %d", response.Meta.Total)
+    }
+    if entry, _ := facetValue(response.Meta, model.FacetDecade, "1990"); !entry.Selected || entry.Label != "1990s" {
+        t.Errorf("Expected the 1990s to be selected but got %+v", entry)
+    }
+
+    _, response = doJSON(t, r, "GET", "/books?subject=1", nil)
+    if entry, _ := facetValue(response.Meta, model.FacetSubject, "1"); !entry.Selected || entry.Label != "Fiction" || entry.Count != 3 {
+        t.Errorf("Expected Fiction to be selected with 3 books but got %+v", entry)
+    }
+
+    if code, _ := doJSON(t, r, "GET", "/books?perPage=0", nil); code != http.StatusBadRequest {
+        t.Errorf("Expected invalid perPage to be rejected but got %d", code)
+    }
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/health"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+// probe sends an unauthenticated request to a probe endpoint and decodes its report
+func probe(t *testing.T, app *router.App, url string) (int, model.HealthReport, model.APIResponse) {
+    req, _ := http.NewRequest("GET", url, nil)
+    w := httptest.NewRecorder()
+    app.Router.ServeHTTP(w, req)
+    var response model.APIResponse
+    json.NewDecoder(w.Body).Decode(&response)
+    var report model.HealthReport
+    decodeData(t, response, &report)
+    return w.Code, report, response
+}
+
+func TestHealthEndpoints(t *testing.T) {
+    dir := t.TempDir()
+    cfg := config.Default()
+    cfg.Storage.Dir = filepath.Join(dir, "blobs")
+    app := router.NewApp(cfg)
+
+    code, report, _ := probe(t, app, "/healthz")
+    if code != http.StatusOK || report.Status != model.HealthOK || len(report.Checks) != 1 || report.Checks[0].Name != "jobs" {
+        t.Fatalf("Expected the process to be live but got %d %+v", code, report)
+    }
+    code, report, _ = probe(t, app, "/readyz")
+    if code != http.StatusOK || report.Status != model.HealthOK || len(report.Checks) != 3 || report.Checks[1].Name != "storage" ||
+        report.Checks[2].Name != "migrations" {
+        t.Fatalf("Expected the server to be ready but got %d %+v", code, report)
+    }
+
+    // Storage that cannot be written to fails readiness but not liveness
+    os.RemoveAll(cfg.Storage.Dir)
+    os.WriteFile(cfg.Storage.Dir, []byte("not a directory"), 0600)
+    code, report, response := probe(t, app, "/readyz")
+    if code != http.StatusServiceUnavailable || report.Status != model.HealthFailing || response.Error.Code != "SERVICE_UNAVAILABLE" {
+        t.Fatalf("Expected unusable storage to fail readiness but got %d %+v", code, report)
+    }
+    if report.Checks[1].Status != model.HealthFailing || report.Checks[1].Error == "" || report.Checks[0].Status != model.HealthOK {
+        t.Errorf("Expected only the storage check to fail but got %+v", report.Checks)
+    }
+    if code, _, _ := probe(t, app, "/healthz"); code != http.StatusOK {
+        t.Errorf("Expected storage not to affect liveness but got %d", code)
+    }
+    os.Remove(cfg.Storage.Dir)
+
+    // Readiness fails from the start of shutdown; a stopped scheduler fails liveness too
+    app.Health.Drain()
+    code, report, _ = probe(t, app, "/readyz")
+    if code != http.StatusServiceUnavailable || report.Checks[0].Name != "shutdown" || report.Checks[0].Error != health.ErrShuttingDown.Error() {
+        t.Errorf("Expected a draining server not to be ready but got %d %+v", code, report)
+    }
+    app.Scheduler.Stop()
+    if code, report, _ := probe(t, app, "/healthz"); code != http.StatusServiceUnavailable || report.Checks[0].Status != model.HealthFailing {
+        t.Errorf("Expected a stopped scheduler to fail liveness but got %d %+v", code, report)
+    }
+}
+
+func TestCorruptJobState(t *testing.T) {
+    cfg := config.Default()
+    cfg.Jobs.StateFile = filepath.Join(t.TempDir(), "jobs.json")
+    os.WriteFile(cfg.Jobs.StateFile, []byte("{not json"), 0600)
+    app := router.NewApp(cfg)
+
+    // A restart would not fix the file, so it must not fail the probes
+    if code, report, _ := probe(t, app, "/healthz"); code != http.StatusOK {
+        t.Errorf("Expected an unreadable job state not to fail liveness but got %d %+v", code, report)
+    }
+    if code, report, _ := probe(t, app, "/readyz"); code != http.StatusOK {
+        t.Errorf("Expected an unreadable job state not to fail readiness but got %d %+v", code, report)
+    }
+}
+
+func TestHealthRegistry(t *testing.T) {
+    registry := health.NewRegistry(50 * time.Millisecond)
+    registry.AddReadiness("slow", func(ctx context.Context) error {
+        <-time.After(time.Second)
+        return nil
+    })
+    registry.AddReadiness("broken", func(ctx context.Context) error { return errors.New("connection refused") })
+    registry.AddLiveness("panics", func(ctx context.Context) error { panic("boom") })
+
+    started := time.Now()
+    report := registry.Ready(context.Background())
+    if time.Since(started) > 500*time.Millisecond {
+        t.Errorf("Expected a hanging check to time out but the probe took %s", time.Since(started))
+    }
+    if report.Status != model.HealthFailing || len(report.Checks) != 3 {
+        t.Fatalf("Expected three failing checks but got %+v", report)
+    }
+    for i, name := range []string{"slow", "broken", "panics"} {
+        if check := report.Checks[i]; check.Name != name || check.Status != model.HealthFailing || check.Error == "" {
+            t.Errorf("Expected %s to fail in registration order but got %+v", name, check)
+        }
+    }
+    if live := registry.Live(context.Background()); len(live.Checks) != 1 || live.Checks[0].Name != "panics" {
+        t.Errorf("Expected only liveness checks in the liveness report but got %+v", live)
+    }
+}
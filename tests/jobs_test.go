@@ -0,0 +1,237 @@
+package handler
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "testing"
+    "time"
+    "LibraryGo/internal/jobs"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/router"
+    "LibraryGo/internal/service"
+)
+
+func TestParseSchedule(t *testing.T) {
+    base := time.Date(2026, time.March, 14, 10, 7, 30, 0, time.UTC) // Saturday
+
+    tests := []struct {
+        name    string
+        spec    string
+        want    time.Time
+        wantErr bool
+    }{
+        {
+            name: "Every Fifteen Minutes",
+            spec: "*/15 * * * *",
+            want: time.Date(2026, time.March, 14, 10, 15, 0, 0, time.UTC),
+        },
+        {
+            name: "Daily Descriptor",
+            spec: "@daily",
+            want: time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC),
+        },
+        {
+            name: "Weekdays At Nine",
+            spec: "0 9 * * 1-5",
+            want: time.Date(2026, time.March, 16, 9, 0, 0, 0, time.UTC),
+        },
+        {
+            name: "First Of Month",
+            spec: "30 2 1 * *",
+            want: time.Date(2026, time.April, 1, 2, 30, 0, 0, time.UTC),
+        },
+        {
+            name: "Fixed Interval",
+            spec: "@every 90s",
+            want: base.Add(90 * time.Second),
+        },
+        {
+            name:    "Too Few Fields",
+            spec:    "* * *",
+            wantErr: true,
+        },
+        {
+            name:    "Out Of Range",
+            spec:    "61 * * * *",
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            schedule, err := jobs.ParseSchedule(tt.spec)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("Expected an error for spec %q", tt.spec)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Unexpected error: %v", err)
+            }
+            if got := schedule.Next(base); !got.Equal(tt.want) {
+                t.Errorf("Expected next run %v but got %v", tt.want, got)
+            }
+        })
+    }
+}
+
+func TestSchedulerOverlapAndPersistence(t *testing.T) {
+    store := jobs.NewFileStateStore(filepath.Join(t.TempDir(), "jobs.json"))
+    scheduler := jobs.NewScheduler(store)
+
+    release := make(chan struct{})
+    started := make(chan struct{})
+    err := scheduler.Register(jobs.Job{
+        Name: "slow",
+        Spec: "@hourly",
+        Run: func(ctx context.Context) error {
+            close(started)
+            <-release
+            return nil
+        },
+    })
+    if err != nil {
+        t.Fatalf("Failed to register job: %v", err)
+    }
+
+    if err := scheduler.Trigger("slow"); err != nil {
+        t.Fatalf("Failed to trigger job: %v", err)
+    }
+    <-started
+    if err := scheduler.Trigger("slow"); !errors.Is(err, jobs.ErrJobRunning) {
+        t.Errorf("Expected ErrJobRunning but got %v", err)
+    }
+    close(release)
+    scheduler.Stop()
+
+    restored := jobs.NewScheduler(store)
+    restored.Register(jobs.Job{Name: "slow", Spec: "@hourly", Run: func(ctx context.Context) error { return nil }})
+    status, err := restored.Status("slow")
+    if err != nil {
+        t.Fatalf("Failed to get status: %v", err)
+    }
+    if status.RunCount != 1 || status.LastSuccessAt == nil {
+        t.Errorf("Expected persisted run history but got %+v", status.JobState)
+    }
+}
+
+func TestStopCancelsRunningJob(t *testing.T) {
+    scheduler := jobs.NewScheduler(jobs.NewMemoryStateStore())
+    started := make(chan struct{})
+    scheduler.Register(jobs.Job{
+        Name: "blocking",
+        Spec: "@daily",
+        Run: func(ctx context.Context) error {
+            close(started)
+            <-ctx.Done()
+            return ctx.Err()
+        },
+    })
+
+    scheduler.Trigger("blocking")
+    <-started
+    scheduler.Stop()
+
+    status, _ := scheduler.Status("blocking")
+    if status.Running || status.FailureCount != 1 {
+        t.Errorf("Expected cancelled run to be recorded as a failure but got %+v", status)
+    }
+    if err := scheduler.Trigger("blocking"); !errors.Is(err, jobs.ErrSchedulerStopped) {
+        t.Errorf("Expected ErrSchedulerStopped but got %v", err)
+    }
+}
+
+func TestOverdueAndHoldExpiry(t *testing.T) {
+    books := repository.NewBookRepository(slog.Default())
+    patrons := repository.NewPatronRepository()
+    circulation := service.NewCirculationService(repository.NewLoanRepository(), repository.NewHoldRepository(), books, patrons, repository.NewCopyRepository())
+    now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+    circulation.SetClock(func() time.Time { return now })
+
+    book := books.AddBook(context.Background(), model.Book{Title: "Test Book", Author: "Test Author", PublishedYear: 2024})
+    borrower := patrons.AddPatron(model.Patron{Name: "Borrower"})
+    waiter := patrons.AddPatron(model.Patron{Name: "Waiter"})
+    loan, err := circulation.Checkout(book.ID, borrower.ID, 0)
+    if err != nil {
+        t.Fatalf("Failed to check out: %v", err)
+    }
+    hold, err := circulation.PlaceHold(book.ID, waiter.ID)
+    if err != nil || hold.Status != model.HoldStatusPending {
+        t.Fatalf("Expected pending hold but got %+v (%v)", hold, err)
+    }
+
+    now = now.Add(service.DefaultLoanPeriod + time.Hour)
+    overdue, err := circulation.MarkOverdueLoans(context.Background())
+    if err != nil || len(overdue) != 1 || overdue[0].ID != loan.ID {
+        t.Fatalf("Expected loan %d to be marked overdue but got %+v (%v)", loan.ID, overdue, err)
+    }
+
+    if _, err := circulation.Return(loan.ID, 0); err != nil {
+        t.Fatalf("Failed to return: %v", err)
+    }
+    hold, _ = circulation.GetHoldByID(hold.ID)
+    if hold.Status != model.HoldStatusReady {
+        t.Fatalf("Expected hold to be ready after return but got %s", hold.Status)
+    }
+
+    now = now.Add(service.DefaultPickupWindow + time.Hour)
+    expired, err := circulation.ExpireHolds(context.Background())
+    if err != nil || len(expired) != 1 {
+        t.Fatalf("Expected one expired hold but got %+v (%v)", expired, err)
+    }
+}
+
+func TestAdminJobsEndpoints(t *testing.T) {
+    r := router.SetupRouter()
+
+    req, _ := http.NewRequest("GET", "/admin/jobs", nil)
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusOK {
+        t.Fatalf("Expected status code %d but got %d", http.StatusOK, w.Code)
+    }
+
+    var response model.APIResponse
+    if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+        t.Fatalf("Failed to decode response: %v", err)
+    }
+    if response.Meta == nil || response.Meta.Count != 6 {
+        t.Errorf("Expected 6 registered jobs but got %+v", response.Meta)
+    }
+
+    tests := []struct {
+        name       string
+        url        string
+        wantStatus int
+    }{
+        {
+            name:       "Run And Wait",
+            url:        "/admin/jobs/overdue-detection/run?wait=true",
+            wantStatus: http.StatusOK,
+        },
+        {
+            name:       "Unknown Job",
+            url:        "/admin/jobs/nope/run",
+            wantStatus: http.StatusNotFound,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req, _ := http.NewRequest("POST", tt.url, nil)
+            w := httptest.NewRecorder()
+            r.ServeHTTP(w, req)
+
+            if w.Code != tt.wantStatus {
+                t.Errorf("Expected status code %d but got %d", tt.wantStatus, w.Code)
+            }
+        })
+    }
+}
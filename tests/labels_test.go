@@ -0,0 +1,145 @@
+package handler
+
+import (
+    "bytes"
+    "image/png"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+    "LibraryGo/internal/barcode"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+func TestCode128(t *testing.T) {
+    // Numeric barcodes are packed two digits to a symbol: start, 7 pairs, checksum and stop
+    symbol, err := barcode.EncodeCode128("31234567890123")
+    if err != nil || len(symbol.Modules[0]) != 9*11+13 {
+        t.Fatalf("Expected 112 modules but got %d (%v)", len(symbol.Modules[0]), err)
+    }
+    // Start C is bars and spaces of 2 1 1 2 3 2; the stop pattern ends in two bars
+    start := []bool{true, true, false, true, false, false, true, true, true, false, false}
+    for i, dark := range start {
+        if symbol.Modules[0][i] != dark {
+            t.Fatalf("Expected the start C pattern but got %v", symbol.Modules[0][:11])
+        }
+    }
+    if symbol, _ := barcode.EncodeCode128("PJJ123C"); len(symbol.Modules[0]) != 9*11+13 {
+        t.Errorf("Expected short digit runs to stay in set B but got %d modules", len(symbol.Modules[0]))
+    }
+    if _, err := barcode.EncodeCode128("Bücher"); err != barcode.ErrInvalidChar {
+        t.Errorf("Expected non-ASCII to be refused but got %v", err)
+    }
+}
+
+func TestQRCode(t *testing.T) {
+    symbol, err := barcode.EncodeQR([]byte("12345"))
+    if err != nil || len(symbol.Modules) != 21 {
+        t.Fatalf("Expected a version 1 symbol but got %d (%v)", len(symbol.Modules), err)
+    }
+    symbol, _ = barcode.EncodeQR([]byte("https://library.example/books/12345"))
+    if len(symbol.Modules) != 29 {
+        t.Errorf("Expected a version 3 symbol but got %d modules", len(symbol.Modules))
+    }
+
+    // Both copies of the format information agree and name level M with a valid mask
+    formats := map[int]bool{0x5412: true, 0x5125: true, 0x5E7C: true, 0x5B4B: true, 0x45F9: true, 0x40CE: true, 0x4F97: true, 0x4AA0: true}
+    m := symbol.Modules
+    size := len(m)
+    first, second := 0, 0
+    for i := 0; i < 15; i++ {
+        var a, b bool
+        switch {
+        case i < 6:
+            a = m[i][8]
+        case i < 8:
+            a = m[i+1][8]
+        case i == 8:
+            a = m[8][7]
+        default:
+            a = m[8][14-i]
+        }
+        if i < 8 {
+            b = m[8][size-1-i]
+        } else {
+            b = m[size-15+i][8]
+        }
+        if a {
+            first |= 1 << i
+        }
+        if b {
+            second |= 1 << i
+        }
+    }
+    if first != second || !formats[first] {
+        t.Errorf("Expected matching level M format bits but got %015b and %015b", first, second)
+    }
+
+    if _, err := barcode.EncodeQR(bytes.Repeat([]byte("x"), 300)); err != barcode.ErrTooLong {
+        t.Errorf("Expected oversized data to be refused but got %v", err)
+    }
+}
+
+func TestLabels(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+    var branch model.Branch
+    _, response := doJSON(t, r, "POST", "/branches", model.Branch{Code: "CEN", Name: "Central"})
+    decodeData(t, response, &branch)
+    for i := 1; i <= 35; i++ {
+        doJSON(t, r, "POST", "/books/1/copies", map[string]interface{}{"barcode": "3100000000" + strconv.Itoa(1000+i), "homeBranchId": branch.ID})
+    }
+
+    get := func(url string) *httptest.ResponseRecorder {
+        req, _ := http.NewRequest("GET", url, nil)
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        return w
+    }
+
+    w := get("/copies/1/barcode?scale=2")
+    img, err := png.Decode(w.Body)
+    if w.Code != http.StatusOK || err != nil || img.Bounds().Dx() != 2*(112+20) {
+        t.Fatalf("Expected a Code 128 PNG but got %d %v", w.Code, err)
+    }
+    w = get("/copies/1/barcode?format=svg&symbology=qr")
+    if w.Header().Get("Content-Type") != "image/svg+xml" || !strings.HasPrefix(w.Body.String(), "<svg") {
+        t.Errorf("Expected a QR Code SVG but got %q", w.Header().Get("Content-Type"))
+    }
+    if w := get("/books/1/barcode"); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+        t.Errorf("Expected the book ID as a QR Code but got %d", w.Code)
+    }
+    if w := get("/copies/1/barcode?format=gif"); w.Code != http.StatusBadRequest {
+        t.Errorf("Expected unknown formats to be refused but got %d", w.Code)
+    }
+    if w := get("/copies/99/barcode"); w.Code != http.StatusNotFound {
+        t.Errorf("Expected an unknown copy to be not found but got %d", w.Code)
+    }
+
+    // 35 labels after 5 used ones need two sheets of 30
+    req, _ := http.NewRequest("POST", "/labels/sheet", strings.NewReader(`{"bookIds":[1],"copyIds":[3],"skip":5}`))
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    pdf := w.Body.String()
+    if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(pdf, "%PDF-") {
+        t.Fatalf("Expected a PDF but got %d %s", w.Code, pdf)
+    }
+    if !strings.Contains(pdf, "/Count 2") || !strings.Contains(pdf, "(Test Book 1)") || !strings.HasSuffix(pdf, "%%EOF\n") {
+        t.Errorf("Expected two pages of labels with titles")
+    }
+
+    if code, _ := doJSON(t, r, "POST", "/labels/sheet", map[string]interface{}{"stock": "unknown", "copyIds": []int{1}}); code != http.StatusBadRequest {
+        t.Errorf("Expected an unknown stock to be refused but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "POST", "/labels/sheet", map[string]interface{}{"bookIds": []int{2}}); code != http.StatusBadRequest {
+        t.Errorf("Expected a book without copies to have nothing to print but got %d", code)
+    }
+    var stocks []model.LabelStock
+    _, response = doJSON(t, r, "GET", "/labels/stocks", nil)
+    decodeData(t, response, &stocks)
+    if len(stocks) != 3 {
+        t.Errorf("Expected 3 label stocks but got %d", len(stocks))
+    }
+}
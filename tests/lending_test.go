@@ -0,0 +1,187 @@
+package handler
+
+import (
+    "archive/zip"
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "testing"
+    "testing/iotest"
+    "time"
+    "LibraryGo/internal/blob"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/router"
+    "LibraryGo/internal/service"
+)
+
+// testEPUB builds the smallest archive that is recognised as an EPUB
+func testEPUB(t *testing.T) []byte {
+    var buf bytes.Buffer
+    archive := zip.NewWriter(&buf)
+    mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+    if err != nil {
+        t.Fatalf("Failed to build EPUB: %v", err)
+    }
+    mimetype.Write([]byte("application/epub+zip"))
+    archive.Close()
+    return buf.Bytes()
+}
+
+func TestDigitalLending(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+    for _, name := range []string{"Ann", "Ben", "Cid"} {
+        doJSON(t, r, "POST", "/patrons", model.Patron{Name: name})
+    }
+
+    pdf := []byte("%PDF-1.7\n% test file\n")
+    w := upload(r, "POST", "/books/1/assets?licenses=2&fileName=book1.pdf", "application/pdf", pdf)
+    if w.Code != http.StatusCreated {
+        t.Fatalf("Expected PDF upload to succeed but got %d %s", w.Code, w.Body)
+    }
+    if w := upload(r, "POST", "/books/1/assets", "application/epub+zip", testEPUB(t)); w.Code != http.StatusCreated {
+        t.Errorf("Expected EPUB upload to succeed but got %d %s", w.Code, w.Body)
+    }
+    if w := upload(r, "POST", "/books/1/assets", "application/pdf", []byte("plain text")); w.Code != http.StatusUnsupportedMediaType {
+        t.Errorf("Expected other files to be rejected but got %d", w.Code)
+    }
+
+    // Two licenses serve two patrons at once
+    var loans []model.DigitalLoan
+    for patronID := 1; patronID <= 2; patronID++ {
+        code, response := doJSON(t, r, "POST", "/assets/1/loans", map[string]int{"patronId": patronID})
+        var loan model.DigitalLoan
+        decodeData(t, response, &loan)
+        if code != http.StatusCreated || loan.DownloadURL == "" {
+            t.Fatalf("Expected a loan with a download link but got %d %+v", code, loan)
+        }
+        loans = append(loans, loan)
+    }
+    if code, _ := doJSON(t, r, "POST", "/assets/1/loans", map[string]int{"patronId": 3}); code != http.StatusConflict {
+        t.Errorf("Expected no free license for a third patron but got %d", code)
+    }
+    var asset model.DigitalAsset
+    _, response := doJSON(t, r, "GET", "/assets/1", nil)
+    decodeData(t, response, &asset)
+    if asset.Available != 0 || asset.FileName != "book1.pdf" {
+        t.Errorf("Expected no available licenses but got %+v", asset)
+    }
+
+    req, _ := http.NewRequest("GET", loans[0].DownloadURL, nil)
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), pdf) || !strings.Contains(w.Header().Get("Content-Disposition"), "book1.pdf") {
+        t.Fatalf("Expected the PDF download but got %d %q", w.Code, w.Header().Get("Content-Disposition"))
+    }
+
+    // Links cannot be altered to reach another loan
+    tampered := strings.Replace(loans[0].DownloadURL, "/downloads/"+strconv.Itoa(loans[0].ID), "/downloads/"+strconv.Itoa(loans[1].ID), 1)
+    if code, _ := doJSON(t, r, "GET", tampered, nil); code != http.StatusForbidden {
+        t.Errorf("Expected a tampered link to be refused but got %d", code)
+    }
+
+    // Returning a loan frees its license and kills its link
+    doJSON(t, r, "POST", "/digital-loans/1/return", nil)
+    if code, _ := doJSON(t, r, "GET", loans[0].DownloadURL, nil); code != http.StatusConflict {
+        t.Errorf("Expected the link of a returned loan to stop working but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "POST", "/assets/1/loans", map[string]int{"patronId": 3}); code != http.StatusCreated {
+        t.Errorf("Expected the freed license to be lent but got %d", code)
+    }
+}
+
+func TestDigitalLoanExpiry(t *testing.T) {
+    books := repository.NewBookRepository(slog.Default())
+    patrons := repository.NewPatronRepository()
+    lending := service.NewLendingService(repository.NewAssetRepository(), repository.NewDigitalLoanRepository(), books, patrons,
+        blob.NewMemoryStore(), []byte("test key"))
+    now := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+    lending.SetClock(func() time.Time { return now })
+
+    book := books.AddBook(context.Background(), model.Book{Title: "Test Book", Author: "Test Author", PublishedYear: 2024})
+    patron := patrons.AddPatron(model.Patron{Name: "Reader"})
+    asset, err := lending.AddAsset(book.ID, "book.pdf", 1, strings.NewReader("%PDF-1.4"))
+    if err != nil {
+        t.Fatalf("Failed to add asset: %v", err)
+    }
+    loan, err := lending.Borrow(asset.ID, patron.ID)
+    if err != nil {
+        t.Fatalf("Failed to borrow: %v", err)
+    }
+    link, _ := url.Parse(loan.DownloadURL)
+    expires, signature := link.Query().Get("expires"), link.Query().Get("signature")
+
+    // Links last an hour; the loan itself hands out fresh ones
+    now = now.Add(2 * time.Hour)
+    if _, _, err := lending.Download(loan.ID, expires, signature); !errors.Is(err, service.ErrDownloadLinkExpired) {
+        t.Errorf("Expected an old link to have expired but got %v", err)
+    }
+
+    // The license is back in the pool once the loan period ends, before the job even runs
+    now = now.Add(service.DefaultLoanPeriod)
+    if asset, _ := lending.GetAssetByID(asset.ID); asset.Available != 1 {
+        t.Errorf("Expected the license to be free after the due date but got %d", asset.Available)
+    }
+    expired, err := lending.ExpireLoans(context.Background())
+    if err != nil || len(expired) != 1 || expired[0].Status != model.DigitalLoanStatusExpired {
+        t.Fatalf("Expected the loan to expire but got %+v (%v)", expired, err)
+    }
+    if refreshed, _ := lending.GetLoanByID(loan.ID); refreshed.DownloadURL != "" {
+        t.Errorf("Expected no download link for an expired loan but got %s", refreshed.DownloadURL)
+    }
+}
+
+func TestStreamedAssets(t *testing.T) {
+    dir := t.TempDir()
+    books := repository.NewBookRepository(slog.Default())
+    assets := repository.NewAssetRepository()
+    patrons := repository.NewPatronRepository()
+    lending := service.NewLendingService(assets, repository.NewDigitalLoanRepository(), books, patrons,
+        blob.NewFileStore(dir), []byte("test key"))
+    book := books.AddBook(context.Background(), model.Book{Title: "Test Book", Author: "Test Author", PublishedYear: 2024})
+    patron := patrons.AddPatron(model.Patron{Name: "Reader"})
+
+    // Files go to the store as they are read and come back the same way
+    pdf := append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("0123456789abcdef"), 1<<16)...)
+    asset, err := lending.AddAsset(book.ID, "big.pdf", 1, bytes.NewReader(pdf))
+    if err != nil || asset.Size != len(pdf) {
+        t.Fatalf("Expected a %d byte asset but got %+v (%v)", len(pdf), asset, err)
+    }
+    loan, err := lending.Borrow(asset.ID, patron.ID)
+    if err != nil {
+        t.Fatalf("Failed to borrow: %v", err)
+    }
+    link, _ := url.Parse(loan.DownloadURL)
+    _, file, err := lending.Download(loan.ID, link.Query().Get("expires"), link.Query().Get("signature"))
+    if err != nil {
+        t.Fatalf("Failed to download: %v", err)
+    }
+    data, err := io.ReadAll(file)
+    file.Close()
+    if err != nil || !bytes.Equal(data, pdf) {
+        t.Errorf("Expected the download to match the upload but got %d bytes (%v)", len(data), err)
+    }
+
+    // An upload that breaks off leaves neither an asset nor a file behind
+    broken := io.MultiReader(strings.NewReader("%PDF-1.7\n"), iotest.ErrReader(errors.New("connection reset")))
+    if _, err := lending.AddAsset(book.ID, "broken.pdf", 1, broken); err == nil {
+        t.Fatal("Expected a broken upload to fail")
+    }
+    if listed := assets.GetAssets(book.ID); len(listed) != 1 {
+        t.Errorf("Expected only the first asset to be kept but got %d", len(listed))
+    }
+    entries, _ := os.ReadDir(filepath.Join(dir, "assets"))
+    if len(entries) != 1 {
+        t.Errorf("Expected one file in the store but got %d", len(entries))
+    }
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+    "bytes"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/logging"
+    "LibraryGo/internal/middleware"
+)
+
+func TestAccessLog(t *testing.T) {
+    var out bytes.Buffer
+    logger, err := logging.New(&out, "debug", logging.FormatJSON)
+    if err != nil {
+        t.Fatalf("Failed to create logger: %v", err)
+    }
+
+    r := mux.NewRouter()
+    r.Use(middleware.RequestID, middleware.AccessLog(logger))
+    r.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+        logger.InfoContext(r.Context(), "book looked up")
+        w.WriteHeader(http.StatusTeapot)
+        w.Write([]byte("short"))
+    }).Methods("GET")
+
+    req, _ := http.NewRequest("GET", "/books/42", nil)
+    req.Header.Set("X-Request-ID", "req-42")
+    r.ServeHTTP(httptest.NewRecorder(), req)
+
+    var records []map[string]interface{}
+    decoder := json.NewDecoder(&out)
+    for decoder.More() {
+        var record map[string]interface{}
+        if err := decoder.Decode(&record); err != nil {
+            t.Fatalf("Expected JSON log lines but got %v", err)
+        }
+        records = append(records, record)
+    }
+    if len(records) != 2 {
+        t.Fatalf("Expected a domain record and an access record but got %v", records)
+    }
+    if records[0]["msg"] != "book looked up" || records[0]["request_id"] != "req-42" {
+        t.Errorf("Expected the handler's record to carry the request ID but got %v", records[0])
+    }
+    access := records[1]
+    if access["msg"] != "request" || access["method"] != "GET" || access["route"] != "/books/{id}" || access["path"] != "/books/42" ||
+        access["status"] != float64(http.StatusTeapot) || access["bytes"] != float64(5) || access["request_id"] != "req-42" {
+        t.Errorf("Expected the access record to describe the request but got %v", access)
+    }
+    if _, ok := access["latency"]; !ok {
+        t.Errorf("Expected the access record to carry the latency but got %v", access)
+    }
+
+    out.Reset()
+    quiet, _ := logging.New(&out, "warn", logging.FormatText)
+    quiet.Info("not shown")
+    if out.Len() != 0 {
+        t.Errorf("Expected info records to be dropped at warn level but got %q", out.String())
+    }
+    if _, err := logging.New(&out, "loud", logging.FormatText); err == nil {
+        t.Errorf("Expected an unknown level to be rejected")
+    }
+    if _, err := logging.New(&out, "info", "xml"); err == nil {
+        t.Errorf("Expected an unknown format to be rejected")
+    }
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "LibraryGo/internal/metrics"
+    "LibraryGo/internal/router"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+    for _, url := range []string{"/books/1", "/books/2", "/books/99"} {
+        req, _ := http.NewRequest("GET", url, nil)
+        r.ServeHTTP(httptest.NewRecorder(), req)
+    }
+
+    req, _ := http.NewRequest("GET", "/metrics", nil)
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusOK || w.Header().Get("Content-Type") != metrics.ContentType {
+        t.Fatalf("Expected the exposition format but got %d %q", w.Code, w.Header().Get("Content-Type"))
+    }
+    body := w.Body.String()
+    for _, line := range []string{
+        "# TYPE http_request_duration_seconds histogram",
+        `http_request_duration_seconds_count{method="GET",route="/books/{id}",status="200"} 2`,
+        `http_request_duration_seconds_bucket{method="GET",route="/books/{id}",status="404",le="+Inf"} 1`,
+        `http_request_duration_seconds_count{method="POST",route="/books",status="201"} 3`,
+        `library_repository_operation_duration_seconds_count{repository="books",operation="add"} 3`,
+        "library_books 3",
+        "library_active_loans 0",
+        "library_trace_spans_dropped_total 0",
+        "# TYPE go_goroutines gauge",
+    } {
+        if !strings.Contains(body, line+"\n") {
+            t.Errorf("Expected metrics to contain %q", line)
+        }
+    }
+}
+
+func TestMetricsRegistry(t *testing.T) {
+    registry := metrics.NewRegistry()
+    latency := registry.NewHistogram("op_seconds", "Latency.", []float64{0.1, 1}, "op")
+    latency.Observe(0.05, "read")
+    latency.Observe(0.1, "read")
+    latency.Observe(3, "read")
+    errors := registry.NewCounter("errors_total", "Errors by reason.", "reason")
+    errors.Inc(`quote " and \ slash`)
+
+    var out bytes.Buffer
+    registry.WriteTo(&out)
+    want := `# HELP errors_total Errors by reason.
+# TYPE errors_total counter
+errors_total{reason="quote \" and \\ slash"} 1
+# HELP op_seconds Latency.
+# TYPE op_seconds histogram
+op_seconds_bucket{op="read",le="0.1"} 2
+op_seconds_bucket{op="read",le="1"} 2
+op_seconds_bucket{op="read",le="+Inf"} 3
+op_seconds_sum{op="read"} 3.15
+op_seconds_count{op="read"} 3
+`
+    if out.String() != want {
+        t.Errorf("Expected\n%s\nbut got\n%s", want, out.String())
+    }
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "log/slog"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/notify"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/router"
+    "LibraryGo/internal/service"
+)
+
+// fakeSMTPServer accepts mail on a local port and hands each message body to a channel
+func fakeSMTPServer(t *testing.T) (string, int, <-chan string) {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Failed to listen: %v", err)
+    }
+    t.Cleanup(func() { listener.Close() })
+
+    messages := make(chan string, 10)
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            go serveSMTP(conn, messages)
+        }
+    }()
+
+    addr := listener.Addr().(*net.TCPAddr)
+    return addr.IP.String(), addr.Port, messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- string) {
+    defer conn.Close()
+    reader := bufio.NewReader(conn)
+    reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+    reply("220 localhost fake SMTP")
+    for {
+        line, err := reader.ReadString('\n')
+        if err != nil {
+            return
+        }
+        command := strings.ToUpper(strings.TrimSpace(line))
+        switch {
+        case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+            reply("250 localhost")
+        case strings.HasPrefix(command, "DATA"):
+            reply("354 End data with <CR><LF>.<CR><LF>")
+            var data strings.Builder
+            for {
+                dataLine, err := reader.ReadString('\n')
+                if err != nil {
+                    return
+                }
+                if dataLine == ".\r\n" {
+                    break
+                }
+                data.WriteString(dataLine)
+            }
+            messages <- data.String()
+            reply("250 OK")
+        case strings.HasPrefix(command, "RCPT") && strings.Contains(command, "UNKNOWN"):
+            reply("550 No such user")
+        case strings.HasPrefix(command, "QUIT"):
+            reply("221 Bye")
+            return
+        default:
+            reply("250 OK")
+        }
+    }
+}
+
+// newNotificationFixture builds a notification service over fresh repositories
+func newNotificationFixture(t *testing.T, defaultChannel string, channels ...notify.Channel) (*service.NotificationService, *service.CirculationService, *repository.PatronRepository, model.Book) {
+    books := repository.NewBookRepository(slog.Default())
+    patrons := repository.NewPatronRepository()
+    circulation := service.NewCirculationService(repository.NewLoanRepository(), repository.NewHoldRepository(), books, patrons, repository.NewCopyRepository())
+
+    templates, err := notify.NewTemplates()
+    if err != nil {
+        t.Fatalf("Failed to parse templates: %v", err)
+    }
+    retry := notify.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+    notifications := service.NewNotificationService(patrons, books, circulation, repository.NewNotificationRepository(),
+        templates, retry, defaultChannel, channels...)
+
+    book := books.AddBook(context.Background(), model.Book{Title: "Test Book", Author: "Test Author", PublishedYear: 2024})
+    return notifications, circulation, patrons, book
+}
+
+func TestSMTPOverdueNotice(t *testing.T) {
+    host, port, messages := fakeSMTPServer(t)
+    smtpChannel := notify.NewSMTPChannel(notify.SMTPConfig{Host: host, Port: port, From: "library@example.org"})
+    notifications, circulation, patrons, book := newNotificationFixture(t, "smtp", smtpChannel)
+
+    now := time.Now()
+    circulation.SetClock(func() time.Time { return now })
+    patron := patrons.AddPatron(model.Patron{Name: "Dana", Email: "dana@example.org", Locale: "he"})
+    if _, err := circulation.Checkout(book.ID, patron.ID, 0); err != nil {
+        t.Fatalf("Failed to check out: %v", err)
+    }
+
+    now = now.Add(service.DefaultLoanPeriod + time.Hour)
+    circulation.MarkOverdueLoans(context.Background())
+    sent, err := notifications.SendOverdueNotices(context.Background())
+    if err != nil || sent != 1 {
+        t.Fatalf("Expected one notice to be sent but got %d (%v)", sent, err)
+    }
+
+    select {
+    case msg := <-messages:
+        if !strings.Contains(msg, "To: dana@example.org") || !strings.Contains(msg, "Test Book") {
+            t.Errorf("Unexpected message:\n%s", msg)
+        }
+        if !strings.Contains(msg, "שלום Dana") {
+            t.Errorf("Expected a Hebrew message body but got:\n%s", msg)
+        }
+    case <-time.After(2 * time.Second):
+        t.Fatal("Fake SMTP server received no message")
+    }
+
+    // A second run must not notify about the same loan again
+    if sent, _ := notifications.SendOverdueNotices(context.Background()); sent != 0 {
+        t.Errorf("Expected no repeated notices but %d were sent", sent)
+    }
+}
+
+func TestSMTPRejectedRecipient(t *testing.T) {
+    host, port, _ := fakeSMTPServer(t)
+    smtpChannel := notify.NewSMTPChannel(notify.SMTPConfig{Host: host, Port: port, From: "library@example.org"})
+    notifications, _, patrons, book := newNotificationFixture(t, "smtp", smtpChannel)
+
+    // A recipient the server refuses is not retried
+    patron := patrons.AddPatron(model.Patron{Name: "Dana", Email: "unknown@example.org"})
+    data := notify.TemplateData{BookTitle: book.Title, BookAuthor: book.Author, DueDate: time.Now()}
+    entry, err := notifications.Notify(context.Background(), patron.ID, model.NotificationDueSoon, "loan:1", data)
+    if !notify.IsPermanent(err) || entry.Status != model.DeliveryStatusUndeliverable || entry.Attempts != 1 {
+        t.Errorf("Expected one attempt and an undeliverable entry but got %s after %d (%v)", entry.Status, entry.Attempts, err)
+    }
+
+    // Display names are dropped from addresses, leaving a valid recipient
+    r := router.SetupRouter()
+    var created model.Patron
+    _, response := doJSON(t, r, "POST", "/patrons", model.Patron{Name: "Ada", Email: "Ada Lovelace <ada@example.org>"})
+    decodeData(t, response, &created)
+    if created.Email != "ada@example.org" {
+        t.Errorf("Expected the bare address to be stored but got %q", created.Email)
+    }
+}
+
+func TestWebhookRetryAndDeliveryLog(t *testing.T) {
+    var calls int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&calls, 1) < 3 {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        var msg notify.Message
+        json.NewDecoder(r.Body).Decode(&msg)
+        if msg.Kind != model.NotificationHoldReady {
+            t.Errorf("Expected kind %s but got %s", model.NotificationHoldReady, msg.Kind)
+        }
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer server.Close()
+
+    notifications, circulation, patrons, book := newNotificationFixture(t, "webhook", notify.NewWebhookChannel(server.URL, time.Second))
+    patron := patrons.AddPatron(model.Patron{Name: "Noa"})
+    if _, err := circulation.PlaceHold(book.ID, patron.ID); err != nil {
+        t.Fatalf("Failed to place hold: %v", err)
+    }
+
+    sent, err := notifications.SendHoldReadyNotices(context.Background())
+    if err != nil || sent != 1 {
+        t.Fatalf("Expected one notice to be sent but got %d (%v)", sent, err)
+    }
+
+    log, err := notifications.GetDeliveries(patron.ID, "", "")
+    if err != nil || len(log) != 1 {
+        t.Fatalf("Expected one delivery log entry but got %+v (%v)", log, err)
+    }
+    if log[0].Status != model.DeliveryStatusSent || log[0].Attempts != 3 {
+        t.Errorf("Expected sent after 3 attempts but got %s after %d", log[0].Status, log[0].Attempts)
+    }
+}
+
+func TestOutboxAndUndeliverable(t *testing.T) {
+    outboxPath := filepath.Join(t.TempDir(), "outbox.jsonl")
+    notifications, _, patrons, book := newNotificationFixture(t, "outbox", notify.NewOutboxChannel(outboxPath))
+
+    outboxPatron := patrons.AddPatron(model.Patron{Name: "Avi"})
+    smtpPatron := patrons.AddPatron(model.Patron{Name: "Tal", PreferredChannel: "smtp"})
+    data := notify.TemplateData{BookTitle: book.Title, BookAuthor: book.Author, DueDate: time.Now()}
+
+    if _, err := notifications.Notify(context.Background(), outboxPatron.ID, model.NotificationDueSoon, "loan:1", data); err != nil {
+        t.Fatalf("Failed to write to outbox: %v", err)
+    }
+    contents, err := os.ReadFile(outboxPath)
+    if err != nil || !strings.Contains(string(contents), "Reminder") {
+        t.Errorf("Expected reminder in outbox but got %q (%v)", contents, err)
+    }
+
+    entry, err := notifications.Notify(context.Background(), smtpPatron.ID, model.NotificationDueSoon, "loan:2", data)
+    if !notify.IsPermanent(err) || entry.Status != model.DeliveryStatusUndeliverable {
+        t.Errorf("Expected undeliverable entry for unconfigured channel but got %s (%v)", entry.Status, err)
+    }
+}
+
+func TestPreferredChannelValidated(t *testing.T) {
+    r := router.SetupRouter()
+
+    // Out of the box messages go to the outbox file, and there is no mail server
+    if code, _ := doJSON(t, r, "POST", "/patrons", model.Patron{Name: "Ann", PreferredChannel: "outbox"}); code != http.StatusCreated {
+        t.Errorf("Expected the outbox to be accepted but got %d", code)
+    }
+    for _, channel := range []string{"smtp", "pigeon"} {
+        code, response := doJSON(t, r, "POST", "/patrons", model.Patron{Name: "Ben", PreferredChannel: channel})
+        if code != http.StatusBadRequest || response.Error == nil || !strings.Contains(response.Error.Details, "outbox, webhook") {
+            t.Errorf("Expected %s to be refused with the configured channels but got %d %+v", channel, code, response.Error)
+        }
+    }
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+    "time"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/auth"
+    "LibraryGo/internal/auth/oidctest"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+    "LibraryGo/internal/service"
+)
+
+const testRedirectURL = "http://library.test/auth/callback"
+
+// startProvider runs a fake identity provider for the test
+func startProvider(t *testing.T, user oidctest.User) *oidctest.Provider {
+    provider, err := oidctest.NewProvider("library", "client-secret", user)
+    if err != nil {
+        t.Fatalf("Failed to start provider: %v", err)
+    }
+    t.Cleanup(provider.Close)
+    return provider
+}
+
+// approve sends the browser to the provider and returns the callback URL it is redirected to
+func approve(t *testing.T, authURL string) *url.URL {
+    client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+    resp, err := client.Get(authURL)
+    if err != nil {
+        t.Fatalf("Failed to reach provider: %v", err)
+    }
+    resp.Body.Close()
+    callback, err := url.Parse(resp.Header.Get("Location"))
+    if resp.StatusCode != http.StatusFound || err != nil {
+        t.Fatalf("Expected the provider to redirect back but got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+    }
+    return callback
+}
+
+// browserLogin runs the whole login and returns the callback response
+func browserLogin(t *testing.T, r *mux.Router, returnTo string) (*httptest.ResponseRecorder, *url.URL) {
+    req, _ := http.NewRequest("GET", "/auth/login?returnTo="+url.QueryEscape(returnTo), nil)
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusFound {
+        t.Fatalf("Expected a redirect to the provider but got %d: %s", w.Code, w.Body.String())
+    }
+    callback := approve(t, w.Header().Get("Location"))
+
+    req, _ = http.NewRequest("GET", "/auth/callback?"+callback.RawQuery, nil)
+    for _, cookie := range w.Result().Cookies() {
+        req.AddCookie(cookie)
+    }
+    w = httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    return w, callback
+}
+
+// sessionRequest sends a request with a session cookie
+func sessionRequest(r *mux.Router, method, url string, cookie *http.Cookie) (int, model.APIResponse) {
+    req, _ := http.NewRequest(method, url, nil)
+    if cookie != nil {
+        req.AddCookie(cookie)
+    }
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    var response model.APIResponse
+    json.NewDecoder(w.Body).Decode(&response)
+    return w.Code, response
+}
+
+func TestOIDCLogin(t *testing.T) {
+    provider := startProvider(t, oidctest.User{Subject: "u-1001", Name: "Robin Reed"})
+    cfg := config.Default()
+    cfg.Auth.OIDCIssuer = provider.URL
+    cfg.Auth.OIDCClientID = "library"
+    cfg.Auth.OIDCClientSecret = "client-secret"
+    cfg.Auth.OIDCRedirectURL = testRedirectURL
+    cfg.Auth.OIDCDefaultRole = auth.RoleStaff
+    r := router.NewApp(cfg).Router
+
+    w, callback := browserLogin(t, r, "/loans")
+    if w.Code != http.StatusFound || w.Header().Get("Location") != "/loans" {
+        t.Fatalf("Expected a redirect to the return path but got %d %q: %s", w.Code, w.Header().Get("Location"), w.Body.String())
+    }
+    var cookie *http.Cookie
+    for _, c := range w.Result().Cookies() {
+        if c.Name == auth.SessionCookie {
+            cookie = c
+        }
+    }
+    if cookie == nil || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.Secure {
+        t.Fatalf("Expected an HttpOnly Lax session cookie but got %+v", cookie)
+    }
+
+    var principal model.Principal
+    code, response := sessionRequest(r, "GET", "/auth/me", cookie)
+    decodeData(t, response, &principal)
+    if code != http.StatusOK || principal.ID != "u-1001" || principal.Kind != model.PrincipalSession || len(principal.Roles) != 1 || principal.Roles[0] != auth.RoleStaff {
+        t.Fatalf("Expected the session's staff principal but got %d %+v", code, principal)
+    }
+    if code, _ := sessionRequest(r, "GET", "/loans", cookie); code != http.StatusOK {
+        t.Errorf("Expected the session to reach staff routes but got %d", code)
+    }
+
+    // A callback is only good once
+    req, _ := http.NewRequest("GET", "/auth/callback?"+callback.RawQuery, nil)
+    req.AddCookie(&http.Cookie{Name: auth.LoginStateCookie, Value: callback.Query().Get("state")})
+    replay := httptest.NewRecorder()
+    r.ServeHTTP(replay, req)
+    if replay.Code != http.StatusBadRequest {
+        t.Errorf("Expected a replayed callback to be refused but got %d", replay.Code)
+    }
+
+    // Roles in the ID token replace the default role; discovery and keys stay cached
+    provider.SetUser(oidctest.User{Subject: "u-1", Name: "Ada", Roles: []string{auth.RoleAdmin}})
+    w, _ = browserLogin(t, r, "")
+    if w.Code != http.StatusFound || w.Header().Get("Location") != "/auth/me" {
+        t.Fatalf("Expected a second login to succeed but got %d: %s", w.Code, w.Body.String())
+    }
+    if provider.Hits("/.well-known/openid-configuration") != 1 || provider.Hits("/jwks") != 1 {
+        t.Errorf("Expected discovery and keys to be fetched once but got %d and %d",
+            provider.Hits("/.well-known/openid-configuration"), provider.Hits("/jwks"))
+    }
+    var admin *http.Cookie
+    for _, c := range w.Result().Cookies() {
+        if c.Name == auth.SessionCookie {
+            admin = c
+        }
+    }
+    if code, _ := sessionRequest(r, "GET", "/admin/jobs", admin); code != http.StatusOK {
+        t.Errorf("Expected the admin session to list jobs but got %d", code)
+    }
+    if code, _ := sessionRequest(r, "GET", "/admin/jobs", cookie); code != http.StatusForbidden {
+        t.Errorf("Expected the staff session to be refused jobs but got %d", code)
+    }
+
+    // Only pages of this server may log the browser out
+    logout := func(origin string, cookie *http.Cookie) int {
+        req, _ := http.NewRequest("POST", "/auth/logout", nil)
+        req.Header.Set("Origin", origin)
+        if cookie != nil {
+            req.AddCookie(cookie)
+        }
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        return w.Code
+    }
+    if code := logout("https://evil.example", cookie); code != http.StatusForbidden {
+        t.Errorf("Expected a cross-site logout to be refused but got %d", code)
+    }
+    if code := logout("http://library.test", nil); code != http.StatusUnauthorized {
+        t.Errorf("Expected a logout without a session to be refused but got %d", code)
+    }
+
+    // Logging out ends the session; the stale cookie leaves the browser anonymous
+    if code := logout("http://library.test", cookie); code != http.StatusNoContent {
+        t.Fatalf("Expected logout to succeed but got %d", code)
+    }
+    if code, _ := sessionRequest(r, "GET", "/loans", cookie); code != http.StatusUnauthorized {
+        t.Errorf("Expected the ended session to be refused but got %d", code)
+    }
+    if code, _ := sessionRequest(r, "GET", "/books", cookie); code != http.StatusOK {
+        t.Errorf("Expected a stale cookie to browse as anonymous but got %d", code)
+    }
+
+    // A callback only completes in the browser that started the login
+    req, _ = http.NewRequest("GET", "/auth/login", nil)
+    start := httptest.NewRecorder()
+    r.ServeHTTP(start, req)
+    var state *http.Cookie
+    for _, c := range start.Result().Cookies() {
+        if c.Name == auth.LoginStateCookie {
+            state = c
+        }
+    }
+    if state == nil || !state.HttpOnly || state.SameSite != http.SameSiteLaxMode || state.Value == "" {
+        t.Fatalf("Expected an HttpOnly Lax login state cookie but got %+v", state)
+    }
+    callback = approve(t, start.Header().Get("Location"))
+    for name, cookie := range map[string]*http.Cookie{
+        "no state cookie":          nil,
+        "another browser's cookie": {Name: auth.LoginStateCookie, Value: "someone-elses-state"},
+    } {
+        if code, response := sessionRequest(r, "GET", "/auth/callback?"+callback.RawQuery, cookie); code != http.StatusBadRequest || response.Error.Code != "INVALID_REQUEST" {
+            t.Errorf("Expected a callback with %s to be refused but got %d", name, code)
+        }
+    }
+
+    code, response = sessionRequest(r, "GET", "/auth/login?returnTo="+url.QueryEscape("//evil.example/"), nil)
+    if code != http.StatusBadRequest || response.Error.Code != "INVALID_PARAMETER" {
+        t.Errorf("Expected an off-site return path to be refused but got %d", code)
+    }
+    code, response = sessionRequest(r, "GET", "/auth/callback?error=access_denied&state=x", nil)
+    if code != http.StatusUnauthorized || response.Error.Code != "UNAUTHORIZED" {
+        t.Errorf("Expected a refused login to be reported but got %d", code)
+    }
+}
+
+func TestOIDCLoginWithoutRole(t *testing.T) {
+    provider := startProvider(t, oidctest.User{Subject: "u-1001", Name: "Robin Reed"})
+    cfg := config.Default()
+    cfg.Auth.OIDCIssuer = provider.URL
+    cfg.Auth.OIDCClientID = "library"
+    cfg.Auth.OIDCClientSecret = "client-secret"
+    cfg.Auth.OIDCRedirectURL = testRedirectURL
+    r := router.NewApp(cfg).Router
+
+    // Without a default role, a user the provider gave no roles does not get in
+    w, _ := browserLogin(t, r, "")
+    for _, cookie := range w.Result().Cookies() {
+        if cookie.Name == auth.SessionCookie && cookie.Value != "" {
+            t.Errorf("Expected no session but got %+v", cookie)
+        }
+    }
+    if w.Code != http.StatusForbidden {
+        t.Errorf("Expected a user without roles to be refused but got %d: %s", w.Code, w.Body.String())
+    }
+}
+
+func TestPendingLoginsCapped(t *testing.T) {
+    provider := startProvider(t, oidctest.User{Subject: "u-1001", Name: "Robin Reed", Roles: []string{auth.RoleStaff}})
+    cfg := config.Default()
+    cfg.Auth.OIDCIssuer = provider.URL
+    cfg.Auth.OIDCClientID = "library"
+    cfg.Auth.OIDCClientSecret = "client-secret"
+    cfg.Auth.OIDCRedirectURL = testRedirectURL
+    cfg.RateLimit.Disabled = true
+    r := router.NewApp(cfg).Router
+
+    start := func() *httptest.ResponseRecorder {
+        req, _ := http.NewRequest("GET", "/auth/login", nil)
+        w := httptest.NewRecorder()
+        r.ServeHTTP(w, req)
+        return w
+    }
+
+    // Once the cap is reached new logins are refused, and those in progress still complete
+    first := start()
+    for i := 1; i < service.MaxPendingLogins; i++ {
+        start()
+    }
+    if w := start(); w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+        t.Errorf("Expected a login over the cap to be refused but got %d", w.Code)
+    }
+    callback := approve(t, first.Header().Get("Location"))
+    req, _ := http.NewRequest("GET", "/auth/callback?"+callback.RawQuery, nil)
+    for _, cookie := range first.Result().Cookies() {
+        req.AddCookie(cookie)
+    }
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    if w.Code != http.StatusFound {
+        t.Errorf("Expected the first login to complete but got %d: %s", w.Code, w.Body.String())
+    }
+    if w, _ := browserLogin(t, r, ""); w.Code != http.StatusFound {
+        t.Errorf("Expected a new login to succeed once one completed but got %d: %s", w.Code, w.Body.String())
+    }
+}
+
+func TestOIDCClient(t *testing.T) {
+    provider := startProvider(t, oidctest.User{Subject: "u-7", Name: "Kim"})
+    client := auth.NewOIDCClient(auth.OIDCConfig{Issuer: provider.URL, ClientID: "library", ClientSecret: "client-secret",
+        RedirectURL: testRedirectURL}, nil)
+    now := time.Now()
+    client.SetClock(func() time.Time { return now })
+    ctx := context.Background()
+
+    login := func(nonce string) (code, verifier string) {
+        verifier, challenge, err := auth.NewPKCEVerifier()
+        if err != nil {
+            t.Fatalf("Failed to create verifier: %v", err)
+        }
+        authURL, err := client.AuthCodeURL(ctx, "state", nonce, challenge)
+        if err != nil {
+            t.Fatalf("Failed to build the authorization URL: %v", err)
+        }
+        return approve(t, authURL).Query().Get("code"), verifier
+    }
+
+    code, verifier := login("n-1")
+    claims, err := client.Exchange(ctx, code, verifier, "n-1")
+    if err != nil || claims.Subject != "u-7" {
+        t.Fatalf("Expected the code to be exchanged but got %+v %v", claims, err)
+    }
+
+    code, _ = login("n-2")
+    if _, err := client.Exchange(ctx, code, "not-the-verifier", "n-2"); !errors.Is(err, auth.ErrCodeExchange) {
+        t.Errorf("Expected a wrong PKCE verifier to be refused but got %v", err)
+    }
+    code, verifier = login("n-3")
+    if _, err := client.Exchange(ctx, code, verifier, "another-login"); !errors.Is(err, auth.ErrNonceMismatch) {
+        t.Errorf("Expected a token for another login to be refused but got %v", err)
+    }
+
+    // A rotated key is picked up once the cached key set is old enough to refetch
+    provider.RotateKey()
+    code, verifier = login("n-4")
+    if _, err := client.Exchange(ctx, code, verifier, "n-4"); !errors.Is(err, auth.ErrInvalidToken) {
+        t.Errorf("Expected keys not to be refetched right away but got %v", err)
+    }
+    now = now.Add(2 * time.Minute)
+    code, verifier = login("n-5")
+    if _, err := client.Exchange(ctx, code, verifier, "n-5"); err != nil {
+        t.Errorf("Expected the rotated key to be fetched but got %v", err)
+    }
+    if provider.Hits("/jwks") != 2 || provider.Hits("/.well-known/openid-configuration") != 1 {
+        t.Errorf("Expected 2 key fetches and 1 discovery but got %d and %d", provider.Hits("/jwks"), provider.Hits("/.well-known/openid-configuration"))
+    }
+}
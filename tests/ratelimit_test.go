@@ -0,0 +1,129 @@
+package handler
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/auth"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/ratelimit"
+    "LibraryGo/internal/router"
+)
+
+// clientRequest sends a GET request from an IP address, with an optional API key
+func clientRequest(r *mux.Router, url, ip, key string) *httptest.ResponseRecorder {
+    req, _ := http.NewRequest("GET", url, nil)
+    req.RemoteAddr = ip + ":40000"
+    if key != "" {
+        req.Header.Set("X-API-Key", key)
+    }
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    return w
+}
+
+func TestRateLimiting(t *testing.T) {
+    dir := t.TempDir()
+    keys, _ := json.Marshal([]auth.APIKey{{Name: "discovery-layer", Hash: auth.HashAPIKey("lib_discovery"), Roles: []string{auth.RoleStaff}}})
+    os.WriteFile(filepath.Join(dir, "keys.json"), keys, 0600)
+    cfg := config.Default()
+    cfg.Auth.APIKeysFile = filepath.Join(dir, "keys.json")
+    cfg.RateLimit.Default = "5/1m"
+    cfg.RateLimit.Routes = "GET /books=3/1m;GET /auth/me=off"
+    r := router.NewApp(cfg).Router
+
+    for i := 0; i < 3; i++ {
+        w := clientRequest(r, "/books", "198.51.100.7", "")
+        if w.Code != http.StatusOK {
+            t.Fatalf("Expected request %d to be allowed but got %d", i+1, w.Code)
+        }
+        if w.Header().Get("RateLimit-Limit") != "3" || w.Header().Get("RateLimit-Policy") != "3;w=60" {
+            t.Errorf("Expected the route's limit in the headers but got %v", w.Header())
+        }
+    }
+    w := clientRequest(r, "/books", "198.51.100.7", "")
+    var response model.APIResponse
+    json.NewDecoder(w.Body).Decode(&response)
+    if w.Code != http.StatusTooManyRequests || response.Error == nil || response.Error.Code != "RATE_LIMITED" {
+        t.Fatalf("Expected the fourth search to be limited but got %d %+v", w.Code, response.Error)
+    }
+    if w.Header().Get("Retry-After") != "20" || w.Header().Get("RateLimit-Remaining") != "0" {
+        t.Errorf("Expected a token back in 20 seconds but got Retry-After %q, remaining %q",
+            w.Header().Get("Retry-After"), w.Header().Get("RateLimit-Remaining"))
+    }
+
+    // Other clients, keys and routes have buckets of their own
+    if w := clientRequest(r, "/books", "198.51.100.8", ""); w.Code != http.StatusOK {
+        t.Errorf("Expected another address to be allowed but got %d", w.Code)
+    }
+    if w := clientRequest(r, "/books", "198.51.100.7", "lib_discovery"); w.Code != http.StatusOK {
+        t.Errorf("Expected an API key to be counted on its own but got %d", w.Code)
+    }
+    if w := clientRequest(r, "/books/1", "198.51.100.7", ""); w.Code == http.StatusTooManyRequests || w.Header().Get("RateLimit-Limit") != "5" {
+        t.Errorf("Expected the default limit on other routes but got %d with limit %q", w.Code, w.Header().Get("RateLimit-Limit"))
+    }
+    for i := 0; i < 10; i++ {
+        if w := clientRequest(r, "/auth/me", "198.51.100.7", ""); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+            t.Fatalf("Expected an unlimited route to be left alone but got %d", w.Code)
+        }
+    }
+}
+
+func TestBadCredentialsThrottled(t *testing.T) {
+    dir := t.TempDir()
+    keys, _ := json.Marshal([]auth.APIKey{{Name: "discovery-layer", Hash: auth.HashAPIKey("lib_discovery"), Roles: []string{auth.RoleStaff}}})
+    os.WriteFile(filepath.Join(dir, "keys.json"), keys, 0600)
+    cfg := config.Default()
+    cfg.Auth.APIKeysFile = filepath.Join(dir, "keys.json")
+    cfg.RateLimit.PerIP = "3/1m"
+    r := router.NewApp(cfg).Router
+
+    // Refused credentials never reach the per-route limits, but still count against the address
+    for i := 0; i < 3; i++ {
+        if w := clientRequest(r, "/books", "198.51.100.7", "lib_guess"); w.Code != http.StatusUnauthorized {
+            t.Fatalf("Expected guess %d to be refused but got %d", i+1, w.Code)
+        }
+    }
+    w := clientRequest(r, "/books", "198.51.100.7", "lib_discovery")
+    if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "20" {
+        t.Errorf("Expected the address to be throttled after three guesses but got %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+    }
+    if w := clientRequest(r, "/books", "198.51.100.8", "lib_guess"); w.Code != http.StatusUnauthorized {
+        t.Errorf("Expected another address to be counted on its own but got %d", w.Code)
+    }
+}
+
+func TestTokenBucket(t *testing.T) {
+    limiter := ratelimit.NewLimiter()
+    now := time.Now()
+    limiter.SetClock(func() time.Time { return now })
+    limit, err := ratelimit.ParseLimit("2/s")
+    if err != nil || limit.Requests != 2 || limit.Window != time.Second {
+        t.Fatalf("Expected 2 per second but got %+v %v", limit, err)
+    }
+
+    limiter.Allow("client", limit)
+    if decision := limiter.Allow("client", limit); !decision.Allowed || decision.Remaining != 0 {
+        t.Fatalf("Expected a burst of 2 but got %+v", decision)
+    }
+    decision := limiter.Allow("client", limit)
+    if decision.Allowed || decision.RetryAfter != 500*time.Millisecond {
+        t.Fatalf("Expected to wait half a second but got %+v", decision)
+    }
+    now = now.Add(500 * time.Millisecond)
+    if decision := limiter.Allow("client", limit); !decision.Allowed {
+        t.Errorf("Expected a token to have refilled but got %+v", decision)
+    }
+
+    for _, spec := range []string{"", "60", "0/1m", "x/1m", "10/-1s", "10/fortnight"} {
+        if _, err := ratelimit.ParseLimit(spec); err == nil {
+            t.Errorf("Expected %q to be rejected", spec)
+        }
+    }
+}
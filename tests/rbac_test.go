@@ -0,0 +1,86 @@
+package handler
+
+import (
+    "bytes"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "github.com/gorilla/mux"
+    "LibraryGo/internal/auth"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+// keyRequest sends a JSON request with an API key, or none when key is empty
+func keyRequest(r *mux.Router, method, url, key string, body interface{}) (int, model.APIResponse) {
+    var payload bytes.Buffer
+    if body != nil {
+        json.NewEncoder(&payload).Encode(body)
+    }
+    req, _ := http.NewRequest(method, url, &payload)
+    req.Header.Set("Content-Type", "application/json")
+    if key != "" {
+        req.Header.Set("X-API-Key", key)
+    }
+    w := httptest.NewRecorder()
+    r.ServeHTTP(w, req)
+    var response model.APIResponse
+    json.NewDecoder(w.Body).Decode(&response)
+    return w.Code, response
+}
+
+func TestRolePermissions(t *testing.T) {
+    dir := t.TempDir()
+    keys, _ := json.Marshal([]auth.APIKey{
+        {Name: "front-desk", Hash: auth.HashAPIKey("lib_staff"), Roles: []string{auth.RoleStaff}},
+        {Name: "systems", Hash: auth.HashAPIKey("lib_admin"), Roles: []string{auth.RoleAdmin}},
+        {Name: "kiosk", Hash: auth.HashAPIKey("lib_kiosk"), Roles: []string{"kiosk"}},
+    })
+    os.WriteFile(filepath.Join(dir, "keys.json"), keys, 0600)
+    cfg := config.Default()
+    cfg.Auth.APIKeysFile = filepath.Join(dir, "keys.json")
+    r := router.NewApp(cfg).Router
+
+    book := model.Book{Title: "Test Book 1", Author: "Test Author 1", PublishedYear: 2024}
+    if code, response := keyRequest(r, "POST", "/books", "", book); code != http.StatusUnauthorized || response.Error.Code != "UNAUTHORIZED" {
+        t.Errorf("Expected anonymous callers to be asked for credentials but got %d", code)
+    }
+    if code, _ := keyRequest(r, "POST", "/books", "lib_kiosk", book); code != http.StatusForbidden {
+        t.Errorf("Expected an unknown role to grant nothing but got %d", code)
+    }
+    if code, _ := keyRequest(r, "POST", "/books", "lib_staff", book); code != http.StatusCreated {
+        t.Fatalf("Expected staff to add books but got %d", code)
+    }
+    if code, _ := keyRequest(r, "GET", "/books/1", "", nil); code != http.StatusOK {
+        t.Errorf("Expected anonymous callers to read the catalogue but got %d", code)
+    }
+
+    code, response := keyRequest(r, "DELETE", "/books/1", "lib_staff", nil)
+    if code != http.StatusForbidden || response.Error == nil || response.Error.Code != "FORBIDDEN" || response.Error.Details != auth.PermCatalogDelete {
+        t.Fatalf("Expected staff to be refused catalog:delete but got %d %+v", code, response.Error)
+    }
+    if code, _ := keyRequest(r, "GET", "/admin/jobs", "lib_staff", nil); code != http.StatusForbidden {
+        t.Errorf("Expected staff to be refused jobs but got %d", code)
+    }
+    if code, _ := keyRequest(r, "GET", "/admin/jobs", "lib_admin", nil); code != http.StatusOK {
+        t.Errorf("Expected admins to list jobs but got %d", code)
+    }
+    if code, _ := keyRequest(r, "DELETE", "/books/1", "lib_admin", nil); code != http.StatusOK && code != http.StatusNoContent {
+        t.Errorf("Expected admins to delete books but got %d", code)
+    }
+
+    var principal model.Principal
+    _, response = keyRequest(r, "GET", "/auth/me", "lib_staff", nil)
+    decodeData(t, response, &principal)
+    granted := make(map[string]bool)
+    for _, permission := range principal.Permissions {
+        granted[permission] = true
+    }
+    if !granted[auth.PermCirculationWrite] || granted[auth.PermCatalogDelete] {
+        t.Errorf("Expected the permissions of the staff role but got %v", principal.Permissions)
+    }
+}
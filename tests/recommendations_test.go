@@ -0,0 +1,145 @@
+package handler
+
+import (
+    "net/http"
+    "strconv"
+    "testing"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+    "github.com/gorilla/mux"
+)
+
+// borrowAndReturn checks a book out to a patron and brings it straight back
+func borrowAndReturn(t *testing.T, r *mux.Router, bookID, patronID int) {
+    code, response := doJSON(t, r, "POST", "/loans", map[string]int{"bookId": bookID, "patronId": patronID})
+    var loan model.Loan
+    decodeData(t, response, &loan)
+    if code != http.StatusCreated {
+        t.Fatalf("Failed to lend book %d to patron %d: %d", bookID, patronID, code)
+    }
+    doJSON(t, r, "POST", "/loans/"+strconv.Itoa(loan.ID)+"/return", nil)
+}
+
+// recommended lists the book IDs and reasons of a recommendations response
+func recommended(t *testing.T, response model.APIResponse) ([]int, []string) {
+    var recommendations []model.Recommendation
+    decodeData(t, response, &recommendations)
+    ids := []int{}
+    reasons := []string{}
+    for _, recommendation := range recommendations {
+        ids = append(ids, recommendation.Book.ID)
+        reasons = append(reasons, recommendation.Reason)
+    }
+    return ids, reasons
+}
+
+func equalInts(a, b []int) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+func TestRecommendations(t *testing.T) {
+    r := router.SetupRouter()
+    books := []model.Book{
+        {Title: "Dune", Author: "Frank Herbert", PublishedYear: 1965},
+        {Title: "Foundation", Author: "Isaac Asimov", PublishedYear: 1951},
+        {Title: "Hyperion", Author: "Dan Simmons", PublishedYear: 1989},
+        {Title: "Children of Dune", Author: "Frank Herbert", PublishedYear: 1976},
+        {Title: "Emma", Author: "Jane Austen", PublishedYear: 1815},
+    }
+    for _, book := range books {
+        doJSON(t, r, "POST", "/books", book)
+    }
+    for _, name := range []string{"Ann", "Ben", "Cid", "Dee", "Eve"} {
+        doJSON(t, r, "POST", "/patrons", model.Patron{Name: name})
+    }
+
+    borrowAndReturn(t, r, 1, 1)
+    borrowAndReturn(t, r, 2, 1)
+    borrowAndReturn(t, r, 1, 2)
+    borrowAndReturn(t, r, 2, 2)
+    borrowAndReturn(t, r, 3, 2)
+    borrowAndReturn(t, r, 1, 3)
+    borrowAndReturn(t, r, 5, 4)
+
+    // Foundation shares two readers with Dune, Hyperion one; another Herbert fills in
+    code, response := doJSON(t, r, "GET", "/books/1/related", nil)
+    ids, reasons := recommended(t, response)
+    if code != http.StatusOK || !equalInts(ids, []int{2, 3, 4}) {
+        t.Fatalf("Expected books 2, 3, 4 related to Dune but got %d %v", code, ids)
+    }
+    if reasons[0] != model.ReasonBorrowedTogether || reasons[2] != model.ReasonSameAuthor {
+        t.Errorf("Unexpected reasons %v", reasons)
+    }
+
+    // A book nobody borrowed falls back to its author
+    _, response = doJSON(t, r, "GET", "/books/4/related", nil)
+    if ids, _ := recommended(t, response); !equalInts(ids, []int{1}) {
+        t.Errorf("Expected Dune related to Children of Dune but got %v", ids)
+    }
+
+    // Patrons are not offered what they already read
+    _, response = doJSON(t, r, "GET", "/patrons/3/recommendations", nil)
+    ids, reasons = recommended(t, response)
+    if !equalInts(ids, []int{2, 3, 4, 5}) || reasons[3] != model.ReasonPopular {
+        t.Errorf("Expected books 2, 3, 4, 5 for Cid but got %v %v", ids, reasons)
+    }
+    _, response = doJSON(t, r, "GET", "/patrons/5/recommendations?limit=2", nil)
+    if ids, _ := recommended(t, response); !equalInts(ids, []int{1, 2}) {
+        t.Errorf("Expected the most borrowed books for a new patron but got %v", ids)
+    }
+
+    // Deleted books drop out of recommendations
+    doJSON(t, r, "DELETE", "/books/2", nil)
+    _, response = doJSON(t, r, "GET", "/books/1/related", nil)
+    if ids, _ := recommended(t, response); !equalInts(ids, []int{3, 4}) {
+        t.Errorf("Expected the deleted book to be skipped but got %v", ids)
+    }
+
+    if code, _ := doJSON(t, r, "GET", "/books/99/related", nil); code != http.StatusNotFound {
+        t.Errorf("Expected unknown book to return 404 but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "GET", "/patrons/99/recommendations", nil); code != http.StatusNotFound {
+        t.Errorf("Expected unknown patron to return 404 but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "GET", "/books/1/related?limit=0", nil); code != http.StatusBadRequest {
+        t.Errorf("Expected invalid limit to be rejected but got %d", code)
+    }
+}
+
+func TestPatronRecommendationsOwnership(t *testing.T) {
+    secret := "a shared secret of at least 32 bytes!"
+    cfg := config.Default()
+    cfg.Auth.JWTSecret = secret
+    cfg.RateLimit.Disabled = true
+    r := router.NewApp(cfg).Router
+
+    staff := memberToken(t, secret, "staff-1", 0, "staff")
+    reed := memberToken(t, secret, "reed", 1, "patron")
+
+    tokenRequest(r, "POST", "/books", staff, model.Book{Title: "Test Book 1", Author: "Test Author 1", PublishedYear: 2024})
+    for _, name := range []string{"Ms Reed", "Ben"} {
+        tokenRequest(r, "POST", "/patrons", staff, model.Patron{Name: name})
+    }
+
+    if code, _ := tokenRequest(r, "GET", "/patrons/1/recommendations", reed, nil); code != http.StatusOK {
+        t.Errorf("Expected a patron to read their own recommendations but got %d", code)
+    }
+    if code, _ := tokenRequest(r, "GET", "/patrons/2/recommendations", reed, nil); code != http.StatusForbidden {
+        t.Errorf("Expected another patron's recommendations to be forbidden but got %d", code)
+    }
+    if code, _ := tokenRequest(r, "GET", "/patrons/1/recommendations", "", nil); code != http.StatusForbidden {
+        t.Errorf("Expected anonymous callers to be refused but got %d", code)
+    }
+    if code, _ := tokenRequest(r, "GET", "/patrons/2/recommendations", staff, nil); code != http.StatusOK {
+        t.Errorf("Expected staff to read any patron's recommendations but got %d", code)
+    }
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+func TestRequestID(t *testing.T) {
+    r := router.SetupRouter()
+
+    tests := []struct {
+        name   string
+        sent   string
+        echoed bool
+    }{
+        {name: "client ID is kept", sent: "req-7f3a9c", echoed: true},
+        {name: "missing ID is generated"},
+        {name: "ID with spaces is replaced", sent: "two words"},
+        {name: "overlong ID is replaced", sent: strings.Repeat("a", 129)},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req, _ := http.NewRequest("GET", "/books/999", nil)
+            if tt.sent != "" {
+                req.Header.Set("X-Request-ID", tt.sent)
+            }
+            w := httptest.NewRecorder()
+            r.ServeHTTP(w, req)
+
+            var response model.APIResponse
+            json.NewDecoder(w.Body).Decode(&response)
+            id := w.Header().Get("X-Request-ID")
+            if id == "" || response.RequestID != id {
+                t.Fatalf("Expected the header and body to carry the same ID but got %q and %q", id, response.RequestID)
+            }
+            if (id == tt.sent) != tt.echoed {
+                t.Errorf("Expected echoed=%v for %q but got %q", tt.echoed, tt.sent, id)
+            }
+        })
+    }
+}
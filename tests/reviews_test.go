@@ -0,0 +1,118 @@
+package handler
+
+import (
+    "net/http"
+    "strconv"
+    "testing"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+func TestReviewsAndRatings(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+    for _, name := range []string{"Ann", "Ben", "Cid"} {
+        doJSON(t, r, "POST", "/patrons", model.Patron{Name: name})
+    }
+
+    reviews := []struct {
+        bookID, patronID, rating int
+    }{
+        {2, 1, 5}, {2, 2, 4}, {2, 3, 1}, {3, 1, 3},
+    }
+    for _, review := range reviews {
+        url := "/books/" + strconv.Itoa(review.bookID) + "/reviews"
+        body := model.Review{PatronID: review.patronID, Rating: review.rating, Text: "Read it"}
+        code, response := doJSON(t, r, "POST", url, body)
+        var created model.Review
+        decodeData(t, response, &created)
+        if code != http.StatusCreated || created.Status != model.ReviewStatusPending {
+            t.Fatalf("Expected pending review but got %d %+v", code, created)
+        }
+    }
+
+    if code, _ := doJSON(t, r, "POST", "/books/2/reviews", model.Review{PatronID: 1, Rating: 2}); code != http.StatusConflict {
+        t.Errorf("Expected a second review by the same patron to conflict but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "POST", "/books/1/reviews", model.Review{PatronID: 1, Rating: 6}); code != http.StatusBadRequest {
+        t.Errorf("Expected rating out of range to be rejected but got %d", code)
+    }
+
+    // Pending reviews neither show publicly nor count towards the rating
+    _, response := doJSON(t, r, "GET", "/books/2/reviews", nil)
+    if response.Meta.Count != 0 {
+        t.Errorf("Expected no public reviews before moderation but got %d", response.Meta.Count)
+    }
+    _, response = doJSON(t, r, "GET", "/reviews?status=pending", nil)
+    if response.Meta.Count != 4 {
+        t.Errorf("Expected four reviews awaiting moderation but got %d", response.Meta.Count)
+    }
+
+    for _, id := range []string{"1", "2", "4"} {
+        doJSON(t, r, "POST", "/reviews/"+id+"/approve", nil)
+    }
+    doJSON(t, r, "POST", "/reviews/3/reject", nil)
+
+    var book model.Book
+    _, response = doJSON(t, r, "GET", "/books/2", nil)
+    decodeData(t, response, &book)
+    if book.Rating == nil || book.Rating.Average != 4.5 || book.Rating.Count != 2 {
+        t.Errorf("Expected a 4.5 average over two reviews but got %+v", book.Rating)
+    }
+
+    var books []model.Book
+    _, response = doJSON(t, r, "GET", "/books?sort=rating", nil)
+    decodeData(t, response, &books)
+    if len(books) != 3 || books[0].ID != 2 || books[1].ID != 3 || books[2].Rating != nil {
+        t.Errorf("Expected books ordered 2, 3, then the unrated one but got %+v", books)
+    }
+}
+
+func TestReviewIdentity(t *testing.T) {
+    secret := "a shared secret of at least 32 bytes!"
+    cfg := config.Default()
+    cfg.Auth.JWTSecret = secret
+    cfg.RateLimit.Disabled = true
+    r := router.NewApp(cfg).Router
+    staff := memberToken(t, secret, "staff-1", 0, "staff")
+    ann := memberToken(t, secret, "ann", 1, "patron")
+
+    tokenRequest(r, "POST", "/books", staff, model.Book{Title: "Test Book 1", Author: "Test Author 1", PublishedYear: 2024})
+    for _, name := range []string{"Ann", "Ben"} {
+        tokenRequest(r, "POST", "/patrons", staff, model.Patron{Name: name})
+    }
+
+    // A patron reviews as themselves, whoever the body names
+    var review model.Review
+    code, response := tokenRequest(r, "POST", "/books/1/reviews", ann, model.Review{PatronID: 2, Rating: 1})
+    decodeData(t, response, &review)
+    if code != http.StatusCreated || review.PatronID != 1 || review.EnteredBy != "" {
+        t.Fatalf("Expected the review to be the caller's but got %d %+v", code, review)
+    }
+    // Staff enter reviews for a patron and are recorded doing so
+    code, response = tokenRequest(r, "POST", "/books/1/reviews", staff, model.Review{PatronID: 2, Rating: 5})
+    decodeData(t, response, &review)
+    if code != http.StatusCreated || review.PatronID != 2 || review.EnteredBy != "staff-1" {
+        t.Fatalf("Expected a review entered by staff for patron 2 but got %d %+v", code, review)
+    }
+    if code, _ := tokenRequest(r, "POST", "/books/1/reviews", staff, model.Review{Rating: 5}); code != http.StatusForbidden {
+        t.Errorf("Expected staff to have to name a patron but got %d", code)
+    }
+
+    // Only moderators look past the approved reviews
+    for _, status := range []string{"pending", "rejected", "all"} {
+        if code, _ := tokenRequest(r, "GET", "/books/1/reviews?status="+status, "", nil); code != http.StatusForbidden {
+            t.Errorf("Expected anonymous callers not to list %s reviews but got %d", status, code)
+        }
+        if code, _ := tokenRequest(r, "GET", "/books/1/reviews?status="+status, ann, nil); code != http.StatusForbidden {
+            t.Errorf("Expected patrons not to list %s reviews but got %d", status, code)
+        }
+    }
+    if _, response := tokenRequest(r, "GET", "/books/1/reviews?status=pending", staff, nil); response.Meta == nil || response.Meta.Count != 2 {
+        t.Errorf("Expected staff to see both pending reviews but got %+v", response.Meta)
+    }
+    if code, response := tokenRequest(r, "GET", "/books/1/reviews", "", nil); code != http.StatusOK || response.Meta.Count != 0 {
+        t.Errorf("Expected anonymous callers to see the approved reviews but got %d", code)
+    }
+}
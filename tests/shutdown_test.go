@@ -0,0 +1,192 @@
+package handler
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "net"
+    "net/http"
+    "path/filepath"
+    "testing"
+    "time"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/jobs"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+// serve runs the app's server on a local port, with a /slow route that answers once
+// release is closed
+func serve(t *testing.T, app *router.App, cfg config.Config, release chan struct{}) (*http.Server, string, chan struct{}) {
+    server := app.NewServer(cfg)
+    started := make(chan struct{}, 1)
+    server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/slow" {
+            app.Router.ServeHTTP(w, r)
+            return
+        }
+        started <- struct{}{}
+        <-release
+        io.WriteString(w, "done")
+    })
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Failed to listen: %v", err)
+    }
+    go server.Serve(listener)
+    return server, "http://" + listener.Addr().String(), started
+}
+
+func TestGracefulShutdown(t *testing.T) {
+    cfg := config.Default()
+    cfg.Auth.Disabled = true
+    cfg.Storage.Dir = filepath.Join(t.TempDir(), "blobs")
+    app := router.NewApp(cfg)
+    app.Scheduler.Start(context.Background())
+
+    server := app.NewServer(cfg)
+    if server.ReadHeaderTimeout != cfg.Server.ReadHeaderTimeout || server.WriteTimeout != cfg.Server.WriteTimeout ||
+        server.IdleTimeout != cfg.Server.IdleTimeout || server.MaxHeaderBytes != 64<<10 {
+        t.Errorf("Expected the configured timeouts and header limit but got %+v", server)
+    }
+    if err := app.Storage.Put("covers/1/small", []byte("jpeg")); err != nil {
+        t.Fatalf("Failed to store a blob: %v", err)
+    }
+
+    release := make(chan struct{})
+    server, url, started := serve(t, app, cfg, release)
+    inFlight := make(chan error, 1)
+    go func() {
+        resp, err := http.Get(url + "/slow")
+        if err == nil {
+            body, _ := io.ReadAll(resp.Body)
+            resp.Body.Close()
+            if string(body) != "done" {
+                err = errors.New("unexpected body " + string(body))
+            }
+        }
+        inFlight <- err
+    }()
+    <-started
+
+    shutdown := make(chan error, 1)
+    go func() { shutdown <- app.Shutdown(context.Background(), server, 200*time.Millisecond) }()
+
+    // While the load balancer catches up, the server still answers but is not ready
+    deadline := time.Now().Add(time.Second)
+    for {
+        resp, err := http.Get(url + "/readyz")
+        if err != nil {
+            t.Fatalf("Expected the server to answer during the drain delay but got %v", err)
+        }
+        resp.Body.Close()
+        if resp.StatusCode == http.StatusServiceUnavailable {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("Expected readiness to fail once shutdown began but got %d", resp.StatusCode)
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+
+    select {
+    case err := <-shutdown:
+        t.Fatalf("Expected shutdown to wait for the request in flight but it returned %v", err)
+    case <-time.After(400 * time.Millisecond):
+    }
+    if _, err := http.Get(url + "/healthz"); err == nil {
+        t.Errorf("Expected new connections to be refused after the drain delay")
+    }
+    close(release)
+    if err := <-inFlight; err != nil {
+        t.Errorf("Expected the request in flight to complete but got %v", err)
+    }
+    if err := <-shutdown; err != nil {
+        t.Fatalf("Expected a clean shutdown but got %v", err)
+    }
+    if err := app.Scheduler.Check(); !errors.Is(err, jobs.ErrSchedulerStopped) {
+        t.Errorf("Expected background jobs to be stopped but got %v", err)
+    }
+    if data, err := app.Storage.Get("covers/1/small"); err != nil || string(data) != "jpeg" {
+        t.Errorf("Expected stored blobs to survive shutdown but got %q %v", data, err)
+    }
+}
+
+func TestShutdownDeadline(t *testing.T) {
+    endpoint, spans := startCollector(t)
+    cfg := config.Default()
+    cfg.Auth.Disabled = true
+    cfg.Tracing.Exporter = "otlp"
+    cfg.Tracing.Endpoint = endpoint
+    app := router.NewApp(cfg)
+    release := make(chan struct{})
+    defer close(release)
+    server, url, started := serve(t, app, cfg, release)
+    if resp, err := http.Get(url + "/healthz"); err == nil {
+        resp.Body.Close()
+    }
+    go http.Get(url + "/slow")
+    <-started
+
+    // A request that outlives the deadline is cut off
+    ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+    defer cancel()
+    err := app.Shutdown(ctx, server, 0)
+    if !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("Expected the deadline to be reported but got %v", err)
+    }
+    if _, err := http.Get(url + "/healthz"); err == nil {
+        t.Errorf("Expected the server to be closed after the deadline")
+    }
+    // Spans are still flushed once the deadline has passed
+    if _, ok := spans()["GET /healthz"]; !ok {
+        t.Errorf("Expected the spans to be flushed after the deadline but got %v", spans())
+    }
+}
+
+func TestTransferDeadlines(t *testing.T) {
+    cfg := config.Default()
+    cfg.Auth.Disabled = true
+    cfg.RateLimit.Disabled = true
+    cfg.Storage.Dir = filepath.Join(t.TempDir(), "blobs")
+    cfg.Server.ReadTimeout = 300 * time.Millisecond
+    cfg.Server.TransferTimeout = 10 * time.Second
+    app := router.NewApp(cfg)
+    server, base, _ := serve(t, app, cfg, nil)
+    defer server.Close()
+
+    // slowPost sends the body in pieces over about a second, well past the read timeout
+    slowPost := func(path string, pieces ...string) (int, model.APIResponse, error) {
+        body, writer := io.Pipe()
+        defer body.Close()
+        go func() {
+            for _, piece := range pieces {
+                time.Sleep(time.Second / time.Duration(len(pieces)))
+                if _, err := io.WriteString(writer, piece); err != nil {
+                    return
+                }
+            }
+            writer.Close()
+        }()
+        resp, err := http.Post(base+path, "application/octet-stream", body)
+        if err != nil {
+            return 0, model.APIResponse{}, err
+        }
+        defer resp.Body.Close()
+        var response model.APIResponse
+        json.NewDecoder(resp.Body).Decode(&response)
+        return resp.StatusCode, response, nil
+    }
+
+    // An upload route reads the whole slow body, which then turns out not to be an e-book
+    code, response, err := slowPost("/books/import", "not ", "an ", "e-book ", "at ", "all")
+    if err != nil || code != http.StatusUnsupportedMediaType || response.Error == nil || response.Error.Code != "UNSUPPORTED_MEDIA_TYPE" {
+        t.Errorf("Expected the upload to be read in full and refused as no e-book but got %d %+v %v", code, response.Error, err)
+    }
+    // Other routes keep the server's read timeout
+    code, _, err = slowPost("/books", `{"title":`, `"Slow",`, `"author":`, `"Writer"`, `}`)
+    if err == nil && code == http.StatusCreated {
+        t.Errorf("Expected a slow request to an ordinary route to time out but got %d", code)
+    }
+}
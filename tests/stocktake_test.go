@@ -0,0 +1,114 @@
+package handler
+
+import (
+    "net/http"
+    "testing"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+func TestStocktakeReportAndMarkLost(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+
+    doJSON(t, r, "POST", "/branches", model.Branch{Code: "CEN", Name: "Central"})
+    doJSON(t, r, "POST", "/branches", model.Branch{Code: "EST", Name: "East"})
+    copies := []map[string]interface{}{
+        {"barcode": "C-1", "homeBranchId": 1, "shelf": "A1"},
+        {"barcode": "C-2", "homeBranchId": 1, "shelf": "A1"},
+        {"barcode": "C-3", "homeBranchId": 1, "shelf": "A2"},
+        {"barcode": "E-1", "homeBranchId": 2, "shelf": "A1"},
+    }
+    for i, body := range copies {
+        if code, _ := doJSON(t, r, "POST", "/books/1/copies", body); code != http.StatusCreated {
+            t.Fatalf("Failed to add copy %d: %d", i+1, code)
+        }
+    }
+
+    var session model.StocktakeSession
+    code, response := doJSON(t, r, "POST", "/stocktakes", map[string]interface{}{"branchId": 1})
+    if code != http.StatusCreated {
+        t.Fatalf("Expected status code %d but got %d", http.StatusCreated, code)
+    }
+    decodeData(t, response, &session)
+
+    // C-1 is in place, C-3 sits on the wrong shelf, E-1 belongs to East and X-9 is not in the catalog
+    doJSON(t, r, "POST", "/stocktakes/1/scans", map[string]interface{}{"shelf": "A1", "barcodes": []string{"C-1", "C-3", "E-1", "X-9"}})
+    if code, _ := doJSON(t, r, "POST", "/stocktakes/1/scans", map[string]interface{}{"shelf": "A1"}); code != http.StatusBadRequest {
+        t.Errorf("Expected scan without barcodes to be rejected but got %d", code)
+    }
+
+    var report model.StocktakeReport
+    _, response = doJSON(t, r, "GET", "/stocktakes/1/report", nil)
+    decodeData(t, response, &report)
+    if report.Expected != 3 || report.Scanned != 4 || report.Found != 1 {
+        t.Errorf("Expected 3 expected, 4 scanned and 1 found but got %+v", report)
+    }
+    if len(report.Missing) != 1 || report.Missing[0].Barcode != "C-2" {
+        t.Errorf("Expected C-2 to be missing but got %+v", report.Missing)
+    }
+    if len(report.Misplaced) != 1 || report.Misplaced[0].ExpectedShelf != "A2" {
+        t.Errorf("Expected C-3 to be misplaced but got %+v", report.Misplaced)
+    }
+    if len(report.Unexpected) != 2 || report.Unexpected[0].Reason != model.StocktakeReasonOtherBranch ||
+        report.Unexpected[1].Reason != model.StocktakeReasonUnknown {
+        t.Errorf("Expected E-1 and X-9 to be unexpected but got %+v", report.Unexpected)
+    }
+
+    if code, _ := doJSON(t, r, "POST", "/stocktakes/1/mark-lost", map[string]interface{}{"copyIds": []int{1}}); code != http.StatusConflict {
+        t.Errorf("Expected marking a found copy lost to conflict but got %d", code)
+    }
+
+    if code, _ := doJSON(t, r, "POST", "/stocktakes/1/close", nil); code != http.StatusOK {
+        t.Fatalf("Expected status code %d but got %d", http.StatusOK, code)
+    }
+    if code, _ := doJSON(t, r, "POST", "/stocktakes/1/scans", map[string]interface{}{"shelf": "A1", "barcodes": []string{"C-2"}}); code != http.StatusConflict {
+        t.Errorf("Expected scanning into a closed session to conflict but got %d", code)
+    }
+
+    var lost []model.Copy
+    _, response = doJSON(t, r, "POST", "/stocktakes/1/mark-lost", nil)
+    decodeData(t, response, &lost)
+    if len(lost) != 1 || lost[0].Barcode != "C-2" || lost[0].Status != model.CopyStatusLost {
+        t.Errorf("Expected C-2 to be marked lost but got %+v", lost)
+    }
+}
+
+func TestStocktakeFinalReport(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+
+    doJSON(t, r, "POST", "/branches", model.Branch{Code: "CEN", Name: "Central"})
+    doJSON(t, r, "POST", "/books/1/copies", map[string]interface{}{"barcode": "C-1", "homeBranchId": 1, "shelf": "A1"})
+    doJSON(t, r, "POST", "/books/1/copies", map[string]interface{}{"barcode": "C-2", "homeBranchId": 1, "shelf": "A1"})
+    doJSON(t, r, "POST", "/patrons", model.Patron{Name: "Ann"})
+    doJSON(t, r, "POST", "/stocktakes", map[string]interface{}{"branchId": 1})
+    doJSON(t, r, "POST", "/stocktakes/1/close", nil)
+
+    // C-1 turns up at the desk after the stocktake and goes out on loan
+    if code, _ := doJSON(t, r, "POST", "/loans", map[string]int{"bookId": 1, "patronId": 1}); code != http.StatusCreated {
+        t.Fatalf("Expected checkout to succeed but got %d", code)
+    }
+
+    // The final report stays as it was when the session closed
+    var report model.StocktakeReport
+    _, response := doJSON(t, r, "GET", "/stocktakes/1/report", nil)
+    decodeData(t, response, &report)
+    if report.ClosedAt == nil || report.Expected != 2 || len(report.Missing) != 2 {
+        t.Errorf("Expected the frozen report with two missing copies but got %+v", report)
+    }
+
+    // Only the copy still unaccounted for is marked lost
+    var lost []model.Copy
+    _, response = doJSON(t, r, "POST", "/stocktakes/1/mark-lost", nil)
+    decodeData(t, response, &lost)
+    if len(lost) != 1 || lost[0].Barcode != "C-2" {
+        t.Errorf("Expected only C-2 to be marked lost but got %+v", lost)
+    }
+    var copies []model.Copy
+    _, response = doJSON(t, r, "GET", "/books/1/copies", nil)
+    decodeData(t, response, &copies)
+    if copies[0].Status != model.CopyStatusOnLoan {
+        t.Errorf("Expected the lent copy to stay on loan but got %s", copies[0].Status)
+    }
+}
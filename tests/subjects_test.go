@@ -0,0 +1,76 @@
+package handler
+
+import (
+    "net/http"
+    "testing"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/router"
+)
+
+func TestSubjectTaxonomyAndFiltering(t *testing.T) {
+    r := router.SetupRouter()
+    setupTestBooks(t, r)
+
+    var science, physics, quantum model.Subject
+    _, response := doJSON(t, r, "POST", "/subjects", model.Subject{Name: "Science"})
+    decodeData(t, response, &science)
+    _, response = doJSON(t, r, "POST", "/subjects", model.Subject{Name: "Physics", BroaderID: science.ID})
+    decodeData(t, response, &physics)
+    _, response = doJSON(t, r, "POST", "/subjects", model.Subject{Name: "Quantum mechanics", BroaderID: physics.ID})
+    decodeData(t, response, &quantum)
+
+    if code, _ := doJSON(t, r, "POST", "/subjects", model.Subject{Name: "physics", BroaderID: science.ID}); code != http.StatusConflict {
+        t.Errorf("Expected duplicate narrower term to conflict but got %d", code)
+    }
+    if code, _ := doJSON(t, r, "PUT", "/subjects/1", model.Subject{Name: "Science", BroaderID: quantum.ID}); code != http.StatusBadRequest {
+        t.Errorf("Expected a cycle in the taxonomy to be rejected but got %d", code)
+    }
+
+    _, response = doJSON(t, r, "GET", "/subjects/1", nil)
+    decodeData(t, response, &science)
+    if len(science.NarrowerIDs) != 1 || science.NarrowerIDs[0] != physics.ID {
+        t.Errorf("Expected Physics as the narrower term of Science but got %v", science.NarrowerIDs)
+    }
+
+    doJSON(t, r, "PUT", "/books/1/subjects", map[string][]int{"subjectIds": {quantum.ID}})
+    doJSON(t, r, "POST", "/books/2/subjects/1", nil)
+    if code, _ := doJSON(t, r, "POST", "/books/3/subjects/99", nil); code != http.StatusBadRequest {
+        t.Errorf("Expected assigning an unknown subject to fail but got %d", code)
+    }
+
+    var book model.Book
+    doJSON(t, r, "PUT", "/books/1/tags", map[string][]string{"tags": {" Classic ", "favourite"}})
+    _, response = doJSON(t, r, "POST", "/books/1/tags", map[string][]string{"tags": {"CLASSIC", "signed"}})
+    decodeData(t, response, &book)
+    if len(book.Tags) != 3 || book.Tags[0] != "classic" {
+        t.Errorf("Expected three normalised tags but got %v", book.Tags)
+    }
+    _, response = doJSON(t, r, "DELETE", "/books/1/tags/signed", nil)
+    decodeData(t, response, &book)
+    if len(book.Tags) != 2 {
+        t.Errorf("Expected tag to be removed but got %v", book.Tags)
+    }
+
+    // Filtering by a broad subject includes books under its narrower terms
+    _, response = doJSON(t, r, "GET", "/books?subject=1", nil)
+    if response.Meta == nil || response.Meta.Count != 2 {
+        t.Errorf("Expected two books under Science but got %+v", response.Meta)
+    }
+    _, response = doJSON(t, r, "GET", "/books?subject=2", nil)
+    if response.Meta == nil || response.Meta.Count != 1 {
+        t.Errorf("Expected one book under Physics but got %+v", response.Meta)
+    }
+    _, response = doJSON(t, r, "GET", "/books?subject=1&tag=classic", nil)
+    var books []model.Book
+    decodeData(t, response, &books)
+    if len(books) != 1 || books[0].ID != 1 {
+        t.Errorf("Expected only book 1 for Science and classic but got %+v", books)
+    }
+
+    var unassigned model.Book
+    _, response = doJSON(t, r, "DELETE", "/books/2/subjects/1", nil)
+    decodeData(t, response, &unassigned)
+    if unassigned.ID != 2 || len(unassigned.SubjectIDs) != 0 {
+        t.Errorf("Expected subject to be unassigned but got %+v", unassigned)
+    }
+}
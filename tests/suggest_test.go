@@ -0,0 +1,177 @@
+package handler
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "testing"
+    "LibraryGo/internal/model"
+    "LibraryGo/internal/repository"
+    "LibraryGo/internal/router"
+)
+
+func TestSuggest(t *testing.T) {
+    r := router.SetupRouter()
+    books := []model.Book{
+        {Title: "The Lord of the Rings", Author: "J. R. R. Tolkien", PublishedYear: 1954},
+        {Title: "The Hobbit", Author: "J. R. R. Tolkien", PublishedYear: 1937},
+        {Title: "Lord of the Flies", Author: "William Golding", PublishedYear: 1954},
+        {Title: "The Lord of the Rings", Author: "J. R. R. Tolkien", PublishedYear: 2004},
+        {Title: "Lolita", Author: "Vladimir Nabokov", PublishedYear: 1955},
+    }
+    for _, book := range books {
+        doJSON(t, r, "POST", "/books", book)
+    }
+
+    var suggestions []model.Suggestion
+    code, response := doJSON(t, r, "GET", "/books/suggest?q=lo&field=title", nil)
+    if code != http.StatusOK {
+        t.Fatalf("Expected status code %d but got %d", http.StatusOK, code)
+    }
+    decodeData(t, response, &suggestions)
+    // Two editions of The Lord of the Rings rank it first; words past the first also match
+    if len(suggestions) != 3 || suggestions[0].Text != "The Lord of the Rings" || suggestions[0].Count != 2 {
+        t.Fatalf("Expected three ranked titles but got %+v", suggestions)
+    }
+
+    _, response = doJSON(t, r, "GET", "/books/suggest?q=tolk", nil)
+    decodeData(t, response, &suggestions)
+    if len(suggestions) != 1 || suggestions[0].Field != model.SuggestFieldAuthor || suggestions[0].Count != 3 {
+        t.Errorf("Expected Tolkien as an author suggestion but got %+v", suggestions)
+    }
+
+    // Deleting books keeps the index in step
+    doJSON(t, r, "DELETE", "/books/3", nil)
+    _, response = doJSON(t, r, "GET", "/books/suggest?q=flies", nil)
+    decodeData(t, response, &suggestions)
+    if len(suggestions) != 0 {
+        t.Errorf("Expected no suggestions for a deleted title but got %+v", suggestions)
+    }
+
+    _, response = doJSON(t, r, "GET", "/books/suggest?q=l", nil)
+    decodeData(t, response, &suggestions)
+    if len(suggestions) != 0 {
+        t.Errorf("Expected no suggestions for a single character but got %+v", suggestions)
+    }
+    if code, _ := doJSON(t, r, "GET", "/books/suggest?q=lo&field=isbn", nil); code != http.StatusBadRequest {
+        t.Errorf("Expected unknown field to be rejected but got %d", code)
+    }
+}
+
+// suggestedTexts lists the text of each suggestion in order
+func suggestedTexts(suggestions []model.Suggestion) []string {
+    texts := make([]string, 0, len(suggestions))
+    for _, suggestion := range suggestions {
+        texts = append(texts, suggestion.Text)
+    }
+    return texts
+}
+
+func TestSuggestFollowsDeletes(t *testing.T) {
+    ctx := context.Background()
+    repo := repository.NewBookRepository(slog.Default())
+    lolita := repo.AddBook(ctx, model.Book{Title: "Lolita", Author: "Vladimir Nabokov", PublishedYear: 1955})
+    repo.AddBook(ctx, model.Book{Title: "Lord of the Flies", Author: "William Golding", PublishedYear: 1954})
+
+    repo.DeleteBookByID(ctx, lolita.ID)
+    if got := repo.Suggest(model.SuggestFieldTitle, "lol", 5); len(got) != 0 {
+        t.Errorf("Expected the deleted title to be gone but got %+v", got)
+    }
+    if got := repo.Suggest(model.SuggestFieldAuthor, "nabokov", 5); len(got) != 0 {
+        t.Errorf("Expected the deleted author to be gone but got %+v", got)
+    }
+
+    // The node the two titles shared ends no key now and is merged into the remaining
+    // branch, which must still be found by a prefix ending part-way along the new edge
+    for _, prefix := range []string{"lo", "lor", "lord of"} {
+        if got := suggestedTexts(repo.Suggest(model.SuggestFieldTitle, prefix, 5)); len(got) != 1 || got[0] != "Lord of the Flies" {
+            t.Errorf("Expected %q to find Lord of the Flies after pruning but got %v", prefix, got)
+        }
+    }
+
+    // Adding the title back splits the merged edge again
+    repo.AddBook(ctx, model.Book{Title: "Lolita", Author: "Vladimir Nabokov", PublishedYear: 1955})
+    if got := suggestedTexts(repo.Suggest(model.SuggestFieldTitle, "lo", 5)); len(got) != 2 || got[0] != "Lolita" || got[1] != "Lord of the Flies" {
+        t.Errorf("Expected both titles after re-adding Lolita but got %v", got)
+    }
+    if got := suggestedTexts(repo.Suggest(model.SuggestFieldTitle, "lol", 5)); len(got) != 1 || got[0] != "Lolita" {
+        t.Errorf("Expected Lolita alone but got %v", got)
+    }
+
+    // Once every book is gone nothing is left to suggest
+    for _, book := range repo.GetAllBooks(ctx) {
+        repo.DeleteBookByID(ctx, book.ID)
+    }
+    if got := repo.Suggest("", "lo", 5); len(got) != 0 {
+        t.Errorf("Expected an empty index but got %+v", got)
+    }
+}
+
+func TestSuggestFollowsUpdates(t *testing.T) {
+    ctx := context.Background()
+    repo := repository.NewBookRepository(slog.Default())
+    book := repo.AddBook(ctx, model.Book{Title: "Lord of the Flies", Author: "William Golding", PublishedYear: 1954})
+    repo.AddBook(ctx, model.Book{Title: "The Inheritors", Author: "William Golding", PublishedYear: 1955})
+
+    book.Title = "The Lord of the Rings"
+    book.Author = "J. R. R. Tolkien"
+    if err := repo.UpdateBook(ctx, book); err != nil {
+        t.Fatalf("Failed to update book: %v", err)
+    }
+
+    if got := repo.Suggest(model.SuggestFieldTitle, "flies", 5); len(got) != 0 {
+        t.Errorf("Expected the old title to be gone but got %+v", got)
+    }
+    if got := suggestedTexts(repo.Suggest(model.SuggestFieldTitle, "rings", 5)); len(got) != 1 || got[0] != "The Lord of the Rings" {
+        t.Errorf("Expected the new title but got %v", got)
+    }
+    if got := repo.Suggest(model.SuggestFieldAuthor, "tolkien", 5); len(got) != 1 || got[0].Count != 1 {
+        t.Errorf("Expected the new author once but got %+v", got)
+    }
+    // The old author still has a book, one fewer than before
+    if got := repo.Suggest(model.SuggestFieldAuthor, "william", 5); len(got) != 1 || got[0].Count != 1 {
+        t.Errorf("Expected the old author with one book left but got %+v", got)
+    }
+}
+
+func TestSuggestRanking(t *testing.T) {
+    ctx := context.Background()
+    repo := repository.NewBookRepository(slog.Default())
+    // Twelve titles share the prefix; Sea 07 has three editions and Sea 11 two
+    for i := 1; i <= 12; i++ {
+        editions := 1
+        switch i {
+        case 7:
+            editions = 3
+        case 11:
+            editions = 2
+        }
+        for edition := 0; edition < editions; edition++ {
+            repo.AddBook(ctx, model.Book{Title: fmt.Sprintf("Sea %02d", i), Author: "Test Author", PublishedYear: 2000 + edition})
+        }
+    }
+
+    // Most editions first, then alphabetically
+    expected := []string{"Sea 07", "Sea 11", "Sea 01", "Sea 02", "Sea 03"}
+    if got := suggestedTexts(repo.Suggest(model.SuggestFieldTitle, "sea", 5)); fmt.Sprint(got) != fmt.Sprint(expected) {
+        t.Errorf("Expected %v but got %v", expected, got)
+    }
+    if got := repo.Suggest(model.SuggestFieldTitle, "sea", 50); len(got) != repository.MaxSuggestions {
+        t.Errorf("Expected at most %d suggestions but got %d", repository.MaxSuggestions, len(got))
+    }
+    if got := suggestedTexts(repo.Suggest(model.SuggestFieldTitle, "sea", 50)); got[len(got)-1] != "Sea 09" {
+        t.Errorf("Expected Sea 09 to be the last ranked title but got %v", got)
+    }
+
+    // Deleting the editions of the leader lets a title from below the cut back in
+    for _, book := range repo.GetAllBooks(ctx) {
+        if book.Title == "Sea 07" {
+            repo.DeleteBookByID(ctx, book.ID)
+        }
+    }
+    got := suggestedTexts(repo.Suggest(model.SuggestFieldTitle, "sea", 50))
+    if len(got) != repository.MaxSuggestions || got[0] != "Sea 11" || got[len(got)-1] != "Sea 10" {
+        t.Errorf("Expected Sea 11 first and Sea 10 back in the ranking but got %v", got)
+    }
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "LibraryGo/internal/config"
+    "LibraryGo/internal/router"
+    "LibraryGo/internal/tracing"
+)
+
+const incomingTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// startCollector runs a fake OpenTelemetry collector and returns the spans it received by name
+func startCollector(t *testing.T) (string, func() map[string]tracing.OTLPSpan) {
+    var mu sync.Mutex
+    spans := make(map[string]tracing.OTLPSpan)
+    collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var request tracing.ExportRequest
+        if r.URL.Path != "/v1/traces" || json.NewDecoder(r.Body).Decode(&request) != nil {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        mu.Lock()
+        defer mu.Unlock()
+        for _, resource := range request.ResourceSpans {
+            for _, scope := range resource.ScopeSpans {
+                for _, span := range scope.Spans {
+                    spans[span.Name] = span
+                }
+            }
+        }
+    }))
+    t.Cleanup(collector.Close)
+    return collector.URL + "/v1/traces", func() map[string]tracing.OTLPSpan {
+        mu.Lock()
+        defer mu.Unlock()
+        return spans
+    }
+}
+
+// attribute looks up an attribute of an exported span
+func attribute(span tracing.OTLPSpan, key string) interface{} {
+    for _, kv := range span.Attributes {
+        if kv.Key == key {
+            return kv.Value.Value()
+        }
+    }
+    return nil
+}
+
+func TestTracing(t *testing.T) {
+    endpoint, received := startCollector(t)
+    cfg := config.Default()
+    cfg.Auth.Disabled = true
+    cfg.RateLimit.Disabled = true
+    cfg.Tracing.Exporter = "otlp"
+    cfg.Tracing.Endpoint = endpoint
+    app := router.NewApp(cfg)
+
+    req, _ := http.NewRequest("POST", "/books", strings.NewReader(`{"title":"Traced","author":"Ada","publishedYear":2020}`))
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("traceparent", incomingTraceparent)
+    w := httptest.NewRecorder()
+    app.Router.ServeHTTP(w, req)
+    if w.Code != http.StatusCreated {
+        t.Fatalf("Expected the book to be added but got %d: %s", w.Code, w.Body.String())
+    }
+    returned, err := tracing.ParseTraceparent(w.Header().Get("traceparent"))
+    if err != nil || returned.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || !returned.Sampled {
+        t.Errorf("Expected the caller's trace in the response but got %q", w.Header().Get("traceparent"))
+    }
+
+    req, _ = http.NewRequest("PUT", "/books/1/tags", strings.NewReader(`{"tags":["traced"]}`))
+    req.Header.Set("Content-Type", "application/json")
+    app.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+    req, _ = http.NewRequest("GET", "/books/99", nil)
+    req.Header.Set("traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+    w = httptest.NewRecorder()
+    app.Router.ServeHTTP(w, req)
+    fresh, err := tracing.ParseTraceparent(w.Header().Get("traceparent"))
+    if err != nil || fresh.TraceID == returned.TraceID {
+        t.Errorf("Expected an invalid traceparent to start a new trace but got %q", w.Header().Get("traceparent"))
+    }
+
+    if err := app.Tracer.Shutdown(context.Background()); err != nil {
+        t.Fatalf("Failed to flush spans: %v", err)
+    }
+    spans := received()
+    server, service, repo := spans["POST /books"], spans["BookService.AddBook"], spans["books.add"]
+    if server.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID != "00f067aa0ba902b7" || server.Kind != tracing.KindServer {
+        t.Fatalf("Expected a server span continuing the caller's trace but got %+v", server)
+    }
+    if server.SpanID != returned.SpanID.String() || attribute(server, "http.route") != "/books" || attribute(server, "http.response.status_code") != int64(201) {
+        t.Errorf("Expected the server span to describe the request but got %+v", server)
+    }
+    if service.ParentSpanID != server.SpanID || repo.ParentSpanID != service.SpanID || repo.TraceID != server.TraceID {
+        t.Errorf("Expected request, service and repository spans to nest but got %+v, %+v and %+v", server, service, repo)
+    }
+    if lookup := spans["BookService.GetBookByID"]; lookup.Status.Code != tracing.StatusError || lookup.ParentSpanID != spans["GET /books/{id}"].SpanID {
+        t.Errorf("Expected the failed lookup to be marked as an error but got %+v", lookup)
+    }
+    if get := spans["books.get"]; get.ParentSpanID != spans["BookService.GetBookByID"].SpanID {
+        t.Errorf("Expected the lookup to trace its repository read but got %+v", get)
+    }
+    if update := spans["books.update"]; update.ParentSpanID != spans["PUT /books/{id}/tags"].SpanID || attribute(update, "db.operation.name") != "update" {
+        t.Errorf("Expected the tag change to trace its repository update but got %+v", update)
+    }
+}
+
+func TestTraceparent(t *testing.T) {
+    sc, err := tracing.ParseTraceparent(incomingTraceparent)
+    if err != nil || sc.Traceparent() != incomingTraceparent {
+        t.Fatalf("Expected the header to round trip but got %q %v", sc.Traceparent(), err)
+    }
+    if sc, err := tracing.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil || sc.Sampled {
+        t.Errorf("Expected a later version to be read as version 00 but got %+v %v", sc, err)
+    }
+    for _, header := range []string{
+        "",
+        "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+        "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+        "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+        "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+        "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+    } {
+        if _, err := tracing.ParseTraceparent(header); err == nil {
+            t.Errorf("Expected %q to be rejected", header)
+        }
+    }
+
+    // The stdout exporter writes a line of OTLP JSON per batch
+    var out bytes.Buffer
+    tracer := tracing.NewTracer("librarygo-test", tracing.NewWriterExporter(&out), slog.New(slog.NewTextHandler(&out, nil)))
+    ctx, span := tracer.Start(context.Background(), "job", tracing.KindInternal)
+    _, child := tracing.Start(ctx, "step", tracing.Int("count", 3))
+    child.End()
+    span.End()
+    tracer.Shutdown(context.Background())
+    var request tracing.ExportRequest
+    if err := json.Unmarshal(out.Bytes(), &request); err != nil || len(request.ResourceSpans) != 1 {
+        t.Fatalf("Expected one batch of OTLP JSON but got %q", out.String())
+    }
+    batch := request.ResourceSpans[0]
+    spans := batch.ScopeSpans[0].Spans
+    if batch.Resource.Attributes[0].Value.Value() != "librarygo-test" || len(spans) != 2 || spans[0].ParentSpanID != spans[1].SpanID {
+        t.Errorf("Expected the child and its parent for the service but got %+v", batch)
+    }
+    if _, none := tracing.Start(context.Background(), "untraced"); none != nil {
+        t.Errorf("Expected no span outside a trace")
+    }
+}
+
+// failingExporter stands in for a collector that is down
+type failingExporter struct{}
+
+func (failingExporter) Export(ctx context.Context, service string, spans []tracing.SpanData) error {
+    return errors.New("connection refused")
+}
+
+func TestFailedExports(t *testing.T) {
+    var logs bytes.Buffer
+    tracer := tracing.NewTracer("librarygo-test", failingExporter{}, slog.New(slog.NewTextHandler(&logs, nil)))
+    // More than fit in one batch, so several exports fail
+    for i := 0; i < 1100; i++ {
+        _, span := tracer.Start(context.Background(), "job", tracing.KindInternal)
+        span.End()
+    }
+    tracer.Shutdown(context.Background())
+
+    if tracer.Dropped() != 1100 {
+        t.Errorf("Expected every span to be counted as dropped but got %d", tracer.Dropped())
+    }
+    // The first failure is logged at once and the rest are summed up at shutdown
+    lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+    if len(lines) != 2 || !strings.Contains(lines[0], "connection refused") || !strings.Contains(lines[1], "failures=2") ||
+        !strings.Contains(lines[1], "dropped_total=1100") {
+        t.Errorf("Expected one warning and a summary at shutdown but got %q", logs.String())
+    }
+}